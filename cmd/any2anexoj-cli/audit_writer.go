@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+)
+
+// AuditWriter exports the audit entries of the report to a file.
+type AuditWriter interface {
+	internal.AuditWriter
+
+	// Close flushes what is left to write. It doesn't close the underlying io.Writer.
+	Close() error
+}
+
+// NewAuditWriter returns a JSON writer when path has the .json extension and a CSV writer
+// otherwise.
+func NewAuditWriter(w io.Writer, path string) AuditWriter {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return NewJSONAuditWriter(w)
+	}
+	return NewCSVAuditWriter(w)
+}
+
+var auditHeader = []string{
+	"symbol", "account", "nature", "short", "buy_value", "sell_value", "fees", "taxes",
+	"realisation_source", "realisation_line", "realisation_side", "realisation_timestamp", "realisation_quantity", "realisation_price",
+	"acquisition_source", "acquisition_line", "acquisition_side", "acquisition_timestamp", "acquisition_quantity", "acquisition_price",
+	"matched_quantity", "lot_filled_before", "lot_filled_after",
+}
+
+// CSVAuditWriter writes one line per audit entry.
+type CSVAuditWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func NewCSVAuditWriter(w io.Writer) *CSVAuditWriter {
+	return &CSVAuditWriter{
+		w: csv.NewWriter(w),
+	}
+}
+
+func (aw *CSVAuditWriter) WriteAudit(_ context.Context, e internal.AuditEntry) error {
+	err := aw.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	line := []string{
+		e.Item.Symbol,
+		e.Item.Account,
+		e.Item.Nature.String(),
+		strconv.FormatBool(e.Item.Short),
+		e.Item.BuyValue.String(),
+		e.Item.SellValue.String(),
+		e.Item.Fees.String(),
+		e.Item.Taxes.String(),
+	}
+	line = append(line, auditRecordFields(e.Realisation)...)
+	line = append(line, auditRecordFields(e.Acquisition)...)
+	line = append(line,
+		e.Quantity.String(),
+		e.LotFilledBefore.String(),
+		e.LotFilledAfter.String(),
+	)
+
+	return aw.w.Write(line)
+}
+
+// Close writes the header, if there were no entries, and flushes the lines.
+func (aw *CSVAuditWriter) Close() error {
+	err := aw.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	aw.w.Flush()
+	return aw.w.Error()
+}
+
+func (aw *CSVAuditWriter) writeHeader() error {
+	if aw.headerWritten {
+		return nil
+	}
+
+	err := aw.w.Write(auditHeader)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	aw.headerWritten = true
+
+	return nil
+}
+
+func auditRecordFields(r internal.AuditRecord) []string {
+	return []string{
+		r.Source,
+		strconv.Itoa(r.Line),
+		r.Side.String(),
+		r.Timestamp.Format(time.RFC3339),
+		r.Quantity.String(),
+		r.Price.String(),
+	}
+}
+
+// JSONAuditWriter writes the audit entries as a JSON array once closed.
+type JSONAuditWriter struct {
+	w       io.Writer
+	entries []jsonAuditEntry
+}
+
+func NewJSONAuditWriter(w io.Writer) *JSONAuditWriter {
+	return &JSONAuditWriter{
+		w:       w,
+		entries: []jsonAuditEntry{},
+	}
+}
+
+type jsonAuditEntry struct {
+	Symbol          string          `json:"symbol"`
+	Account         string          `json:"account,omitempty"`
+	Nature          string          `json:"nature"`
+	Short           bool            `json:"short,omitempty"`
+	BuyValue        string          `json:"buy_value"`
+	SellValue       string          `json:"sell_value"`
+	Fees            string          `json:"fees"`
+	Taxes           string          `json:"taxes"`
+	Realisation     jsonAuditRecord `json:"realisation"`
+	Acquisition     jsonAuditRecord `json:"acquisition"`
+	MatchedQuantity string          `json:"matched_quantity"`
+	LotFilledBefore string          `json:"lot_filled_before"`
+	LotFilledAfter  string          `json:"lot_filled_after"`
+}
+
+type jsonAuditRecord struct {
+	Source    string    `json:"source,omitempty"`
+	Line      int       `json:"line,omitempty"`
+	Side      string    `json:"side"`
+	Timestamp time.Time `json:"timestamp"`
+	Quantity  string    `json:"quantity"`
+	Price     string    `json:"price"`
+}
+
+func newJSONAuditRecord(r internal.AuditRecord) jsonAuditRecord {
+	return jsonAuditRecord{
+		Source:    r.Source,
+		Line:      r.Line,
+		Side:      r.Side.String(),
+		Timestamp: r.Timestamp,
+		Quantity:  r.Quantity.String(),
+		Price:     r.Price.String(),
+	}
+}
+
+func (aw *JSONAuditWriter) WriteAudit(_ context.Context, e internal.AuditEntry) error {
+	aw.entries = append(aw.entries, jsonAuditEntry{
+		Symbol:          e.Item.Symbol,
+		Account:         e.Item.Account,
+		Nature:          e.Item.Nature.String(),
+		Short:           e.Item.Short,
+		BuyValue:        e.Item.BuyValue.String(),
+		SellValue:       e.Item.SellValue.String(),
+		Fees:            e.Item.Fees.String(),
+		Taxes:           e.Item.Taxes.String(),
+		Realisation:     newJSONAuditRecord(e.Realisation),
+		Acquisition:     newJSONAuditRecord(e.Acquisition),
+		MatchedQuantity: e.Quantity.String(),
+		LotFilledBefore: e.LotFilledBefore.String(),
+		LotFilledAfter:  e.LotFilledAfter.String(),
+	})
+
+	return nil
+}
+
+func (aw *JSONAuditWriter) Close() error {
+	enc := json.NewEncoder(aw.w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(aw.entries)
+	if err != nil {
+		return fmt.Errorf("encode audit entries: %w", err)
+	}
+
+	return nil
+}
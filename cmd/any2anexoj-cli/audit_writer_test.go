@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestAuditWriter(t *testing.T) {
+	buyTime := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	sellTime := time.Date(2025, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	entry := internal.AuditEntry{
+		Item: internal.ReportItem{
+			Symbol:        "US0378331005",
+			Account:       "main",
+			Nature:        internal.NatureG01,
+			BuyValue:      decimal.NewFromInt(100),
+			BuyTimestamp:  buyTime,
+			SellValue:     decimal.NewFromInt(150),
+			SellTimestamp: sellTime,
+		},
+		Realisation: internal.AuditRecord{
+			Source:    "statement.csv",
+			Line:      7,
+			Side:      internal.SideSell,
+			Timestamp: sellTime,
+			Quantity:  decimal.NewFromInt(10),
+			Price:     decimal.NewFromInt(15),
+		},
+		Acquisition: internal.AuditRecord{
+			Source:    "statement.csv",
+			Line:      3,
+			Side:      internal.SideBuy,
+			Timestamp: buyTime,
+			Quantity:  decimal.NewFromInt(20),
+			Price:     decimal.NewFromInt(10),
+		},
+		Quantity:        decimal.NewFromInt(10),
+		LotFilledBefore: decimal.NewFromInt(5),
+		LotFilledAfter:  decimal.NewFromInt(15),
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		aw := NewAuditWriter(buf, "audit.csv")
+
+		err := aw.WriteAudit(t.Context(), entry)
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+
+		err = aw.Close()
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+
+		want := strings.Join(auditHeader, ",") + "\n" +
+			"US0378331005,main,G01,false,100,150,0,0," +
+			"statement.csv,7,sell,2025-03-04T10:00:00Z,10,15," +
+			"statement.csv,3,buy,2024-01-02T10:00:00Z,20,10," +
+			"10,5,15\n"
+
+		if buf.String() != want {
+			t.Fatalf("want:\n%s\nbut got:\n%s", want, buf.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		aw := NewAuditWriter(buf, "audit.JSON")
+
+		err := aw.WriteAudit(t.Context(), entry)
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+
+		err = aw.Close()
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+
+		var got []jsonAuditEntry
+		err = json.Unmarshal(buf.Bytes(), &got)
+		if err != nil {
+			t.Fatalf("want a JSON array but got %q: %v", buf.String(), err)
+		}
+
+		if len(got) != 1 {
+			t.Fatalf("want 1 entry but got %d", len(got))
+		}
+
+		if got[0].Realisation.Line != 7 || got[0].Acquisition.Line != 3 || got[0].LotFilledAfter != "15" {
+			t.Fatalf("want the lines and fill state of the entry but got %+v", got[0])
+		}
+	})
+
+	t.Run("json without entries", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		aw := NewAuditWriter(buf, "audit.json")
+
+		err := aw.Close()
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+
+		if strings.TrimSpace(buf.String()) != "[]" {
+			t.Fatalf("want an empty array but got %q", buf.String())
+		}
+	})
+}
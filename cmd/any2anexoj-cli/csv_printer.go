@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+)
+
+// CSVPrinter writes one line per ReportItem with the columns of Anexo J, quadro 9.2 A, and the
+// symbol, to be imported into spreadsheets or other tools.
+type CSVPrinter struct {
+	w *csv.Writer
+}
+
+func NewCSVPrinter(w io.Writer) *CSVPrinter {
+	return &CSVPrinter{
+		w: csv.NewWriter(w),
+	}
+}
+
+var csvHeader = []string{
+	"symbol", "source_country", "code",
+	"realization_date", "realization_value",
+	"acquisition_date", "acquisition_value",
+	"expenses", "foreign_tax_paid", "counter_country",
+}
+
+func (cp *CSVPrinter) Render(aw *internal.AggregatorWriter) error {
+	err := cp.w.Write(csvHeader)
+	if err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for ri := range aw.Iter() {
+		err = cp.w.Write([]string{
+			ri.Symbol, strconv.FormatInt(ri.AssetCountry, 10), string(ri.Nature),
+			ri.SellTimestamp.Format(time.DateOnly), ri.SellValue.StringFixed(2),
+			ri.BuyTimestamp.Format(time.DateOnly), ri.BuyValue.StringFixed(2),
+			ri.Fees.StringFixed(2), ri.Taxes.StringFixed(2), strconv.FormatInt(ri.BrokerCountry, 10),
+		})
+		if err != nil {
+			return fmt.Errorf("write report item: %w", err)
+		}
+	}
+
+	cp.w.Flush()
+
+	return cp.w.Error()
+}
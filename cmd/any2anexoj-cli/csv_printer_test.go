@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestCSVPrinter_Render(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	err := aw.Write(t.Context(), internal.ReportItem{
+		Symbol:        "US0378331005",
+		Nature:        internal.NatureG01,
+		BrokerCountry: 826,
+		AssetCountry:  840,
+		BuyValue:      decimal.NewFromFloat(100.5),
+		BuyTimestamp:  time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC),
+		SellValue:     decimal.NewFromFloat(150.755),
+		SellTimestamp: time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC),
+		Fees:          decimal.NewFromFloat(2.5),
+		Taxes:         decimal.NewFromFloat(5),
+	})
+	if err != nil {
+		t.Fatalf("failed to write report item: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewCSVPrinter(&buf).Render(aw)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	want := `symbol,source_country,code,realization_date,realization_value,acquisition_date,acquisition_value,expenses,foreign_tax_paid,counter_country
+US0378331005,840,G01,2023-06-20,150.76,2023-01-15,100.50,2.50,5.00,826
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("CSVPrinter.Render() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+// HTMLPrinter writes the report as a single HTML page, with the styles and scripts inline, that can
+// be shared as is. Each row can be expanded to show the records it was matched from.
+type HTMLPrinter struct {
+	w          io.Writer
+	translator Translator
+}
+
+func NewHTMLPrinter(w io.Writer, tr Translator) *HTMLPrinter {
+	return &HTMLPrinter{
+		w:          w,
+		translator: tr,
+	}
+}
+
+type htmlReport struct {
+	Items     []htmlItem
+	Totals    *internal.AggregatorWriter
+	Symbols   []internal.SymbolSummary
+	Countries []internal.CountrySummary
+	HasAudit  bool
+}
+
+type htmlItem struct {
+	Row   int
+	Item  internal.ReportItem
+	Audit []internal.AuditEntry
+}
+
+// Render writes the page. The audit entries are the ones behind each item of aw, in the same order,
+// or nil if the rows are not to be expanded.
+func (hp *HTMLPrinter) Render(aw *internal.AggregatorWriter, audit [][]internal.AuditEntry) error {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"t": func(key string, count int) string {
+			return hp.translator.Translate(key, count, nil)
+		},
+		"euros": func(d decimal.Decimal) string {
+			return d.StringFixed(2)
+		},
+		"date": func(t time.Time) string {
+			return t.Format(time.DateOnly)
+		},
+		"datetime": func(t time.Time) string {
+			return t.Format(time.DateTime)
+		},
+		"country": func(code int64) string {
+			return fmt.Sprintf("%d - %s", code, countries.ByNumeric(int(code)).Info().Name)
+		},
+		"records": func(e internal.AuditEntry) []internal.AuditRecord {
+			return []internal.AuditRecord{e.Realisation, e.Acquisition}
+		},
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+
+	data := htmlReport{
+		Totals:   aw,
+		HasAudit: len(audit) > 0,
+	}
+
+	for ri := range aw.Iter() {
+		item := htmlItem{
+			Row:  len(data.Items) + 1,
+			Item: ri,
+		}
+		if len(data.Items) < len(audit) {
+			item.Audit = audit[len(data.Items)]
+		}
+		data.Items = append(data.Items, item)
+	}
+
+	summary := internal.Summarize(aw.Iter())
+	data.Symbols = summary.Symbols
+	data.Countries = summary.Countries
+
+	err = tmpl.Execute(hp.w, data)
+	if err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+
+	return nil
+}
+
+// auditCollector keeps the audit entries in memory for the formats that show them.
+type auditCollector struct {
+	mu      sync.Mutex
+	entries []internal.AuditEntry
+}
+
+func (ac *auditCollector) WriteAudit(_ context.Context, e internal.AuditEntry) error {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.entries = append(ac.entries, e)
+
+	return nil
+}
+
+func (ac *auditCollector) Entries() []internal.AuditEntry {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	return ac.entries
+}
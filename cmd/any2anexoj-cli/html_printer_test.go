@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestHTMLPrinter_Render(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	items := []internal.ReportItem{
+		{
+			Symbol:        "US0378331005",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 826,
+			AssetCountry:  840,
+			Quantity:      decimal.NewFromInt(1),
+			BuyValue:      decimal.NewFromFloat(100.5),
+			BuyTimestamp:  time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(150.755),
+			SellTimestamp: time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC),
+			Fees:          decimal.NewFromFloat(2.5),
+			Taxes:         decimal.NewFromFloat(5),
+		},
+		{
+			Symbol:        "US0378331005",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 826,
+			AssetCountry:  840,
+			Quantity:      decimal.NewFromInt(2),
+			BuyValue:      decimal.NewFromFloat(60),
+			BuyTimestamp:  time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(50),
+			SellTimestamp: time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Symbol:        "<script>",
+			Nature:        internal.NatureG20,
+			BrokerCountry: 826,
+			AssetCountry:  372,
+			BuyValue:      decimal.NewFromFloat(10),
+			BuyTimestamp:  time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(20),
+			SellTimestamp: time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("failed to write report item: %v", err)
+		}
+	}
+
+	audit := [][]internal.AuditEntry{
+		{
+			{
+				Item: items[0],
+				Realisation: internal.AuditRecord{
+					Source:    "statement.csv",
+					Line:      7,
+					Side:      internal.SideSell,
+					Timestamp: items[0].SellTimestamp,
+					Quantity:  decimal.NewFromFloat(1),
+					Price:     decimal.NewFromFloat(150.755),
+				},
+				Acquisition: internal.AuditRecord{
+					Source:    "statement.csv",
+					Line:      3,
+					Side:      internal.SideBuy,
+					Timestamp: items[0].BuyTimestamp,
+					Quantity:  decimal.NewFromFloat(4),
+					Price:     decimal.NewFromFloat(100.5),
+				},
+				Quantity: decimal.NewFromFloat(1),
+			},
+		},
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewHTMLPrinter(&buf, localizer).Render(aw, audit)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	got := buf.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"title", "<title>Capital gains report</title>"},
+		{"row", `<td>2023-06-20</td><td class="num">150.76</td>`},
+		{"acquisition record", `<tr><td>buy</td><td>statement.csv</td><td class="num">3</td><td>2023-01-15 10:00:00</td><td class="num">4</td><td class="num">100.5</td></tr>`},
+		{"realisation record", `<tr><td>sell</td><td>statement.csv</td><td class="num">7</td>`},
+		{"matched quantity", "<p>Matched quantity: 1</p>"},
+		{"totals", `<td class="num">220.76</td>`},
+		{"symbol summary", `<td>US0378331005</td><td>G01</td><td class="num">2</td><td class="num">3</td>`},
+		{"symbol values", `<td class="num">200.76</td><td class="num">160.50</td>`},
+		{"symbol result", `<td class="num">2.50</td><td class="num">5.00</td><td class="num">37.76</td>`},
+		{"country summary", `<td>372 - Ireland</td><td class="num">1</td>`},
+		{"country result", `<td class="num">0.00</td><td class="num">0.00</td><td class="num">10.00</td>`},
+		{"escaped symbol", "<td>&lt;script&gt;</td>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("want output to contain:\n%s\n\nGot:\n%s", tt.want, got)
+			}
+		})
+	}
+
+	// Only the first row has an audit entry to expand
+	if n := strings.Count(got, `<tr class="detail" hidden>`); n != 1 {
+		t.Errorf("want 1 detail row but got %d", n)
+	}
+
+	if strings.Contains(got, "http") {
+		t.Errorf("want no external assets but the page has a link")
+	}
+}
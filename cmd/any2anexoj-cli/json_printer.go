@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+)
+
+type jsonReportItem struct {
+	Symbol                    string `json:"symbol"`
+	Account                   string `json:"account,omitempty"`
+	Nature                    string `json:"code"`
+	SourceCountry             int64  `json:"source_country"`
+	CounterCountry            int64  `json:"counter_country"`
+	RealizationDate           string `json:"realization_date"`
+	RealizationValue          string `json:"realization_value"`
+	AcquisitionDate           string `json:"acquisition_date"`
+	AcquisitionValue          string `json:"acquisition_value"`
+	CorrectedAcquisitionValue string `json:"corrected_acquisition_value,omitempty"`
+	Expenses                  string `json:"expenses"`
+	ForeignTaxPaid            string `json:"foreign_tax_paid"`
+	AccruedInterest           string `json:"accrued_interest,omitempty"`
+	HoldingDays               int    `json:"holding_days"`
+	ShortTerm                 bool   `json:"short_term"`
+	Short                     bool   `json:"short,omitempty"`
+}
+
+func newJSONReportItem(ri internal.ReportItem) jsonReportItem {
+	item := jsonReportItem{
+		Symbol:           ri.Symbol,
+		Account:          ri.Account,
+		Nature:           string(ri.Nature),
+		SourceCountry:    ri.AssetCountry,
+		CounterCountry:   ri.BrokerCountry,
+		RealizationDate:  ri.SellTimestamp.Format(time.DateOnly),
+		RealizationValue: ri.SellValue.StringFixed(2),
+		AcquisitionDate:  ri.BuyTimestamp.Format(time.DateOnly),
+		AcquisitionValue: ri.BuyValue.StringFixed(2),
+		Expenses:         ri.Fees.StringFixed(2),
+		ForeignTaxPaid:   ri.Taxes.StringFixed(2),
+		HoldingDays:      ri.HoldingDays(),
+		ShortTerm:        ri.IsShortTerm(),
+		Short:            ri.Short,
+	}
+
+	if !ri.CorrectedBuyValue.IsZero() {
+		item.CorrectedAcquisitionValue = ri.CorrectedBuyValue.StringFixed(2)
+	}
+
+	if !ri.AccruedInterest.IsZero() {
+		item.AccruedInterest = ri.AccruedInterest.StringFixed(2)
+	}
+
+	return item
+}
+
+type jsonTotals struct {
+	RealizationValue          string `json:"realization_value"`
+	AcquisitionValue          string `json:"acquisition_value"`
+	CorrectedAcquisitionValue string `json:"corrected_acquisition_value"`
+	Expenses                  string `json:"expenses"`
+	ForeignTaxPaid            string `json:"foreign_tax_paid"`
+	AccruedInterest           string `json:"accrued_interest"`
+	ShortTerm                 struct {
+		RealizationValue string `json:"realization_value"`
+		AcquisitionValue string `json:"acquisition_value"`
+		Expenses         string `json:"expenses"`
+		ForeignTaxPaid   string `json:"foreign_tax_paid"`
+	} `json:"short_term"`
+}
+
+type jsonWarning struct {
+	Row     int    `json:"row,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonReport struct {
+	Items    []jsonReportItem `json:"items"`
+	Totals   jsonTotals       `json:"totals"`
+	Warnings []jsonWarning    `json:"warnings"`
+}
+
+// JSONPrinter writes the report as a single JSON document with the items, the totals and the
+// warnings.
+type JSONPrinter struct {
+	output io.Writer
+}
+
+func NewJSONPrinter(w io.Writer) *JSONPrinter {
+	return &JSONPrinter{
+		output: w,
+	}
+}
+
+func (jp *JSONPrinter) Render(aw *internal.AggregatorWriter) error {
+	doc := jsonReport{
+		Items:    []jsonReportItem{},
+		Warnings: []jsonWarning{},
+	}
+
+	for ri := range aw.Iter() {
+		doc.Items = append(doc.Items, newJSONReportItem(ri))
+	}
+
+	doc.Totals.RealizationValue = aw.TotalEarned().StringFixed(2)
+	doc.Totals.AcquisitionValue = aw.TotalSpent().StringFixed(2)
+	doc.Totals.CorrectedAcquisitionValue = aw.TotalCorrectedSpent().StringFixed(2)
+	doc.Totals.Expenses = aw.TotalFees().StringFixed(2)
+	doc.Totals.ForeignTaxPaid = aw.TotalTaxes().StringFixed(2)
+	doc.Totals.AccruedInterest = aw.TotalAccruedInterest().StringFixed(2)
+	doc.Totals.ShortTerm.RealizationValue = aw.ShortTermEarned().StringFixed(2)
+	doc.Totals.ShortTerm.AcquisitionValue = aw.ShortTermSpent().StringFixed(2)
+	doc.Totals.ShortTerm.Expenses = aw.ShortTermFees().StringFixed(2)
+	doc.Totals.ShortTerm.ForeignTaxPaid = aw.ShortTermTaxes().StringFixed(2)
+
+	for _, w := range internal.Warnings(aw) {
+		doc.Warnings = append(doc.Warnings, jsonWarning{Row: w.Row, Code: w.Code, Message: w.Message})
+	}
+
+	enc := json.NewEncoder(jp.output)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+
+	return nil
+}
+
+// NDJSONWriter is a ReportWriter that writes each ReportItem as a line of JSON as soon as it's
+// produced.
+type NDJSONWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (nw *NDJSONWriter) Write(_ context.Context, ri internal.ReportItem) error {
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	err := nw.enc.Encode(newJSONReportItem(ri))
+	if err != nil {
+		return fmt.Errorf("encode report item: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func jsonTestItem() internal.ReportItem {
+	return internal.ReportItem{
+		Symbol:        "US0378331005",
+		Nature:        internal.NatureG01,
+		BrokerCountry: 826,
+		AssetCountry:  840,
+		BuyValue:      decimal.NewFromFloat(100.5),
+		BuyTimestamp:  time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC),
+		SellValue:     decimal.NewFromFloat(150.755),
+		SellTimestamp: time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC),
+		Fees:          decimal.NewFromFloat(2.5),
+		Taxes:         decimal.NewFromFloat(5),
+	}
+}
+
+func TestJSONPrinter_Render(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	err := aw.Write(t.Context(), jsonTestItem())
+	if err != nil {
+		t.Fatalf("failed to write report item: %v", err)
+	}
+
+	unknown := jsonTestItem()
+	unknown.Nature = internal.NatureUnknown
+	err = aw.Write(t.Context(), unknown)
+	if err != nil {
+		t.Fatalf("failed to write report item: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewJSONPrinter(&buf).Render(aw)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	var got jsonReport
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("want a JSON document but got %q: %v", buf.String(), err)
+	}
+
+	want := jsonReportItem{
+		Symbol:           "US0378331005",
+		Nature:           "G01",
+		SourceCountry:    840,
+		CounterCountry:   826,
+		RealizationDate:  "2023-06-20",
+		RealizationValue: "150.76",
+		AcquisitionDate:  "2023-01-15",
+		AcquisitionValue: "100.50",
+		Expenses:         "2.50",
+		ForeignTaxPaid:   "5.00",
+		HoldingDays:      156,
+		ShortTerm:        true,
+	}
+
+	if len(got.Items) != 2 || got.Items[0] != want {
+		t.Fatalf("want 2 items starting with %+v but got %+v", want, got.Items)
+	}
+
+	if got.Totals.RealizationValue != "301.52" || got.Totals.ShortTerm.AcquisitionValue != "201.00" {
+		t.Fatalf("want the totals of the report but got %+v", got.Totals)
+	}
+
+	wantWarning := jsonWarning{Row: 2, Code: internal.WarningUnknownNature, Message: "unknown nature of US0378331005"}
+	if len(got.Warnings) != 1 || got.Warnings[0] != wantWarning {
+		t.Fatalf("want warnings [%+v] but got %+v", wantWarning, got.Warnings)
+	}
+}
+
+func TestNDJSONWriter_Write(t *testing.T) {
+	var buf bytes.Buffer
+	nw := NewNDJSONWriter(&buf)
+
+	for range 2 {
+		err := nw.Write(t.Context(), jsonTestItem())
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines but got %d: %q", len(lines), buf.String())
+	}
+
+	for _, line := range lines {
+		var got jsonReportItem
+		err := json.Unmarshal([]byte(line), &got)
+		if err != nil {
+			t.Fatalf("want a JSON object per line but got %q: %v", line, err)
+		}
+
+		if got.Symbol != "US0378331005" {
+			t.Fatalf("want symbol US0378331005 but got %q", got.Symbol)
+		}
+	}
+}
@@ -1,16 +1,26 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/etoro"
+	"github.com/nmoniz/any2anexoj/internal/generic"
+	"github.com/nmoniz/any2anexoj/internal/scalable"
+	"github.com/nmoniz/any2anexoj/internal/traderepublic"
 	"github.com/nmoniz/any2anexoj/internal/trading212"
+	"github.com/nmoniz/any2anexoj/internal/xtb"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/pflag"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/text/language"
@@ -22,12 +32,157 @@ var platform = pflag.StringP("platform", "p", "trading212", "one of the supporte
 
 var lang = pflag.StringP("language", "l", language.Portuguese.String(), "2 letter language code")
 
-var readerFactories = map[string]func() internal.RecordReader{
-	"trading212": func() internal.RecordReader {
-		return trading212.NewRecordReader(os.Stdin, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}))
+var configPath = pflag.StringP("config", "c", "", "path to the column mapping of the statement (generic platform only)")
+
+var adjustmentsPath = pflag.StringP("adjustments", "a", "", "path to a file, in the native format, with records missing from the statement (i.e.: transfers-in)")
+
+var actionsPath = pflag.String("actions", "", "path to a file with corporate actions (ISIN changes, splits, spin-offs and mergers)")
+
+var brokerMatching = pflag.Bool("broker-matching", false, "report closed positions as matched by the broker instead of matching them with FIFO (xtb and etoro only)")
+
+var exchangeRatesPath = pflag.String("exchange-rates", "", "path to the ECB euro reference rates history (eurofxref-hist.csv) used to convert the values in other currencies to Euros (etoro and xtb only)")
+
+var account = pflag.String("account", "", "account of the statement read from stdin (defaults to the platform)")
+
+var statements = pflag.StringArray("statement", nil, "statement to read instead of stdin as [ACCOUNT=]PLATFORM:PATH; repeat for each account")
+
+var globalFIFO = pflag.Bool("global-fifo", false, "match sells against the lots of every account instead of only the lots of the same account")
+
+var shortSelling = pflag.Bool("short-selling", false, "open a short position when selling more than what is held instead of failing")
+
+var positions = pflag.Bool("positions", false, "also list the lots still held after the last record")
+
+var summary = pflag.Bool("summary", false, "also show the result by symbol and by source country")
+
+var asOf = pflag.String("as-of", "", "date, as 2006-01-02, used to compute the holding period of the positions (defaults to today) or, with analyze, to value them (defaults to the last record)")
+
+var auditPath = pflag.String("audit", "", "path to write how every line of the report was matched, as JSON if it ends in .json or CSV otherwise")
+
+var taxableIncome = pflag.String("taxable-income", "", "other income taxed with the general rates, in Euros, to estimate the tax at the autonomous rate and with englobamento")
+
+var taxBracket = pflag.Int("tax-bracket", 0, "IRS bracket of the taxpayer, from 1 to 9, to show if the gains held for less than 365 days must be aggregated (defaults to the bracket of --taxable-income)")
+
+var declaredYear = pflag.Int("year", 0, "tax year of the tax estimate and of the loss ledger, whose sales are the only ones considered (defaults to the year of the last sale)")
+
+var lossLedgerPath = pflag.String("loss-ledger", "", "path to a JSON file with the net result of each year to carry forward the losses")
+
+var saveLossLedger = pflag.Bool("save-loss-ledger", false, "record the net result of the tax year in --loss-ledger (created if missing)")
+
+var englobamento = pflag.Bool("englobamento", false, "the tax year is declared with englobamento, so its losses are carried forward with --loss-ledger")
+
+var monetaryCorrectionPath = pflag.String("monetary-correction", "", "path to a JSON file with the natures to correct and the monetary correction coefficients by year of acquisition that replace the built-in ones")
+
+var aggregate = pflag.Bool("aggregate", false, "merge the rows of the same security and countries with the same realization and acquisition dates, as allowed by the Anexo J instructions")
+
+var pricesPath = pflag.String("prices", "", "path to a JSON file with the price in Euros of each symbol held at the end, to value the positions with --positions or analyze")
+
+var format = pflag.StringP("format", "f", "pretty", "output format: pretty, csv, json, ndjson, xlsx, html or pdf")
+
+// version is set when building releases with -ldflags "-X main.version=v1.2.3".
+var version string
+
+// toolVersion returns the version of the release or, when built from source, of the module, which
+// is only known when installed with go install.
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok || bi.Main.Version == "" {
+		return "(devel)"
+	}
+
+	return bi.Main.Version
+}
+
+var readerFactories = map[string]func(io.Reader) (internal.RecordReader, error){
+	"trading212": func(r io.Reader) (internal.RecordReader, error) {
+		return trading212.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second})), nil
+	},
+	"traderepublic": func(r io.Reader) (internal.RecordReader, error) {
+		return traderepublic.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second})), nil
+	},
+	"scalable": func(r io.Reader) (internal.RecordReader, error) {
+		return scalable.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second})), nil
+	},
+	"xtb": func(r io.Reader) (internal.RecordReader, error) {
+		// Only the markets outside the euro area need the rates
+		rates := make(map[string]internal.ExchangeRates)
+		if len(*exchangeRatesPath) > 0 {
+			for _, currency := range xtb.Currencies() {
+				er, err := internal.LoadExchangeRates(*exchangeRatesPath, currency)
+				if err != nil {
+					return nil, fmt.Errorf("load exchange rates: %w", err)
+				}
+				rates[currency] = er
+			}
+		}
+
+		return xtb.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}), *brokerMatching, rates), nil
+	},
+	"etoro": func(r io.Reader) (internal.RecordReader, error) {
+		if len(*exchangeRatesPath) == 0 {
+			return nil, fmt.Errorf("--exchange-rates flag is required for the etoro platform")
+		}
+
+		rates, err := internal.LoadExchangeRates(*exchangeRatesPath, etoro.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("load exchange rates: %w", err)
+		}
+
+		return etoro.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}), *brokerMatching, rates), nil
+	},
+	"generic": func(r io.Reader) (internal.RecordReader, error) {
+		if len(*configPath) == 0 {
+			return nil, fmt.Errorf("--config flag is required for the generic platform")
+		}
+
+		cfg, err := generic.LoadConfig(*configPath)
+		if err != nil {
+			return nil, fmt.Errorf("load generic platform config: %w", err)
+		}
+
+		return generic.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}), cfg), nil
+	},
+	"native": func(r io.Reader) (internal.RecordReader, error) {
+		return generic.NewRecordReader(r, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}), generic.NativeConfig()), nil
 	},
 }
 
+// statementSpec identifies a statement file and the account its records belong to.
+type statementSpec struct {
+	Account  string
+	Platform string
+	Path     string
+}
+
+// parseStatementSpec parses values in the format [ACCOUNT=]PLATFORM:PATH. The account defaults to
+// the platform.
+func parseStatementSpec(s string) (statementSpec, error) {
+	var spec statementSpec
+
+	rest := s
+	if acc, after, ok := strings.Cut(s, "="); ok && !strings.Contains(acc, ":") {
+		spec.Account = acc
+		rest = after
+	}
+
+	platform, path, ok := strings.Cut(rest, ":")
+	if !ok || platform == "" || path == "" {
+		return statementSpec{}, fmt.Errorf("invalid statement %q: want [ACCOUNT=]PLATFORM:PATH", s)
+	}
+
+	spec.Platform = platform
+	spec.Path = path
+
+	if spec.Account == "" {
+		spec.Account = platform
+	}
+
+	return spec, nil
+}
+
 func main() {
 	pflag.Parse()
 
@@ -41,14 +196,25 @@ func main() {
 		os.Exit(1)
 	}
 
-	err := run(context.Background(), *platform, *lang)
+	// The only command is analyze, which shows the performance of the portfolio instead of the report
+	var analyze bool
+	switch pflag.Arg(0) {
+	case "":
+	case "analyze":
+		analyze = true
+	default:
+		slog.Error("unknown command", slog.String("command", pflag.Arg(0)))
+		os.Exit(1)
+	}
+
+	err := run(context.Background(), *platform, *lang, analyze)
 	if err != nil {
 		slog.Error("found a fatal issue", slog.Any("err", err))
 		os.Exit(1)
 	}
 }
 
-func run(ctx context.Context, platform, lang string) error {
+func run(ctx context.Context, platform, lang string, analyze bool) error {
 	ctx, cancel := signal.NotifyContext(ctx, os.Kill, os.Interrupt)
 	defer cancel()
 
@@ -56,17 +222,202 @@ func run(ctx context.Context, platform, lang string) error {
 
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
 
-	factory, ok := readerFactories[platform]
-	if !ok {
-		return fmt.Errorf("unsupported platform: %s", platform)
+	var readers []internal.RecordReader
+
+	// mainAccount is where adjustments without an account go.
+	mainAccount := *account
+	if len(*statements) == 0 {
+		if mainAccount == "" {
+			mainAccount = platform
+		}
+
+		reader, err := newAccountReader(os.Stdin, platform, mainAccount)
+		if err != nil {
+			return err
+		}
+
+		readers = append(readers, internal.NewSourceReader(reader, "stdin"))
+	}
+
+	for _, s := range *statements {
+		spec, err := parseStatementSpec(s)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(spec.Path)
+		if err != nil {
+			return fmt.Errorf("open statement: %w", err)
+		}
+		defer f.Close()
+
+		reader, err := newAccountReader(f, spec.Platform, spec.Account)
+		if err != nil {
+			return err
+		}
+
+		readers = append(readers, internal.NewSourceReader(reader, spec.Path))
+
+		if mainAccount == "" {
+			mainAccount = spec.Account
+		}
+	}
+
+	if len(*adjustmentsPath) > 0 {
+		f, err := os.Open(*adjustmentsPath)
+		if err != nil {
+			return fmt.Errorf("open adjustments: %w", err)
+		}
+		defer f.Close()
+
+		adjustments := generic.NewRecordReader(f, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}), generic.NativeConfig())
+
+		readers = append(readers, internal.NewSourceReader(internal.NewAccountReader(adjustments, mainAccount), *adjustmentsPath))
+	}
+
+	// Actions without an account apply to every account so they're not wrapped. Being the last
+	// reader, they also come after any trade with the same timestamp.
+	if len(*actionsPath) > 0 {
+		f, err := os.Open(*actionsPath)
+		if err != nil {
+			return fmt.Errorf("open actions: %w", err)
+		}
+		defer f.Close()
+
+		actions := generic.NewRecordReader(f, internal.NewOpenFIGI(&http.Client{Timeout: 5 * time.Second}), generic.ActionsConfig())
+
+		readers = append(readers, internal.NewSourceReader(actions, *actionsPath))
+	}
+
+	var opts []internal.ReportOption
+	if *globalFIFO {
+		opts = append(opts, internal.WithGlobalFIFO())
+	}
+	if *shortSelling {
+		opts = append(opts, internal.WithShortSelling())
 	}
 
-	reader := factory()
+	if len(*monetaryCorrectionPath) > 0 {
+		mc, err := internal.LoadMonetaryCorrection(*monetaryCorrectionPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, internal.WithMonetaryCorrection(mc))
+	}
 
 	writer := internal.NewAggregatorWriter()
 
+	var at time.Time
+	if len(*asOf) > 0 {
+		var err error
+		at, err = time.Parse(time.DateOnly, *asOf)
+		if err != nil {
+			return fmt.Errorf("parse --as-of: %w", err)
+		}
+	}
+
+	// The unrealised gains are those of the positions
+	if *positions || analyze {
+		opts = append(opts, internal.WithPositions(writer))
+	}
+
+	var recorder *internal.PerformanceRecorder
+	if analyze {
+		if *format != "pretty" {
+			return fmt.Errorf("analyze only supports --format=pretty")
+		}
+
+		recorder = internal.NewPerformanceRecorder()
+	}
+
+	if _, ok := renderers[*format]; !ok {
+		return fmt.Errorf("unsupported format: %s", *format)
+	}
+
+	// The other formats mirror the declaration and have nowhere to show these
+	if *format != "pretty" {
+		if name, ok := prettyOnlyFlag(); ok {
+			return fmt.Errorf("--%s is only supported with --format=pretty", name)
+		}
+	}
+
+	var prices map[string]decimal.Decimal
+	if len(*pricesPath) > 0 {
+		if !*positions && !analyze {
+			return fmt.Errorf("--prices requires --positions or analyze")
+		}
+
+		var err error
+		prices, err = internal.LoadPrices(*pricesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The rows are only merged once the report is complete but NDJSON writes them as they come
+	if *aggregate && *format == "ndjson" {
+		return fmt.Errorf("--aggregate is not supported with --format=ndjson")
+	}
+
+	if *taxBracket < 0 {
+		return fmt.Errorf("invalid --tax-bracket: %d", *taxBracket)
+	}
+
+	if *declaredYear < 0 {
+		return fmt.Errorf("invalid --year: %d", *declaredYear)
+	}
+
+	if (*saveLossLedger || *englobamento) && len(*lossLedgerPath) == 0 {
+		return fmt.Errorf("--save-loss-ledger and --englobamento require --loss-ledger")
+	}
+
+	var income decimal.Decimal
+	if len(*taxableIncome) > 0 {
+		var err error
+		income, err = decimal.NewFromString(*taxableIncome)
+		if err != nil {
+			return fmt.Errorf("parse --taxable-income: %w", err)
+		}
+	}
+
+	var audits []internal.AuditWriter
+
+	var audit AuditWriter
+	if len(*auditPath) > 0 {
+		f, err := os.Create(*auditPath)
+		if err != nil {
+			return fmt.Errorf("create audit: %w", err)
+		}
+		defer f.Close()
+
+		audit = NewAuditWriter(f, *auditPath)
+		audits = append(audits, audit)
+	}
+
+	// The HTML page shows the records behind each row and the PDF the exchange rates and sources of
+	// the natures of the records
+	var collector *auditCollector
+	if *format == "html" || *format == "pdf" {
+		collector = &auditCollector{}
+		audits = append(audits, collector)
+	}
+
+	if len(audits) > 0 {
+		opts = append(opts, internal.WithAudit(internal.NewMultiAuditWriter(audits...)))
+	}
+
+	var reportWriter internal.ReportWriter = writer
+	if *format == "ndjson" {
+		reportWriter = internal.NewMultiWriter(writer, NewNDJSONWriter(os.Stdout))
+	}
+
+	var reader internal.RecordReader = internal.NewMergeReader(readers...)
+	if recorder != nil {
+		reader = recorder.Reader(reader)
+	}
+
 	eg.Go(func() error {
-		return internal.BuildReport(ctx, reader, writer)
+		return internal.BuildReport(ctx, reader, reportWriter, opts...)
 	})
 
 	err := eg.Wait()
@@ -74,14 +425,211 @@ func run(ctx context.Context, platform, lang string) error {
 		return err
 	}
 
+	if audit != nil {
+		err = audit.Close()
+		if err != nil {
+			return fmt.Errorf("close audit: %w", err)
+		}
+	}
+
+	if recorder != nil {
+		return renderPerformance(recorder, writer, prices, at, lang)
+	}
+
+	// Every row of the report is one item, unless they're merged
+	var rows [][]int
+	if *aggregate {
+		writer, rows = writer.Aggregate()
+	}
+
+	out := report{
+		aw:      writer,
+		bracket: *taxBracket,
+	}
+
+	if collector != nil {
+		out.audit, err = groupAudit(collector.Entries(), rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *positions {
+		out.positionsDate = cmp.Or(at, time.Now())
+		out.prices = prices
+	}
+
+	if *summary {
+		s := internal.Summarize(writer.Iter())
+		out.summary = &s
+	}
+
+	out.year = taxYear(writer, *declaredYear)
+
+	// The general rates are the ones of the year declared
+	if *taxBracket != 0 || len(*taxableIncome) > 0 {
+
+		out.taxBrackets, err = internal.TaxBracketsOf(out.year)
+		if err != nil {
+			return err
+		}
+
+		if *taxBracket > len(out.taxBrackets) {
+			return fmt.Errorf("invalid --tax-bracket: %d", *taxBracket)
+		}
+
+		if len(*taxableIncome) > 0 {
+			est, err := internal.EstimateTax(writer, income, out.bracket, out.year)
+			if err != nil {
+				return err
+			}
+			out.taxEstimate = &est
+			// The bracket of the taxable income, including the short-term gains, unless declared
+			out.bracket = est.Bracket
+		}
+	}
+
+	if len(*lossLedgerPath) > 0 {
+		out.carryForward, err = carryForward(writer, *lossLedgerPath, out.year, *englobamento, *saveLossLedger)
+		if err != nil {
+			return err
+		}
+	}
+
 	loc, err := NewLocalizer(lang)
 	if err != nil {
 		return fmt.Errorf("create localizer: %w", err)
 	}
 
-	printer := NewPrettyPrinter(os.Stdout, loc)
+	return renderers[*format](os.Stdout, loc, out)
+}
+
+// taxYear returns the declared year or, if it's 0, the year of the last disposal of the report or, if
+// there is none, the previous year, which is the one usually declared.
+func taxYear(aw *internal.AggregatorWriter, declared int) int {
+	if declared != 0 {
+		return declared
+	}
+
+	var year int
+	for ri := range aw.Iter() {
+		year = max(year, ri.SellTimestamp.Year())
+	}
+
+	if year == 0 {
+		return time.Now().Year() - 1
+	}
+
+	return year
+}
+
+// prettyOnlyFlag returns the name of the first flag set that only changes the pretty format, if any.
+func prettyOnlyFlag() (string, bool) {
+	flags := []struct {
+		name string
+		set  bool
+	}{
+		{"positions", *positions},
+		{"summary", *summary},
+		{"prices", len(*pricesPath) > 0},
+		{"tax-bracket", *taxBracket != 0},
+		{"taxable-income", len(*taxableIncome) > 0},
+		{"year", *declaredYear != 0},
+		{"loss-ledger", len(*lossLedgerPath) > 0},
+	}
 
-	printer.Render(writer)
+	for _, f := range flags {
+		if f.set {
+			return f.name, true
+		}
+	}
+
+	return "", false
+}
+
+// renderPerformance writes the performance of the records read by recorder, with the positions held
+// valued at prices, if any.
+func renderPerformance(recorder *internal.PerformanceRecorder, aw *internal.AggregatorWriter, prices map[string]decimal.Decimal, at time.Time, lang string) error {
+	loc, err := NewLocalizer(lang)
+	if err != nil {
+		return fmt.Errorf("create localizer: %w", err)
+	}
+
+	NewPrettyPrinter(os.Stdout, loc).RenderPerformance(recorder.Performance(aw, prices, at))
 
 	return nil
 }
+
+// groupAudit returns the audit entries of each row of the report given the indexes of the items
+// merged into each row, which is nil if every row is a single item. The entries are in the same
+// order as the items so every item must have exactly one entry.
+func groupAudit(entries []internal.AuditEntry, rows [][]int) ([][]internal.AuditEntry, error) {
+	if rows == nil {
+		grouped := make([][]internal.AuditEntry, len(entries))
+		for i, e := range entries {
+			grouped[i] = []internal.AuditEntry{e}
+		}
+		return grouped, nil
+	}
+
+	var count int
+	grouped := make([][]internal.AuditEntry, len(rows))
+	for i, items := range rows {
+		for _, item := range items {
+			if item < 0 || item >= len(entries) {
+				return nil, fmt.Errorf("missing audit entry of item %d in row %d", item, i+1)
+			}
+			grouped[i] = append(grouped[i], entries[item])
+		}
+		count += len(items)
+	}
+
+	if count != len(entries) {
+		return nil, fmt.Errorf("audit with %d entries for %d items", len(entries), count)
+	}
+
+	return grouped, nil
+}
+
+// carryForward records the net result of the year of the report in the ledger, with the
+// englobamento option, and returns the losses carried forward into that year. The previous years
+// come from the ledger. The ledger file is only updated if save is true. Returns nil if the report
+// is empty.
+func carryForward(aw *internal.AggregatorWriter, path string, year int, englobamento, save bool) (*internal.CarryForward, error) {
+	ledger, err := internal.LoadLossLedger(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(internal.NetResultsByYear(aw)) == 0 {
+		return nil, nil
+	}
+
+	ledger.RecordYear(aw, year, englobamento)
+
+	if save {
+		err = internal.SaveLossLedger(path, ledger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cf := ledger.CarryForward(year)
+
+	return &cf, nil
+}
+
+// newAccountReader returns a reader for the statement in r that assigns account to its records.
+func newAccountReader(r io.Reader, platform, account string) (internal.RecordReader, error) {
+	factory, ok := readerFactories[platform]
+	if !ok {
+		return nil, fmt.Errorf("unsupported platform: %s", platform)
+	}
+
+	reader, err := factory(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return internal.NewAccountReader(reader, account), nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseStatementSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    statementSpec
+		wantErr bool
+	}{
+		{"platform and path", "xtb:statement.xlsx", statementSpec{Account: "xtb", Platform: "xtb", Path: "statement.xlsx"}, false},
+		{"with account", "joint=trading212:/tmp/a.csv", statementSpec{Account: "joint", Platform: "trading212", Path: "/tmp/a.csv"}, false},
+		{"path with equals", "native:dir/a=b.csv", statementSpec{Account: "native", Platform: "native", Path: "dir/a=b.csv"}, false},
+		{"missing path", "trading212", statementSpec{}, true},
+		{"empty platform", "joint=:a.csv", statementSpec{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatementSpec(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("want %+v but got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGroupAudit(t *testing.T) {
+	entries := []internal.AuditEntry{
+		{Item: internal.ReportItem{Symbol: "A"}},
+		{Item: internal.ReportItem{Symbol: "B"}},
+		{Item: internal.ReportItem{Symbol: "A"}},
+	}
+
+	tests := []struct {
+		name    string
+		rows    [][]int
+		want    [][]string
+		wantErr bool
+	}{
+		{"not aggregated", nil, [][]string{{"A"}, {"B"}, {"A"}}, false},
+		{"aggregated", [][]int{{0, 2}, {1}}, [][]string{{"A", "A"}, {"B"}}, false},
+		{"missing entries", [][]int{{0, 2}, {3}}, nil, true},
+		{"entries without item", [][]int{{0}, {1}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grouped, err := groupAudit(entries, tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+
+			var got [][]string
+			for _, row := range grouped {
+				var symbols []string
+				for _, e := range row {
+					symbols = append(symbols, e.Item.Symbol)
+				}
+				got = append(got, symbols)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("want %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTaxYear(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+	for _, year := range []int{2024, 2025, 2023} {
+		err := aw.Write(t.Context(), internal.ReportItem{SellTimestamp: time.Date(year, 6, 1, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		declared int
+		want     int
+	}{
+		{"last sale", 0, 2025},
+		{"declared", 2024, 2024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taxYear(aw, tt.declared); got != tt.want {
+				t.Fatalf("want %d but got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCarryForward(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+	items := []internal.ReportItem{
+		{SellTimestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(50)},
+		{SellTimestamp: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(150)},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "losses.json")
+
+	ledger := internal.NewLossLedger()
+	ledger.Record(2023, decimal.NewFromInt(-50), false)
+	err := internal.SaveLossLedger(path, ledger)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	cf, err := carryForward(aw, path, 2024, true, true)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+	if cf.Year != 2024 || !cf.Deducted.IsZero() {
+		t.Fatalf("want nothing deducted from 2024 but got %+v", cf)
+	}
+
+	saved, err := internal.LoadLossLedger(path)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	if prev := saved.CarryForward(2023); prev.Englobamento {
+		t.Fatalf("want 2023 kept without englobamento")
+	}
+	if got := saved.CarryForward(2024); !got.Englobamento || !got.NetResult.Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("want 2024 recorded with englobamento and net result 50 but got %+v", got)
+	}
+}
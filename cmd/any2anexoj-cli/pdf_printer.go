@@ -0,0 +1,232 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/pdf"
+	"github.com/shopspring/decimal"
+)
+
+// PDFPrinter writes a document to archive with the declaration: the rows of Anexo J, the totals,
+// the exchange rates and sources of the natures behind them and the version of the tool.
+type PDFPrinter struct {
+	w          io.Writer
+	translator Translator
+	version    string
+	at         time.Time
+}
+
+// NewPDFPrinter returns a printer of documents generated by the given version of the tool at the
+// given time.
+func NewPDFPrinter(w io.Writer, tr Translator, version string, at time.Time) *PDFPrinter {
+	return &PDFPrinter{
+		w:          w,
+		translator: tr,
+		version:    version,
+		at:         at,
+	}
+}
+
+// Render writes the document. The exchange rates and sources of the natures come from the audit
+// entries so those sections are empty without them.
+func (pp *PDFPrinter) Render(aw *internal.AggregatorWriter, audit []internal.AuditEntry) error {
+	title := pp.translator.Translate("report_title", 1, nil)
+
+	doc := pdf.NewWriter(pp.w, pdf.Metadata{
+		Title:    title,
+		Producer: "any2anexoj " + pp.version,
+		Created:  pp.at,
+	})
+
+	doc.Heading(title)
+	doc.Line(pp.translator.Translate("generated_by", 1, map[string]any{
+		"Version": pp.version,
+		"Date":    pp.at.Format(time.DateTime),
+	}))
+	doc.Line("")
+
+	doc.Heading("Anexo J")
+	doc.Line(pp.itemsTable(aw))
+
+	// The accrued interest of bonds is not part of the table because it's declared as income
+	if !aw.TotalAccruedInterest().IsZero() {
+		doc.Line(fmt.Sprintf("%s: %s €", pp.translator.Translate("accrued_interest", 1, nil), aw.TotalAccruedInterest().StringFixed(2)))
+	}
+	doc.Line("")
+
+	doc.Heading(pp.translator.Translate("exchange_rates", 2, nil))
+	doc.Line(pp.exchangeRatesTable(audit))
+	doc.Line("")
+
+	doc.Heading(pp.translator.Translate("nature_sources", 2, nil))
+	doc.Line(pp.natureSourcesTable(audit))
+
+	err := doc.Close()
+	if err != nil {
+		return fmt.Errorf("write pdf: %w", err)
+	}
+
+	return nil
+}
+
+func (pp *PDFPrinter) itemsTable(aw *internal.AggregatorWriter) string {
+	// The widths are limited for the table to fit in the page, with the headers broken in lines.
+	tw := pdfTable(
+		pdfColumn(1, text.AlignLeft, 12),
+		pdfColumn(2, text.AlignRight, 8),
+		pdfColumn(3, text.AlignLeft, 6),
+		pdfColumn(4, text.AlignLeft, 11),
+		pdfColumn(5, text.AlignRight, 12),
+		pdfColumn(6, text.AlignLeft, 11),
+		pdfColumn(7, text.AlignRight, 12),
+		pdfColumn(8, text.AlignRight, 12),
+		pdfColumn(9, text.AlignRight, 12),
+		pdfColumn(10, text.AlignRight, 11),
+	)
+	tw.SetAutoIndex(true)
+
+	tw.AppendHeader(table.Row{
+		pp.translator.Translate("symbol", 1, nil), pp.translator.Translate("source_country", 1, nil), pp.translator.Translate("code", 1, nil),
+		pp.translator.Translate("realization_date", 1, nil), pp.translator.Translate("realization_value", 1, nil),
+		pp.translator.Translate("acquisition_date", 1, nil), pp.translator.Translate("acquisition_value", 1, nil),
+		pp.translator.Translate("expenses", 2, nil), pp.translator.Translate("foreign_tax_paid", 1, nil), pp.translator.Translate("counter_country", 1, nil),
+	})
+
+	for ri := range aw.Iter() {
+		tw.AppendRow(table.Row{
+			ri.Symbol, ri.AssetCountry, ri.Nature,
+			ri.SellTimestamp.Format(time.DateOnly), ri.SellValue.StringFixed(2),
+			ri.BuyTimestamp.Format(time.DateOnly), ri.BuyValue.StringFixed(2),
+			ri.Fees.StringFixed(2), ri.Taxes.StringFixed(2), ri.BrokerCountry,
+		})
+	}
+
+	tw.AppendFooter(table.Row{
+		pp.translator.Translate("total", 1, nil), "", "", "", aw.TotalEarned().StringFixed(2),
+		"", aw.TotalSpent().StringFixed(2), aw.TotalFees().StringFixed(2), aw.TotalTaxes().StringFixed(2),
+	})
+
+	return tw.Render()
+}
+
+type exchangeRate struct {
+	currency string
+	date     string
+	rate     decimal.Decimal
+}
+
+// exchangeRatesTable lists every rate used to convert the records behind the report, once per day.
+func (pp *PDFPrinter) exchangeRatesTable(audit []internal.AuditEntry) string {
+	var rates []exchangeRate
+	for _, e := range audit {
+		for _, r := range []internal.AuditRecord{e.Realisation, e.Acquisition} {
+			if r.Currency == "" {
+				continue
+			}
+
+			rate := exchangeRate{currency: r.Currency, date: r.Timestamp.Format(time.DateOnly), rate: r.ExchangeRate}
+			if !slices.ContainsFunc(rates, func(o exchangeRate) bool {
+				return o.currency == rate.currency && o.date == rate.date && o.rate.Equal(rate.rate)
+			}) {
+				rates = append(rates, rate)
+			}
+		}
+	}
+
+	if len(rates) == 0 {
+		return pp.translator.Translate("none", 1, nil)
+	}
+
+	slices.SortFunc(rates, func(a, b exchangeRate) int {
+		return cmp.Or(cmp.Compare(a.currency, b.currency), cmp.Compare(a.date, b.date), a.rate.Cmp(b.rate))
+	})
+
+	tw := pdfTable(pdfColumn(3, text.AlignRight, 0))
+	tw.AppendHeader(table.Row{
+		pp.translator.Translate("currency", 1, nil), pp.translator.Translate("day", 1, nil), pp.translator.Translate("units_per_euro", 1, nil),
+	})
+	for _, r := range rates {
+		tw.AppendRow(table.Row{r.currency, r.date, r.rate.String()})
+	}
+
+	return tw.Render()
+}
+
+type natureSource struct {
+	symbol string
+	nature internal.Nature
+	source internal.NatureSource
+}
+
+// natureSourcesTable lists where the nature of each symbol came from. The nature of a row is the one
+// of the acquisition.
+func (pp *PDFPrinter) natureSourcesTable(audit []internal.AuditEntry) string {
+	var sources []natureSource
+	for _, e := range audit {
+		ns := natureSource{symbol: e.Item.Symbol, nature: e.Item.Nature, source: e.Acquisition.NatureSource}
+		if !slices.Contains(sources, ns) {
+			sources = append(sources, ns)
+		}
+	}
+
+	if len(sources) == 0 {
+		return pp.translator.Translate("none", 1, nil)
+	}
+
+	slices.SortFunc(sources, func(a, b natureSource) int {
+		return cmp.Or(cmp.Compare(a.symbol, b.symbol), cmp.Compare(a.nature, b.nature), cmp.Compare(a.source, b.source))
+	})
+
+	tw := pdfTable()
+	tw.AppendHeader(table.Row{
+		pp.translator.Translate("symbol", 1, nil), pp.translator.Translate("code", 1, nil), pp.translator.Translate("source", 1, nil),
+	})
+	for _, s := range sources {
+		tw.AppendRow(table.Row{s.symbol, s.nature, pp.natureSourceName(s.source)})
+	}
+
+	return tw.Render()
+}
+
+func (pp *PDFPrinter) natureSourceName(ns internal.NatureSource) string {
+	switch ns {
+	case internal.NatureSourceStatement:
+		return pp.translator.Translate("statement", 1, nil)
+	case internal.NatureSourceOpenFIGI:
+		return "OpenFIGI"
+	default:
+		return pp.translator.Translate("unknown", 1, nil)
+	}
+}
+
+// pdfTable returns a table in plain ASCII, since the PDF fonts have no box drawing characters.
+func pdfTable(configs ...table.ColumnConfig) table.Writer {
+	tw := table.NewWriter()
+	tw.SetStyle(table.StyleDefault)
+	tw.Style().Format.Header = text.FormatDefault
+	tw.Style().Format.Footer = text.FormatDefault
+	tw.SetColumnConfigs(configs)
+
+	return tw
+}
+
+// pdfColumn returns the config of the column n with the given alignment and maximum width, or no
+// limit if 0.
+func pdfColumn(n int, align text.Align, widthMax int) table.ColumnConfig {
+	return table.ColumnConfig{
+		Number:      n,
+		Align:       align,
+		AlignHeader: align,
+		AlignFooter: align,
+		WidthMax:    widthMax,
+		// Break the headers between words
+		WidthMaxEnforcer: text.WrapSoft,
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestPDFPrinter_Render(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	items := []internal.ReportItem{
+		{
+			Symbol:        "US0378331005",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 826,
+			AssetCountry:  840,
+			BuyValue:      decimal.NewFromFloat(100.5),
+			BuyTimestamp:  time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(150.755),
+			SellTimestamp: time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC),
+			Fees:          decimal.NewFromFloat(2.5),
+			Taxes:         decimal.NewFromFloat(5),
+		},
+		{
+			Symbol:        "IE00B4L5Y983",
+			Nature:        internal.NatureG20,
+			BrokerCountry: 826,
+			AssetCountry:  372,
+			BuyValue:      decimal.NewFromFloat(60),
+			BuyTimestamp:  time.Date(2023, 2, 1, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(50),
+			SellTimestamp: time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("failed to write report item: %v", err)
+		}
+	}
+
+	audit := []internal.AuditEntry{
+		{
+			Item: items[0],
+			Realisation: internal.AuditRecord{
+				Side:         internal.SideSell,
+				Timestamp:    items[0].SellTimestamp,
+				Currency:     "USD",
+				ExchangeRate: decimal.RequireFromString("1.08"),
+				NatureSource: internal.NatureSourceOpenFIGI,
+			},
+			Acquisition: internal.AuditRecord{
+				Side:         internal.SideBuy,
+				Timestamp:    items[0].BuyTimestamp,
+				Currency:     "USD",
+				ExchangeRate: decimal.RequireFromString("1.1"),
+				NatureSource: internal.NatureSourceOpenFIGI,
+			},
+		},
+		{
+			Item: items[1],
+			Realisation: internal.AuditRecord{
+				Side:         internal.SideSell,
+				Timestamp:    items[1].SellTimestamp,
+				NatureSource: internal.NatureSourceStatement,
+			},
+			Acquisition: internal.AuditRecord{
+				Side:         internal.SideBuy,
+				Timestamp:    items[1].BuyTimestamp,
+				NatureSource: internal.NatureSourceStatement,
+			},
+		},
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewPDFPrinter(&buf, localizer, "v1.2.3", time.Date(2025, 3, 4, 10, 30, 0, 0, time.UTC)).Render(aw, audit)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	got := buf.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"producer", "/Producer (any2anexoj v1.2.3)"},
+		{"version", "(Generated by any2anexoj v1.2.3 on 2025-03-04 10:30:00) Tj"},
+		{"row", "| 1 | US0378331005 |      840 | G01  | 2023-06-20  |       150.76 | 2023-01-15  |       100.50 |         2.50 |         5.00 |         826 |"},
+		{"totals", "|   | Total        |          |      |             |       200.76 |             |       160.50 |         2.50 |         5.00 |             |"},
+		{"buy rate", "| USD      | 2023-01-15 |           1.1 |"},
+		{"sell rate", "| USD      | 2023-06-20 |          1.08 |"},
+		{"openfigi source", "| US0378331005 | G01  | OpenFIGI  |"},
+		{"statement source", "| IE00B4L5Y983 | G20  | Statement |"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("want document to contain:\n%s\n\nGot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPDFPrinter_RenderWithoutAudit(t *testing.T) {
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewPDFPrinter(&buf, localizer, "v1.2.3", time.Date(2025, 3, 4, 10, 30, 0, 0, time.UTC)).Render(internal.NewAggregatorWriter(), nil)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	if n := strings.Count(buf.String(), "(None) Tj"); n != 2 {
+		t.Errorf("want no exchange rates nor sources of natures but got %d empty sections", n)
+	}
+}
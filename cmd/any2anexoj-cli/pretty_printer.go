@@ -3,11 +3,14 @@ package main
 import (
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/biter777/countries"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
 )
 
 // PrettyPrinter writes a simple, human readable, table row to the provided io.Writer for each
@@ -77,6 +80,375 @@ func (pp *PrettyPrinter) Render(aw *internal.AggregatorWriter) {
 
 	pp.table.AppendFooter(table.Row{"SUM", "SUM", "SUM", "SUM", "SUM", aw.TotalEarned(), "", "", "", aw.TotalSpent(), aw.TotalFees(), aw.TotalTaxes()}, table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignRight})
 	pp.table.Render()
+
+	// The accrued interest of bonds is not part of the table because it's declared as income
+	if !aw.TotalAccruedInterest().IsZero() {
+		fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("accrued_interest", 1, nil), aw.TotalAccruedInterest().StringFixed(2))
+	}
+
+	// The declaration has the nominal values and the correction is applied by the AT
+	if !aw.TotalCorrectedSpent().Equal(aw.TotalSpent()) {
+		fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("corrected_acquisition", 1, nil), aw.TotalCorrectedSpent().StringFixed(2))
+	}
+}
+
+// RenderPositions writes a table with the positions still open, including when each one completes
+// 365 days and 2 years held, which are the usual thresholds for the taxation of gains. When prices
+// is not nil the positions are also valued at those prices, by symbol, with their unrealised gain.
+func (pp *PrettyPrinter) RenderPositions(aw *internal.AggregatorWriter, at time.Time, prices map[string]decimal.Decimal) {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetAutoIndex(true)
+	tw.SetStyle(table.StyleLight)
+
+	header := table.Row{
+		pp.translator.Translate("symbol", 1, nil), pp.translator.Translate("account", 1, nil),
+		pp.translator.Translate("quantity", 1, nil), pp.translator.Translate("acquisition", 1, nil),
+		pp.translator.Translate("cost", 1, nil),
+	}
+	if prices != nil {
+		header = append(header, pp.translator.Translate("market_value", 1, nil), pp.translator.Translate("unrealised_gain", 1, nil))
+	}
+	header = append(header,
+		pp.translator.Translate("days_held", 2, nil),
+		pp.translator.Translate("held_for", 365, map[string]any{"Count": 365, "Unit": pp.translator.Translate("day", 2, nil)}),
+		pp.translator.Translate("held_for", 2, map[string]any{"Count": 2, "Unit": pp.translator.Translate("year", 2, nil)}),
+	)
+
+	notAvailable := pp.translator.Translate("not_available", 1, nil)
+
+	configs := make([]table.ColumnConfig, 0, len(header))
+	for i := range header {
+		switch {
+		case i == 4:
+			configs = append(configs, colEuros(i+1))
+		case prices != nil && (i == 5 || i == 6):
+			// Positions without a price have no value
+			cfg := colEuros(i + 1)
+			cfg.Transformer = func(val any) string {
+				if val == nil {
+					return notAvailable
+				}
+				return fmt.Sprintf("%v €", val)
+			}
+			configs = append(configs, cfg)
+		default:
+			configs = append(configs, colOther(i+1))
+		}
+	}
+	tw.SetColumnConfigs(configs)
+	tw.AppendHeader(header)
+
+	var total, totalValue, totalUnrealised decimal.Decimal
+	for p := range aw.Positions() {
+		quantity := p.Quantity.String()
+		if p.Short {
+			quantity = "-" + quantity
+		}
+
+		row := table.Row{p.Symbol, p.Account, quantity, p.Timestamp.Format(time.DateOnly), p.Cost.StringFixed(2)}
+
+		if prices != nil {
+			price, ok := prices[p.Symbol]
+			if ok {
+				value, unrealised := p.Value(price), p.UnrealisedPnL(price)
+				row = append(row, value.StringFixed(2), unrealised.StringFixed(2))
+
+				if !p.Short {
+					totalValue = totalValue.Add(value.Round(2))
+				}
+				totalUnrealised = totalUnrealised.Add(unrealised.Round(2))
+			} else {
+				row = append(row, nil, nil)
+			}
+		}
+
+		row = append(row, p.DaysHeld(at), p.HeldFor(internal.ShortTermDays).Format(time.DateOnly), p.Timestamp.AddDate(0, internal.MonetaryCorrectionMonths, 0).Format(time.DateOnly))
+		tw.AppendRow(row)
+
+		if !p.Short {
+			total = total.Add(p.Cost.Round(2))
+		}
+	}
+
+	footer := table.Row{"SUM", "SUM", "SUM", "SUM", total}
+	if prices != nil {
+		footer = append(footer, totalValue.StringFixed(2), totalUnrealised.StringFixed(2))
+	}
+
+	tw.AppendFooter(footer, table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignRight})
+	tw.Render()
+}
+
+// RenderShortTerm writes a table with the lines of the report realised in the tax year and held for
+// less than 365 days, which must be aggregated by taxpayers in the top bracket of brackets.
+func (pp *PrettyPrinter) RenderShortTerm(aw *internal.AggregatorWriter, year, bracket int, brackets []internal.TaxBracket) {
+	tw := table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetStyle(table.StyleLight)
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		colOther(1),
+		colOther(2),
+		colOther(3),
+		colOther(4),
+		colOther(5),
+		colEuros(6),
+		colEuros(7),
+		colEuros(8),
+	})
+
+	tw.AppendHeader(table.Row{
+		pp.translator.Translate("row", 1, nil), pp.translator.Translate("symbol", 1, nil),
+		pp.translator.Translate("acquisition_date", 1, nil), pp.translator.Translate("realization_date", 1, nil),
+		pp.translator.Translate("days_held", 2, nil), pp.translator.Translate("realization", 1, nil),
+		pp.translator.Translate("acquisition", 1, nil), pp.translator.Translate("expenses", 2, nil),
+	})
+
+	var count int
+	row := 0
+	for ri := range aw.Iter() {
+		// Same numbering as the index of the report table
+		row++
+
+		if !ri.IsShortTerm() || ri.SellTimestamp.Year() != year {
+			continue
+		}
+
+		count++
+		tw.AppendRow(table.Row{
+			row, ri.Symbol, ri.BuyTimestamp.Format(time.DateOnly), ri.SellTimestamp.Format(time.DateOnly), ri.HoldingDays(),
+			ri.SellValue.StringFixed(2), ri.BuyValue.StringFixed(2), ri.Fees.StringFixed(2),
+		})
+	}
+
+	if count == 0 {
+		return
+	}
+
+	fmt.Fprintln(pp.output, pp.translator.Translate("short_term", count, map[string]any{"Count": count, "Year": year}))
+
+	ofYear := aw.OfYear(year)
+	tw.AppendFooter(table.Row{"SUM", "SUM", "SUM", "SUM", "SUM", ofYear.ShortTermEarned().StringFixed(2), ofYear.ShortTermSpent().StringFixed(2), ofYear.ShortTermFees().StringFixed(2)}, table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignRight})
+	tw.Render()
+
+	if internal.IsTopTaxBracket(bracket, brackets) {
+		fmt.Fprintln(pp.output, pp.translator.Translate("mandatory_aggregation", 1, nil))
+	} else {
+		fmt.Fprintln(pp.output, pp.translator.Translate("no_mandatory_aggregation", 1, map[string]any{"Bracket": bracket}))
+	}
+}
+
+// RenderSummary writes the result of the year by symbol and nature, to tell which positions drove
+// it, and by the source country of the assets.
+func (pp *PrettyPrinter) RenderSummary(s internal.Summary) {
+	valueHeaders := table.Row{
+		pp.translator.Translate("disposal", 2, nil), pp.translator.Translate("quantity", 1, nil),
+		pp.translator.Translate("realization", 1, nil), pp.translator.Translate("acquisition", 1, nil),
+		pp.translator.Translate("expenses", 2, nil), pp.translator.Translate("foreign_tax_paid", 1, nil),
+		pp.translator.Translate("net_pnl", 1, nil),
+	}
+	values := func(sv internal.SummaryValues) table.Row {
+		return table.Row{
+			sv.Disposals, sv.Quantity.String(),
+			sv.RealisationValue.StringFixed(2), sv.AcquisitionValue.StringFixed(2),
+			sv.Fees.StringFixed(2), sv.Taxes.StringFixed(2), sv.NetPnL.StringFixed(2),
+		}
+	}
+
+	fmt.Fprintln(pp.output, pp.translator.Translate("by_symbol", 1, nil))
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetStyle(table.StyleLight)
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		colOther(1),
+		colOther(2),
+		colOther(3),
+		colOther(4),
+		colEuros(5),
+		colEuros(6),
+		colEuros(7),
+		colEuros(8),
+		colEuros(9),
+	})
+
+	tw.AppendHeader(append(table.Row{pp.translator.Translate("symbol", 1, nil), pp.translator.Translate("code", 1, nil)}, valueHeaders...))
+	for _, ss := range s.Symbols {
+		tw.AppendRow(append(table.Row{ss.Symbol, ss.Nature}, values(ss.SummaryValues)...))
+	}
+	tw.Render()
+
+	fmt.Fprintln(pp.output, pp.translator.Translate("by_country", 1, nil))
+
+	tw = table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetStyle(table.StyleLight)
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		colCountry(1),
+		colOther(2),
+		colOther(3),
+		colEuros(4),
+		colEuros(5),
+		colEuros(6),
+		colEuros(7),
+		colEuros(8),
+	})
+
+	tw.AppendHeader(append(table.Row{pp.translator.Translate("source_country", 1, nil)}, valueHeaders...))
+	for _, cs := range s.Countries {
+		tw.AppendRow(append(table.Row{cs.Country}, values(cs.SummaryValues)...))
+	}
+	tw.Render()
+}
+
+// RenderPerformance writes the returns, gains and values of the portfolio followed by a table with
+// what was traded and paid each year.
+func (pp *PrettyPrinter) RenderPerformance(perf internal.Performance) {
+	fmt.Fprintln(pp.output, pp.translator.Translate("period", 1, map[string]any{
+		"Start": perf.Start.Format(time.DateOnly),
+		"End":   perf.End.Format(time.DateOnly),
+	}))
+	fmt.Fprintf(pp.output, "%s: %s%%\n", pp.translator.Translate("time_weighted_return", 1, nil), perf.TWR.Shift(2).StringFixed(2))
+
+	mwr := pp.translator.Translate("not_available", 1, nil)
+	if perf.MWR.Valid {
+		mwr = perf.MWR.Decimal.Shift(2).StringFixed(2) + "%"
+	}
+	fmt.Fprintf(pp.output, "%s: %s\n", pp.translator.Translate("money_weighted_return", 1, nil), mwr)
+
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("net_invested", 1, nil), perf.NetInvested.StringFixed(2))
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("market_value", 1, nil), perf.Value.StringFixed(2))
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("realised_gain", 2, nil), perf.Realised.StringFixed(2))
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("unrealised_gain", 2, nil), perf.Unrealised.StringFixed(2))
+
+	if len(perf.Unpriced) > 0 {
+		fmt.Fprintln(pp.output, pp.translator.Translate("unpriced", len(perf.Unpriced), map[string]any{"Symbols": strings.Join(perf.Unpriced, ", ")}))
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetStyle(table.StyleLight)
+	// The name of the return is longer than the usual columns
+	twr := colOther(8)
+	twr.WidthMax = 36
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		colOther(1),
+		colEuros(2),
+		colEuros(3),
+		colOther(4),
+		colEuros(5),
+		colEuros(6),
+		colEuros(7),
+		twr,
+	})
+
+	tw.AppendHeader(table.Row{
+		pp.translator.Translate("year", 1, nil), pp.translator.Translate("bought", 1, nil),
+		pp.translator.Translate("sold", 1, nil), pp.translator.Translate("turnover", 1, nil),
+		pp.translator.Translate("fee", 2, nil), pp.translator.Translate("tax", 2, nil),
+		pp.translator.Translate("realised_gain", 2, nil), pp.translator.Translate("time_weighted_return", 1, nil),
+	})
+
+	var bought, sold, fees, taxes, realised decimal.Decimal
+	for _, y := range perf.Years {
+		tw.AppendRow(table.Row{
+			y.Year, y.Bought.StringFixed(2), y.Sold.StringFixed(2), y.Turnover.Shift(2).StringFixed(2) + "%",
+			y.Fees.StringFixed(2), y.Taxes.StringFixed(2), y.Realised.StringFixed(2), y.TWR.Shift(2).StringFixed(2) + "%",
+		})
+
+		bought = bought.Add(y.Bought)
+		sold = sold.Add(y.Sold)
+		fees = fees.Add(y.Fees)
+		taxes = taxes.Add(y.Taxes)
+		realised = realised.Add(y.Realised)
+	}
+
+	tw.AppendFooter(table.Row{"SUM", bought.StringFixed(2), sold.StringFixed(2), "", fees.StringFixed(2), taxes.StringFixed(2), realised.StringFixed(2), perf.TWR.Shift(2).StringFixed(2) + "%"})
+	tw.Render()
+}
+
+// RenderTaxEstimate writes a table comparing the tax due at the autonomous rate with the tax due
+// when opting for englobamento.
+func (pp *PrettyPrinter) RenderTaxEstimate(est internal.TaxEstimate) {
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("net_gain", 1, nil), est.NetGain.StringFixed(2))
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("taxable_income", 1, nil), est.TaxableIncome.StringFixed(2))
+
+	autonomousTxt := pp.translator.Translate("autonomous_taxation", 1, nil)
+	englobamentoTxt := pp.translator.Translate("englobamento", 1, nil)
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetStyle(table.StyleLight)
+	// The labels and options are longer than the usual columns
+	label := colOther(1)
+	label.WidthMax = 48
+	autonomous, englobamento := colEuros(2), colEuros(3)
+	autonomous.WidthMax, englobamento.WidthMax = 24, 24
+	tw.SetColumnConfigs([]table.ColumnConfig{label, autonomous, englobamento})
+
+	tw.AppendHeader(table.Row{"", autonomousTxt, englobamentoTxt})
+	tw.AppendRows([]table.Row{
+		{pp.translator.Translate("tax", 1, nil), est.Autonomous.Tax.StringFixed(2), est.Englobamento.Tax.StringFixed(2)},
+		{pp.translator.Translate("foreign_tax_credit", 1, nil), est.Autonomous.ForeignTaxCredit.StringFixed(2), est.Englobamento.ForeignTaxCredit.StringFixed(2)},
+	})
+	tw.AppendFooter(table.Row{pp.translator.Translate("tax_due", 1, nil), est.Autonomous.Due.StringFixed(2), est.Englobamento.Due.StringFixed(2)})
+	tw.Render()
+
+	fmt.Fprintf(pp.output, "%s: %s%% / %s%%\n", pp.translator.Translate("rate", 2, nil), est.Autonomous.Rate.Shift(2).StringFixed(2), est.Englobamento.Rate.Shift(2).StringFixed(2))
+
+	if est.MandatoryAggregation && est.ShortTermGain.IsPositive() {
+		fmt.Fprintln(pp.output, pp.translator.Translate("mandatory_aggregation", 1, nil))
+	}
+
+	option := autonomousTxt
+	if est.PreferEnglobamento() {
+		option = englobamentoTxt
+	}
+	fmt.Fprintln(pp.output, pp.translator.Translate("less_tax", 1, map[string]any{"Option": option}))
+}
+
+// RenderCarryForward writes the losses of past years deducted from the net result of the year and
+// the losses still available for the following years.
+func (pp *PrettyPrinter) RenderCarryForward(cf internal.CarryForward) {
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("net_result", 1, map[string]any{"Year": cf.Year}), cf.NetResult.StringFixed(2))
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("losses_deducted", 2, nil), cf.Deducted.StringFixed(2))
+	fmt.Fprintf(pp.output, "%s: %s €\n", pp.translator.Translate("taxable_gain", 1, nil), cf.Taxable.StringFixed(2))
+
+	if !cf.Englobamento {
+		fmt.Fprintln(pp.output, pp.translator.Translate("no_englobamento", 1, nil))
+	}
+
+	if len(cf.Losses) == 0 {
+		return
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(pp.output)
+	tw.SetStyle(table.StyleLight)
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		colOther(1),
+		colEuros(2),
+		colEuros(3),
+		colEuros(4),
+		colOther(5),
+	})
+
+	tw.AppendHeader(table.Row{
+		pp.translator.Translate("year", 1, nil), pp.translator.Translate("loss", 1, nil),
+		pp.translator.Translate("used", 1, nil), pp.translator.Translate("remaining", 1, nil),
+		pp.translator.Translate("usable_until", 1, nil),
+	})
+
+	var loss, used, remaining decimal.Decimal
+	for _, b := range cf.Losses {
+		tw.AppendRow(table.Row{b.Year, b.Loss.StringFixed(2), b.Used.StringFixed(2), b.Remaining.StringFixed(2), b.Expires})
+		loss = loss.Add(b.Loss)
+		used = used.Add(b.Used)
+		remaining = remaining.Add(b.Remaining)
+	}
+
+	tw.AppendFooter(table.Row{"SUM", loss.StringFixed(2), used.StringFixed(2), remaining.StringFixed(2)})
+	tw.Render()
 }
 
 func colEuros(n int) table.ColumnConfig {
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -82,3 +83,380 @@ func TestPrettyPrinter_Render(t *testing.T) {
 		t.Errorf("PrettyPrinter.Render() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
 	}
 }
+
+func TestPrettyPrinter_RenderAccruedInterest(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	err := aw.Write(t.Context(), internal.ReportItem{
+		Nature:          internal.NatureG10,
+		BrokerCountry:   826,
+		AssetCountry:    276,
+		BuyValue:        decimal.NewFromFloat(1000.00),
+		BuyTimestamp:    time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC),
+		SellValue:       decimal.NewFromFloat(1010.00),
+		SellTimestamp:   time.Date(2023, 6, 20, 0, 0, 0, 0, time.UTC),
+		AccruedInterest: decimal.NewFromFloat(12.345),
+	})
+	if err != nil {
+		t.Fatalf("failed to write report item: %v", err)
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).Render(aw)
+
+	want := "Accrued interest of bonds to declare as income (quadro 8): 12.35 €\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output to end with %q but got:\n%s", want, buf.String())
+	}
+}
+
+func TestPrettyPrinter_RenderMonetaryCorrection(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	err := aw.Write(t.Context(), internal.ReportItem{
+		Nature:            internal.NatureG01,
+		BrokerCountry:     826,
+		AssetCountry:      276,
+		BuyValue:          decimal.NewFromFloat(1000.00),
+		CorrectedBuyValue: decimal.NewFromFloat(1050.00),
+		BuyTimestamp:      time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC),
+		SellValue:         decimal.NewFromFloat(1200.00),
+		SellTimestamp:     time.Date(2023, 6, 20, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("failed to write report item: %v", err)
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).Render(aw)
+
+	want := "Acquisition value with monetary correction: 1050.00 €\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("want output to end with %q but got:\n%s", want, buf.String())
+	}
+}
+
+func TestPrettyPrinter_RenderPositions(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	err := aw.WritePosition(t.Context(), internal.Position{
+		Symbol:    "US0378331005",
+		Account:   "trading212",
+		Quantity:  decimal.NewFromFloat(1.5),
+		Cost:      decimal.NewFromFloat(150.255),
+		Timestamp: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("failed to write position: %v", err)
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderPositions(aw, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+
+	want := `┌───┬──────────────┬────────────┬──────────┬─────────────┬──────────────┬───────────┬─────────────┬────────────┐
+│   │ SYMBOL       │ ACCOUNT    │ QUANTITY │ ACQUISITION │         COST │ DAYS HELD │ 365 DAYS ON │ 2 YEARS ON │
+├───┼──────────────┼────────────┼──────────┼─────────────┼──────────────┼───────────┼─────────────┼────────────┤
+│ 1 │ US0378331005 │ trading212 │ 1.5      │ 2023-03-01  │     150.26 € │ 306       │ 2024-02-29  │ 2025-03-01 │
+├───┼──────────────┴────────────┴──────────┴─────────────┼──────────────┼───────────┼─────────────┼────────────┤
+│   │                                                SUM │     150.26 € │           │             │            │
+└───┴────────────────────────────────────────────────────┴──────────────┴───────────┴─────────────┴────────────┘
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderPositions() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderPositionsWithPrices(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	for _, p := range []internal.Position{
+		{Symbol: "IE00B4L5Y983", Account: "xtb", Quantity: decimal.NewFromInt(2), Cost: decimal.NewFromFloat(200), Timestamp: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Symbol: "US0378331005", Account: "trading212", Quantity: decimal.NewFromFloat(1.5), Cost: decimal.NewFromFloat(150.255), Timestamp: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)},
+	} {
+		err := aw.WritePosition(t.Context(), p)
+		if err != nil {
+			t.Fatalf("failed to write position: %v", err)
+		}
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderPositions(aw, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), map[string]decimal.Decimal{"US0378331005": decimal.NewFromInt(180)})
+
+	want := `┌───┬──────────────┬────────────┬──────────┬─────────────┬──────────────┬──────────────┬─────────────────┬───────────┬─────────────┬────────────┐
+│   │ SYMBOL       │ ACCOUNT    │ QUANTITY │ ACQUISITION │         COST │ MARKET VALUE │ UNREALISED GAIN │ DAYS HELD │ 365 DAYS ON │ 2 YEARS ON │
+├───┼──────────────┼────────────┼──────────┼─────────────┼──────────────┼──────────────┼─────────────────┼───────────┼─────────────┼────────────┤
+│ 1 │ IE00B4L5Y983 │ xtb        │ 2        │ 2023-06-01  │     200.00 € │          n/a │             n/a │ 214       │ 2024-05-31  │ 2025-06-01 │
+│ 2 │ US0378331005 │ trading212 │ 1.5      │ 2023-03-01  │     150.26 € │     270.00 € │        119.75 € │ 306       │ 2024-02-29  │ 2025-03-01 │
+├───┼──────────────┴────────────┴──────────┴─────────────┼──────────────┼──────────────┼─────────────────┼───────────┼─────────────┼────────────┤
+│   │                                                SUM │     350.26 € │     270.00 € │        119.75 € │           │             │            │
+└───┴────────────────────────────────────────────────────┴──────────────┴──────────────┴─────────────────┴───────────┴─────────────┴────────────┘
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderPositions() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderSummary(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	for _, ri := range []internal.ReportItem{
+		{
+			Symbol: "US0378331005", Nature: internal.NatureG01, AssetCountry: 840, Quantity: decimal.NewFromInt(2),
+			BuyValue: decimal.NewFromFloat(100.5), SellValue: decimal.NewFromFloat(150.755), Fees: decimal.NewFromFloat(2.5),
+		},
+		{
+			Symbol: "IE00B4L5Y983", Nature: internal.NatureG20, AssetCountry: 372, Quantity: decimal.NewFromInt(10),
+			BuyValue: decimal.NewFromFloat(60), SellValue: decimal.NewFromFloat(50), Taxes: decimal.NewFromFloat(1),
+		},
+	} {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("failed to write report item: %v", err)
+		}
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderSummary(internal.Summarize(aw.Iter()))
+
+	want := `By symbol
+┌──────────────┬──────┬───────────┬──────────┬──────────────┬──────────────┬─────────────────┬─────────────────┬──────────────┐
+│ SYMBOL       │ CODE │ DISPOSALS │ QUANTITY │  REALIZATION │  ACQUISITION │ EXPENSES AND CH │ TAX PAID ABROAD │   NET RESULT │
+│              │      │           │          │              │              │           ARGES │                 │              │
+├──────────────┼──────┼───────────┼──────────┼──────────────┼──────────────┼─────────────────┼─────────────────┼──────────────┤
+│ IE00B4L5Y983 │ G20  │ 1         │ 10       │      50.00 € │      60.00 € │          0.00 € │          1.00 € │     -10.00 € │
+│ US0378331005 │ G01  │ 1         │ 2        │     150.76 € │     100.50 € │          2.50 € │          0.00 € │      47.76 € │
+└──────────────┴──────┴───────────┴──────────┴──────────────┴──────────────┴─────────────────┴─────────────────┴──────────────┘
+By source country
+┌─────────────────────┬───────────┬──────────┬──────────────┬──────────────┬─────────────────┬─────────────────┬──────────────┐
+│ SOURCE COUNTRY      │ DISPOSALS │ QUANTITY │  REALIZATION │  ACQUISITION │ EXPENSES AND CH │ TAX PAID ABROAD │   NET RESULT │
+│                     │           │          │              │              │           ARGES │                 │              │
+├─────────────────────┼───────────┼──────────┼──────────────┼──────────────┼─────────────────┼─────────────────┼──────────────┤
+│ 372 - Ireland       │ 1         │ 10       │      50.00 € │      60.00 € │          0.00 € │          1.00 € │     -10.00 € │
+│ 840 - United States │ 1         │ 2        │     150.76 € │     100.50 € │          2.50 € │          0.00 € │      47.76 € │
+└─────────────────────┴───────────┴──────────┴──────────────┴──────────────┴─────────────────┴─────────────────┴──────────────┘
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderSummary() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderPerformance(t *testing.T) {
+	perf := internal.Performance{
+		Start:       time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+		TWR:         decimal.NewFromFloat(0.581518),
+		NetInvested: decimal.NewFromFloat(71.5),
+		Value:       decimal.NewFromInt(160),
+		Realised:    decimal.NewFromInt(50),
+		Unrealised:  decimal.NewFromInt(40),
+		Unpriced:    []string{"IE00B4L5Y983", "US0378331005"},
+		Years: []internal.YearPerformance{
+			{Year: 2023, Bought: decimal.NewFromInt(220), Fees: decimal.NewFromInt(1), TWR: decimal.NewFromFloat(0.188119)},
+			{Year: 2024, Bought: decimal.NewFromInt(100), Sold: decimal.NewFromInt(150), Turnover: decimal.NewFromFloat(0.6673), Taxes: decimal.NewFromFloat(0.5), Realised: decimal.NewFromInt(50), TWR: decimal.NewFromFloat(0.331111)},
+		},
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderPerformance(perf)
+
+	want := `Period: 2023-01-01 to 2024-12-31
+Time-weighted return: 58.15%
+Money-weighted return per year: n/a
+Net invested: 71.50 €
+Market value: 160.00 €
+Realised gains: 50.00 €
+Unrealised gains: 40.00 €
+Valued at the last traded price: IE00B4L5Y983, US0378331005
+┌──────┬──────────────┬──────────────┬──────────┬──────────────┬──────────────┬────────────────┬──────────────────────┐
+│ YEAR │       BOUGHT │         SOLD │ TURNOVER │         FEES │        TAXES │ REALISED GAINS │ TIME-WEIGHTED RETURN │
+├──────┼──────────────┼──────────────┼──────────┼──────────────┼──────────────┼────────────────┼──────────────────────┤
+│ 2023 │     220.00 € │       0.00 € │ 0.00%    │       1.00 € │       0.00 € │         0.00 € │ 18.81%               │
+│ 2024 │     100.00 € │     150.00 € │ 66.73%   │       0.00 € │       0.50 € │        50.00 € │ 33.11%               │
+├──────┼──────────────┼──────────────┼──────────┼──────────────┼──────────────┼────────────────┼──────────────────────┤
+│ SUM  │     320.00 € │     150.00 € │          │       1.00 € │       0.50 € │        50.00 € │ 58.15%               │
+└──────┴──────────────┴──────────────┴──────────┴──────────────┴──────────────┴────────────────┴──────────────────────┘
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderPerformance() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderTaxEstimate(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	err := aw.Write(t.Context(), internal.ReportItem{
+		BuyValue:      decimal.NewFromInt(1000),
+		BuyTimestamp:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		SellValue:     decimal.NewFromInt(2010),
+		SellTimestamp: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		Fees:          decimal.NewFromInt(10),
+		Taxes:         decimal.NewFromInt(50),
+	})
+	if err != nil {
+		t.Fatalf("failed to write report item: %v", err)
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	est, err := internal.EstimateTax(aw, decimal.NewFromInt(10000), 0, 2025)
+	if err != nil {
+		t.Fatalf("failed to estimate tax: %v", err)
+	}
+
+	NewPrettyPrinter(&buf, localizer).RenderTaxEstimate(est)
+
+	want := `Net gain: 1000.00 €
+Other taxable income: 10000.00 €
+┌────────────────────┬─────────────────────┬──────────────┐
+│                    │ AUTONOMOUS TAXATION │ ENGLOBAMENTO │
+├────────────────────┼─────────────────────┼──────────────┤
+│ Tax                │            280.00 € │     160.00 € │
+│ Foreign tax credit │             50.00 € │      50.00 € │
+├────────────────────┼─────────────────────┼──────────────┤
+│ TAX DUE            │            230.00 € │     110.00 € │
+└────────────────────┴─────────────────────┴──────────────┘
+Rates: 28.00% / 16.00%
+Option with less tax: Englobamento
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderTaxEstimate() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderShortTerm(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	bought := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	items := []internal.ReportItem{
+		// Short-term, but of another year
+		{Symbol: "OLD", BuyTimestamp: bought.AddDate(-1, 0, 0), SellTimestamp: bought.AddDate(-1, 2, 0), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(130)},
+		{Symbol: "LONG", BuyTimestamp: bought.AddDate(-1, 0, 0), SellTimestamp: bought.AddDate(0, 6, 0), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(150)},
+		{Symbol: "SHORT", BuyTimestamp: bought, SellTimestamp: bought.AddDate(0, 0, 30), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(120), Fees: decimal.NewFromInt(1)},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("failed to write report item: %v", err)
+		}
+	}
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderShortTerm(aw, 2024, 9, internal.TaxBrackets[2024])
+
+	want := `1 line of 2024 held for less than 365 days
+┌─────┬────────┬──────────────┬──────────────┬───────────┬──────────────┬──────────────┬─────────────────┐
+│ ROW │ SYMBOL │ ACQUISITION  │ REALIZATION  │ DAYS HELD │  REALIZATION │  ACQUISITION │ EXPENSES AND CH │
+│     │        │ DATE         │ DATE         │           │              │              │           ARGES │
+├─────┼────────┼──────────────┼──────────────┼───────────┼──────────────┼──────────────┼─────────────────┤
+│ 3   │ SHORT  │ 2024-01-02   │ 2024-02-01   │ 30        │     120.00 € │     100.00 € │          1.00 € │
+├─────┴────────┴──────────────┴──────────────┴───────────┼──────────────┼──────────────┼─────────────────┤
+│                                                    SUM │     120.00 € │     100.00 € │          1.00 € │
+└────────────────────────────────────────────────────────┴──────────────┴──────────────┴─────────────────┘
+Subject to mandatory englobamento (top bracket)
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderShortTerm() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderCarryForward(t *testing.T) {
+	ll := internal.NewLossLedger()
+	ll.Record(2022, decimal.NewFromInt(-1000), true)
+	ll.Record(2023, decimal.NewFromInt(-200), true)
+	ll.Record(2024, decimal.NewFromInt(300), true)
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderCarryForward(ll.CarryForward(2024))
+
+	want := `Net result of 2024: 300.00 €
+Losses of previous years deducted: 300.00 €
+Taxable gain: 0.00 €
+┌──────┬──────────────┬──────────────┬──────────────┬──────────────┐
+│ YEAR │         LOSS │         USED │    REMAINING │ USABLE UNTIL │
+├──────┼──────────────┼──────────────┼──────────────┼──────────────┤
+│ 2022 │    1000.00 € │     300.00 € │     700.00 € │ 2027         │
+│ 2023 │     200.00 € │       0.00 € │     200.00 € │ 2028         │
+├──────┼──────────────┼──────────────┼──────────────┼──────────────┤
+│ SUM  │    1200.00 € │     300.00 € │     900.00 € │              │
+└──────┴──────────────┴──────────────┴──────────────┴──────────────┘
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderCarryForward() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
+
+func TestPrettyPrinter_RenderCarryForwardWithoutEnglobamento(t *testing.T) {
+	ll := internal.NewLossLedger()
+	ll.Record(2023, decimal.NewFromInt(-200), false)
+	ll.Record(2024, decimal.NewFromInt(300), false)
+
+	localizer, err := NewLocalizer("en")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	NewPrettyPrinter(&buf, localizer).RenderCarryForward(ll.CarryForward(2024))
+
+	want := `Net result of 2024: 300.00 €
+Losses of previous years deducted: 0.00 €
+Taxable gain: 300.00 €
+Without englobamento, no losses are carried forward or deducted
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("PrettyPrinter.RenderCarryForward() output doesn't match expected.\n\nGot:\n%s\n\nWant:\n%s", got, want)
+	}
+}
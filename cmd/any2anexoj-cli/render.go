@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+// report is everything computed from the statements to be rendered in the chosen format.
+type report struct {
+	aw *internal.AggregatorWriter
+	// positionsDate is when the holding period of the open positions is computed. It's zero when
+	// the positions were not requested.
+	positionsDate time.Time
+	// prices values the open positions, by symbol, when not nil.
+	prices map[string]decimal.Decimal
+	// bracket is the tax bracket of the taxpayer or 0 if unknown.
+	bracket int
+	// year is the tax year declared and taxBrackets its general IRS rates when the bracket is known.
+	year         int
+	taxBrackets  []internal.TaxBracket
+	summary      *internal.Summary
+	taxEstimate  *internal.TaxEstimate
+	carryForward *internal.CarryForward
+	// audit has the audit entries behind each item of aw, in the same order, when the format shows
+	// them. Items merged from others have the entries of all of them.
+	audit [][]internal.AuditEntry
+}
+
+// renderers writes the report in each of the supported formats.
+var renderers = map[string]func(io.Writer, Translator, report) error{
+	"pretty": renderPretty,
+	"csv": func(w io.Writer, _ Translator, r report) error {
+		return NewCSVPrinter(w).Render(r.aw)
+	},
+	"json": func(w io.Writer, _ Translator, r report) error {
+		return NewJSONPrinter(w).Render(r.aw)
+	},
+	"html": func(w io.Writer, tr Translator, r report) error {
+		return NewHTMLPrinter(w, tr).Render(r.aw, r.audit)
+	},
+	"pdf": func(w io.Writer, tr Translator, r report) error {
+		return NewPDFPrinter(w, tr, toolVersion(), time.Now()).Render(r.aw, slices.Concat(r.audit...))
+	},
+	// The headers are in Portuguese, whatever the language, since the workbook mimics the declaration
+	"xlsx": func(w io.Writer, _ Translator, r report) error {
+		loc, err := NewLocalizer("pt")
+		if err != nil {
+			return fmt.Errorf("create localizer: %w", err)
+		}
+		return NewXLSXPrinter(w, loc).Render(r.aw)
+	},
+	// The items are streamed by an NDJSONWriter while the report is built so there's nothing left
+	"ndjson": func(io.Writer, Translator, report) error {
+		return nil
+	},
+}
+
+func renderPretty(w io.Writer, tr Translator, r report) error {
+	printer := NewPrettyPrinter(w, tr)
+
+	printer.Render(r.aw)
+
+	if !r.positionsDate.IsZero() {
+		printer.RenderPositions(r.aw, r.positionsDate, r.prices)
+	}
+
+	if r.summary != nil {
+		printer.RenderSummary(*r.summary)
+	}
+
+	// Only with a known bracket, from --tax-bracket or --taxable-income, to tell if it's mandatory
+	if r.bracket != 0 {
+		printer.RenderShortTerm(r.aw, r.year, r.bracket, r.taxBrackets)
+	}
+
+	if r.taxEstimate != nil {
+		printer.RenderTaxEstimate(*r.taxEstimate)
+	}
+
+	if r.carryForward != nil {
+		printer.RenderCarryForward(*r.carryForward)
+	}
+
+	return nil
+}
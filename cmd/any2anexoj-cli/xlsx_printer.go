@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/xlsx"
+)
+
+// exemptionDate is when the gains of shares started to be taxed. Shares acquired before are
+// declared as excluded from taxation in Anexo G1.
+var exemptionDate = time.Date(1989, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// XLSXPrinter writes a workbook with a sheet per annex of the declaration, to be handed to someone
+// working in a spreadsheet application. The rows are split across the sheets as follows:
+//   - G1: shares acquired before 1989 whose gains are excluded from taxation;
+//   - G: the remaining rows of a broker in Portugal;
+//   - J quadro 9: the remaining rows, of brokers abroad;
+//   - J quadro 8: the accrued interest of bonds, regardless of the sheet of the row.
+type XLSXPrinter struct {
+	w          io.Writer
+	translator Translator
+}
+
+func NewXLSXPrinter(w io.Writer, tr Translator) *XLSXPrinter {
+	return &XLSXPrinter{
+		w:          w,
+		translator: tr,
+	}
+}
+
+func (xp *XLSXPrinter) Render(aw *internal.AggregatorWriter) error {
+	// Each sheet gets its own aggregator so that the totals match the ones of the other formats
+	// when all the rows are in the same sheet.
+	j9, g, g1 := internal.NewAggregatorWriter(), internal.NewAggregatorWriter(), internal.NewAggregatorWriter()
+	for ri := range aw.Iter() {
+		dst := j9
+		switch {
+		case ri.Nature == internal.NatureG01 && ri.BuyTimestamp.Before(exemptionDate):
+			dst = g1
+		case ri.BrokerCountry == int64(countries.Portugal):
+			dst = g
+		}
+
+		err := dst.Write(context.Background(), ri)
+		if err != nil {
+			return fmt.Errorf("split report item: %w", err)
+		}
+	}
+
+	wb := xlsx.NewWriter(xp.w)
+
+	xp.gainsSheet(wb.AddSheet("J quadro 9"), j9)
+	xp.interestSheet(wb.AddSheet("J quadro 8"), aw)
+	xp.gainsSheet(wb.AddSheet("G"), g)
+	xp.gainsSheet(wb.AddSheet("G1"), g1)
+
+	err := wb.Close()
+	if err != nil {
+		return fmt.Errorf("write workbook: %w", err)
+	}
+
+	return nil
+}
+
+func (xp *XLSXPrinter) gainsSheet(s *xlsx.Sheet, aw *internal.AggregatorWriter) {
+	s.AppendRow(
+		xp.header("row", 1), xp.header("symbol", 1), xp.header("source_country", 1), xp.header("code", 1),
+		xp.header("realization_date", 1), xp.header("realization_value", 1),
+		xp.header("acquisition_date", 1), xp.header("acquisition_value", 1),
+		xp.header("expenses", 2), xp.header("foreign_tax_paid", 1), xp.header("counter_country", 1),
+	)
+
+	var row int64
+	for ri := range aw.Iter() {
+		row++
+		s.AppendRow(
+			xlsx.Integer(row), xlsx.Text(ri.Symbol), xlsx.Integer(ri.AssetCountry), xlsx.Text(string(ri.Nature)),
+			xlsx.Date(ri.SellTimestamp), xlsx.Euro(ri.SellValue),
+			xlsx.Date(ri.BuyTimestamp), xlsx.Euro(ri.BuyValue),
+			xlsx.Euro(ri.Fees), xlsx.Euro(ri.Taxes), xlsx.Integer(ri.BrokerCountry),
+		)
+	}
+
+	s.AppendRow(
+		xp.header("total", 1), xlsx.Text(""), xlsx.Text(""), xlsx.Text(""),
+		xlsx.Text(""), xlsx.Euro(aw.TotalEarned()).Bold(),
+		xlsx.Text(""), xlsx.Euro(aw.TotalSpent()).Bold(),
+		xlsx.Euro(aw.TotalFees()).Bold(), xlsx.Euro(aw.TotalTaxes()).Bold(),
+	)
+}
+
+// interestSheet lists the rows with accrued interest, which is declared as income instead of being
+// part of the gains.
+func (xp *XLSXPrinter) interestSheet(s *xlsx.Sheet, aw *internal.AggregatorWriter) {
+	s.AppendRow(
+		xp.header("row", 1), xp.header("symbol", 1), xp.header("source_country", 1),
+		xp.header("realization_date", 1), xp.header("gross_income", 1),
+	)
+
+	var row int64
+	for ri := range aw.Iter() {
+		if ri.AccruedInterest.IsZero() {
+			continue
+		}
+
+		row++
+		s.AppendRow(
+			xlsx.Integer(row), xlsx.Text(ri.Symbol), xlsx.Integer(ri.AssetCountry),
+			xlsx.Date(ri.SellTimestamp), xlsx.Euro(ri.AccruedInterest),
+		)
+	}
+
+	s.AppendRow(
+		xp.header("total", 1), xlsx.Text(""), xlsx.Text(""),
+		xlsx.Text(""), xlsx.Euro(aw.TotalAccruedInterest()).Bold(),
+	)
+}
+
+func (xp *XLSXPrinter) header(key string, count int) xlsx.Cell {
+	return xlsx.Text(xp.translator.Translate(key, count, nil)).Bold()
+}
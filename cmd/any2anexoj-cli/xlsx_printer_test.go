@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/xlsx"
+	"github.com/shopspring/decimal"
+)
+
+func TestXLSXPrinter_Render(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	items := []internal.ReportItem{
+		{
+			Symbol:        "US0378331005",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 826,
+			AssetCountry:  840,
+			BuyValue:      decimal.NewFromFloat(100.5),
+			BuyTimestamp:  time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(150.755),
+			SellTimestamp: time.Date(2023, 6, 20, 10, 0, 0, 0, time.UTC),
+			Fees:          decimal.NewFromFloat(2.5),
+			Taxes:         decimal.NewFromFloat(5),
+		},
+		{
+			Symbol:          "XS1234567890",
+			Nature:          internal.NatureG10,
+			BrokerCountry:   826,
+			AssetCountry:    276,
+			BuyValue:        decimal.NewFromFloat(990),
+			BuyTimestamp:    time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+			SellValue:       decimal.NewFromFloat(1000),
+			SellTimestamp:   time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+			AccruedInterest: decimal.NewFromFloat(12.345),
+		},
+		{
+			Symbol:        "PTEDP0AM0009",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 620,
+			AssetCountry:  620,
+			BuyValue:      decimal.NewFromFloat(50),
+			BuyTimestamp:  time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(40),
+			SellTimestamp: time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			Symbol:        "PTBCP0AM0015",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 620,
+			AssetCountry:  620,
+			BuyValue:      decimal.NewFromFloat(10),
+			BuyTimestamp:  time.Date(1988, 12, 31, 10, 0, 0, 0, time.UTC),
+			SellValue:     decimal.NewFromFloat(200),
+			SellTimestamp: time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("failed to write report item: %v", err)
+		}
+	}
+
+	localizer, err := NewLocalizer("pt")
+	if err != nil {
+		t.Fatalf("failed to create localizer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = NewXLSXPrinter(&buf, localizer).Render(aw)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	wb, err := xlsx.NewWorkbook(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read workbook: %v", err)
+	}
+
+	if got, want := wb.SheetNames(), []string{"J quadro 9", "J quadro 8", "G", "G1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("want sheets %v but got %v", want, got)
+	}
+
+	gainsHeader := []string{"Linha", "Símbolo", "País da fonte", "Código", "Data de realização", "Valor de realização", "Data de aquisição", "Valor de aquisição", "Despesas e encargos", "Imposto pago no estrangeiro", "País da contraparte"}
+
+	tests := []struct {
+		sheet string
+		want  [][]string
+	}{
+		{
+			sheet: "J quadro 9",
+			want: [][]string{
+				gainsHeader,
+				{"1", "US0378331005", "840", "G01", "45097", "150.76", "44941", "100.50", "2.50", "5.00", "826"},
+				{"2", "XS1234567890", "276", "G10", "44986", "1000.00", "44621", "990.00", "0.00", "0.00", "826"},
+				{"Soma", "", "", "", "", "1150.76", "", "1090.50", "2.50", "5.00"},
+			},
+		},
+		{
+			sheet: "J quadro 8",
+			want: [][]string{
+				{"Linha", "Símbolo", "País da fonte", "Data de realização", "Rendimento bruto"},
+				{"1", "XS1234567890", "276", "44986", "12.35"},
+				{"Soma", "", "", "", "12.35"},
+			},
+		},
+		{
+			sheet: "G",
+			want: [][]string{
+				gainsHeader,
+				{"1", "PTEDP0AM0009", "620", "G01", "45108", "40.00", "43955", "50.00", "0.00", "0.00", "620"},
+				{"Soma", "", "", "", "", "40.00", "", "50.00", "0.00", "0.00"},
+			},
+		},
+		{
+			sheet: "G1",
+			want: [][]string{
+				gainsHeader,
+				{"1", "PTBCP0AM0015", "620", "G01", "45139", "200.00", "32508", "10.00", "0.00", "0.00", "620"},
+				{"Soma", "", "", "", "", "200.00", "", "10.00", "0.00", "0.00"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sheet, func(t *testing.T) {
+			rows, err := wb.Rows(tt.sheet)
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("want rows:\n%q\n\nbut got:\n%q", tt.want, rows)
+			}
+		})
+	}
+}
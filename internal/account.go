@@ -0,0 +1,61 @@
+package internal
+
+import "context"
+
+// AccountRecord is implemented by Records that know which account they belong to.
+type AccountRecord interface {
+	Account() string
+}
+
+// AccountOf returns the account r belongs to or an empty string if unknown.
+func AccountOf(r Record) string {
+	ar, ok := RecordAs[AccountRecord](r)
+	if !ok {
+		return ""
+	}
+	return ar.Account()
+}
+
+// AccountReader assigns an account to the Records of another RecordReader that don't have one.
+type AccountReader struct {
+	reader  RecordReader
+	account string
+}
+
+func NewAccountReader(r RecordReader, account string) *AccountReader {
+	return &AccountReader{
+		reader:  r,
+		account: account,
+	}
+}
+
+func (ar *AccountReader) ReadRecord(ctx context.Context) (Record, error) {
+	rec, err := ar.reader.ReadRecord(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return accountRecord{
+		Record:  rec,
+		account: ar.account,
+	}, nil
+}
+
+type accountRecord struct {
+	Record
+
+	account string
+}
+
+func (ar accountRecord) Unwrap() Record {
+	return ar.Record
+}
+
+// Account returns the account of the wrapped record, if any, because a record that knows its own
+// account is more specific than the default of its reader.
+func (ar accountRecord) Account() string {
+	if account := AccountOf(ar.Record); account != "" {
+		return account
+	}
+	return ar.account
+}
@@ -0,0 +1,90 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccountReader_ReadRecord(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	tests := []struct {
+		name string
+		rec  internal.Record
+		want string
+	}{
+		{"record without account", mockRecord(ctrl, 1, 1, internal.SideBuy, now), "default"},
+		{"record with account", accountTestRecord{Record: mockRecord(ctrl, 1, 1, internal.SideBuy, now), account: "own"}, "own"},
+		{"record with empty account", accountTestRecord{Record: mockRecord(ctrl, 1, 1, internal.SideBuy, now)}, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ar := internal.NewAccountReader(newSliceReader([]internal.Record{tt.rec}), "default")
+
+			got, err := ar.ReadRecord(t.Context())
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+
+			if internal.AccountOf(got) != tt.want {
+				t.Fatalf("want account %q but got %q", tt.want, internal.AccountOf(got))
+			}
+
+			if !got.Price().Equal(tt.rec.Price()) {
+				t.Fatalf("want the values of the original record but got price %v", got.Price())
+			}
+		})
+	}
+}
+
+func TestAccountOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	got := internal.AccountOf(mockRecord(ctrl, 1, 1, internal.SideBuy, time.Now()))
+	if got != "" {
+		t.Fatalf("want empty account for records without one but got %q", got)
+	}
+}
+
+func TestRecordAs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	inner := accountTestRecord{Record: mockRecord(ctrl, 1, 1, internal.SideBuy, time.Now()), account: "inner"}
+	wrapped := wrapperTestRecord{inner}
+
+	got, ok := internal.RecordAs[internal.AccountRecord](wrapped)
+	if !ok || got.Account() != "inner" {
+		t.Fatalf("want to find the account of the wrapped record but got %v, %v", got, ok)
+	}
+
+	_, ok = internal.RecordAs[internal.ClosedPosition](wrapped)
+	if ok {
+		t.Fatalf("want no closed position in the chain of wrappers")
+	}
+}
+
+type accountTestRecord struct {
+	internal.Record
+
+	account string
+}
+
+func (r accountTestRecord) Unwrap() internal.Record {
+	return r.Record
+}
+
+func (r accountTestRecord) Account() string {
+	return r.account
+}
+
+type wrapperTestRecord struct {
+	internal.Record
+}
+
+func (r wrapperTestRecord) Unwrap() internal.Record {
+	return r.Record
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// AggregatedItem is a row of the declaration that stands for one or more ReportItems.
+type AggregatedItem struct {
+	ReportItem
+	// Rows are the indexes, in the order they were written, of the ReportItems merged into this one.
+	Rows []int
+}
+
+// aggregateKey has what the ReportItems must share to be merged. The holding period only depends on
+// the dates so merged items are all short-term or all long-term.
+type aggregateKey struct {
+	symbol        string
+	nature        Nature
+	brokerCountry int64
+	assetCountry  int64
+	sellDate      string
+	buyDate       string
+	short         bool
+}
+
+func newAggregateKey(ri ReportItem) aggregateKey {
+	return aggregateKey{
+		symbol:        ri.Symbol,
+		nature:        ri.Nature,
+		brokerCountry: ri.BrokerCountry,
+		assetCountry:  ri.AssetCountry,
+		sellDate:      ri.SellTimestamp.Format(time.DateOnly),
+		buyDate:       ri.BuyTimestamp.Format(time.DateOnly),
+		short:         ri.Short,
+	}
+}
+
+// AggregateItems merges the items of the same symbol, nature and countries realised and acquired on
+// the same days, which the Anexo J instructions allow to be declared as a single row. The quantities,
+// values, fees and taxes are added up and the timestamps and account are the ones of the first item,
+// unless the accounts differ in which case it's left empty. Rows keep the order of their first item.
+func AggregateItems(items iter.Seq[ReportItem]) []AggregatedItem {
+	var aggregated []AggregatedItem
+	indexes := make(map[aggregateKey]int)
+
+	var row int
+	for ri := range items {
+		key := newAggregateKey(ri)
+
+		idx, ok := indexes[key]
+		if !ok {
+			indexes[key] = len(aggregated)
+			aggregated = append(aggregated, AggregatedItem{
+				ReportItem: ri,
+				Rows:       []int{row},
+			})
+			row++
+			continue
+		}
+
+		ai := &aggregated[idx]
+		ai.Quantity = ai.Quantity.Add(ri.Quantity)
+		ai.SellValue = ai.SellValue.Add(ri.SellValue)
+		ai.BuyValue = ai.BuyValue.Add(ri.BuyValue)
+		ai.Fees = ai.Fees.Add(ri.Fees)
+		ai.Taxes = ai.Taxes.Add(ri.Taxes)
+		ai.AccruedInterest = ai.AccruedInterest.Add(ri.AccruedInterest)
+		// Items acquired on the same day have the same coefficient so either all or none are corrected
+		ai.CorrectedBuyValue = ai.CorrectedBuyValue.Add(ri.CorrectedBuyValue)
+		if ai.Account != ri.Account {
+			ai.Account = ""
+		}
+		ai.Rows = append(ai.Rows, row)
+		row++
+	}
+
+	return aggregated
+}
+
+// Aggregate returns a copy of aw with its items merged by AggregateItems, and the same positions,
+// alongside the indexes of the items of aw merged into each item of the copy.
+func (aw *AggregatorWriter) Aggregate() (*AggregatorWriter, [][]int) {
+	aggregated := AggregateItems(aw.Iter())
+
+	out := NewAggregatorWriter()
+	rows := make([][]int, 0, len(aggregated))
+	for _, ai := range aggregated {
+		// Writing to an AggregatorWriter never fails
+		_ = out.Write(context.Background(), ai.ReportItem)
+		rows = append(rows, ai.Rows)
+	}
+
+	for p := range aw.Positions() {
+		_ = out.WritePosition(context.Background(), p)
+	}
+
+	return out, rows
+}
@@ -0,0 +1,164 @@
+package internal_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestAggregateItems(t *testing.T) {
+	buy := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	sell := time.Date(2024, 6, 20, 10, 0, 0, 0, time.UTC)
+
+	item := func(opts ...func(*internal.ReportItem)) internal.ReportItem {
+		ri := internal.ReportItem{
+			Symbol:        "US0378331005",
+			Account:       "main",
+			Nature:        internal.NatureG01,
+			BrokerCountry: 826,
+			AssetCountry:  840,
+			BuyValue:      decimal.NewFromFloat(10.005),
+			BuyTimestamp:  buy,
+			SellValue:     decimal.NewFromFloat(12.005),
+			SellTimestamp: sell,
+			Fees:          decimal.NewFromFloat(0.1),
+			Taxes:         decimal.NewFromFloat(0.2),
+		}
+		for _, opt := range opts {
+			opt(&ri)
+		}
+		return ri
+	}
+
+	tests := []struct {
+		name     string
+		items    []internal.ReportItem
+		wantRows [][]int
+		want     []internal.ReportItem
+	}{
+		{
+			name:  "partial fills on the same days",
+			items: []internal.ReportItem{item(), item(func(ri *internal.ReportItem) { ri.SellTimestamp = sell.Add(time.Hour) })},
+			wantRows: [][]int{
+				{0, 1},
+			},
+			want: []internal.ReportItem{
+				item(func(ri *internal.ReportItem) {
+					ri.BuyValue = decimal.NewFromFloat(20.01)
+					ri.SellValue = decimal.NewFromFloat(24.01)
+					ri.Fees = decimal.NewFromFloat(0.2)
+					ri.Taxes = decimal.NewFromFloat(0.4)
+				}),
+			},
+		},
+		{
+			name: "different accounts",
+			items: []internal.ReportItem{
+				item(),
+				item(func(ri *internal.ReportItem) { ri.Account = "joint" }),
+			},
+			wantRows: [][]int{{0, 1}},
+			want: []internal.ReportItem{
+				item(func(ri *internal.ReportItem) {
+					ri.Account = ""
+					ri.BuyValue = decimal.NewFromFloat(20.01)
+					ri.SellValue = decimal.NewFromFloat(24.01)
+					ri.Fees = decimal.NewFromFloat(0.2)
+					ri.Taxes = decimal.NewFromFloat(0.4)
+				}),
+			},
+		},
+		{
+			name: "rows that can't be merged keep their order",
+			items: []internal.ReportItem{
+				item(),
+				item(func(ri *internal.ReportItem) { ri.BuyTimestamp = buy.AddDate(0, 0, 1) }),
+				item(func(ri *internal.ReportItem) { ri.SellTimestamp = sell.AddDate(0, 0, 1) }),
+				item(func(ri *internal.ReportItem) { ri.BrokerCountry = 372 }),
+				item(func(ri *internal.ReportItem) { ri.AssetCountry = 372 }),
+				item(func(ri *internal.ReportItem) { ri.Nature = internal.NatureG20 }),
+				item(func(ri *internal.ReportItem) { ri.Symbol = "IE00B4L5Y983" }),
+				item(func(ri *internal.ReportItem) { ri.Short = true }),
+				item(),
+			},
+			wantRows: [][]int{{0, 8}, {1}, {2}, {3}, {4}, {5}, {6}, {7}},
+		},
+		{
+			name: "fills before and after the time of the acquisition a year later",
+			items: []internal.ReportItem{
+				item(func(ri *internal.ReportItem) {
+					ri.SellTimestamp = buy.AddDate(0, 0, internal.ShortTermDays).Add(-time.Hour)
+				}),
+				item(func(ri *internal.ReportItem) {
+					ri.SellTimestamp = buy.AddDate(0, 0, internal.ShortTermDays).Add(time.Hour)
+				}),
+			},
+			wantRows: [][]int{{0, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := internal.AggregateItems(slices.Values(tt.items))
+
+			var gotRows [][]int
+			for _, ai := range got {
+				gotRows = append(gotRows, ai.Rows)
+			}
+			if !reflect.DeepEqual(gotRows, tt.wantRows) {
+				t.Fatalf("want rows %v but got %v", tt.wantRows, gotRows)
+			}
+
+			for i, want := range tt.want {
+				ri := got[i].ReportItem
+				if ri.Account != want.Account || !ri.BuyValue.Equal(want.BuyValue) || !ri.SellValue.Equal(want.SellValue) ||
+					!ri.Fees.Equal(want.Fees) || !ri.Taxes.Equal(want.Taxes) || !ri.SellTimestamp.Equal(want.SellTimestamp) {
+					t.Errorf("want item %d to be %+v but got %+v", i, want, ri)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregatorWriter_Aggregate(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	ts := time.Date(2024, 6, 20, 10, 0, 0, 0, time.UTC)
+	for _, ri := range []internal.ReportItem{
+		{Symbol: "A", SellValue: decimal.NewFromFloat(1.005), SellTimestamp: ts},
+		{Symbol: "B", SellValue: decimal.NewFromInt(2), SellTimestamp: ts},
+		{Symbol: "A", SellValue: decimal.NewFromFloat(1.005), SellTimestamp: ts},
+	} {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+	}
+
+	err := aw.WritePosition(t.Context(), internal.Position{Symbol: "C"})
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	got, rows := aw.Aggregate()
+
+	if want := [][]int{{0, 2}, {1}}; !reflect.DeepEqual(rows, want) {
+		t.Fatalf("want rows %v but got %v", want, rows)
+	}
+
+	// The merged values are rounded once so the totals may be off by a cent from the original ones
+	if want := decimal.NewFromFloat(4.01); !got.TotalEarned().Equal(want) {
+		t.Errorf("want total earned %v but got %v", want, got.TotalEarned())
+	}
+
+	var positions []string
+	for p := range got.Positions() {
+		positions = append(positions, p.Symbol)
+	}
+	if !reflect.DeepEqual(positions, []string{"C"}) {
+		t.Errorf("want the positions to be kept but got %v", positions)
+	}
+}
@@ -12,12 +12,22 @@ import (
 type AggregatorWriter struct {
 	mu sync.RWMutex
 
-	items []ReportItem
+	items     []ReportItem
+	positions []Position
 
 	totalEarned decimal.Decimal
 	totalSpent  decimal.Decimal
 	totalFees   decimal.Decimal
 	totalTaxes  decimal.Decimal
+
+	totalAccruedInterest decimal.Decimal
+	totalCorrectedSpent  decimal.Decimal
+
+	// The short-term totals are the subset of the totals of the items held for less than a year.
+	shortTermEarned decimal.Decimal
+	shortTermSpent  decimal.Decimal
+	shortTermFees   decimal.Decimal
+	shortTermTaxes  decimal.Decimal
 }
 
 func NewAggregatorWriter() *AggregatorWriter {
@@ -34,6 +44,15 @@ func (aw *AggregatorWriter) Write(_ context.Context, ri ReportItem) error {
 	aw.totalSpent = aw.totalSpent.Add(ri.BuyValue.Round(2))
 	aw.totalFees = aw.totalFees.Add(ri.Fees.Round(2))
 	aw.totalTaxes = aw.totalTaxes.Add(ri.Taxes.Round(2))
+	aw.totalAccruedInterest = aw.totalAccruedInterest.Add(ri.AccruedInterest.Round(2))
+	aw.totalCorrectedSpent = aw.totalCorrectedSpent.Add(ri.AcquisitionValue().Round(2))
+
+	if ri.IsShortTerm() {
+		aw.shortTermEarned = aw.shortTermEarned.Add(ri.SellValue.Round(2))
+		aw.shortTermSpent = aw.shortTermSpent.Add(ri.BuyValue.Round(2))
+		aw.shortTermFees = aw.shortTermFees.Add(ri.Fees.Round(2))
+		aw.shortTermTaxes = aw.shortTermTaxes.Add(ri.Taxes.Round(2))
+	}
 
 	return nil
 }
@@ -76,3 +95,89 @@ func (aw *AggregatorWriter) TotalTaxes() decimal.Decimal {
 	defer aw.mu.RUnlock()
 	return aw.totalTaxes
 }
+
+// TotalAccruedInterest is the net interest of bonds that must be declared as income instead of
+// being part of the capital gains.
+func (aw *AggregatorWriter) TotalAccruedInterest() decimal.Decimal {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	return aw.totalAccruedInterest
+}
+
+// TotalCorrectedSpent is the TotalSpent with the monetary correction of the items that have one.
+func (aw *AggregatorWriter) TotalCorrectedSpent() decimal.Decimal {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	return aw.totalCorrectedSpent
+}
+
+// ShortTermEarned is the part of TotalEarned of the items held for less than a year.
+func (aw *AggregatorWriter) ShortTermEarned() decimal.Decimal {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	return aw.shortTermEarned
+}
+
+// ShortTermSpent is the part of TotalSpent of the items held for less than a year.
+func (aw *AggregatorWriter) ShortTermSpent() decimal.Decimal {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	return aw.shortTermSpent
+}
+
+// ShortTermFees is the part of TotalFees of the items held for less than a year.
+func (aw *AggregatorWriter) ShortTermFees() decimal.Decimal {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	return aw.shortTermFees
+}
+
+// ShortTermTaxes is the part of TotalTaxes of the items held for less than a year.
+func (aw *AggregatorWriter) ShortTermTaxes() decimal.Decimal {
+	aw.mu.RLock()
+	defer aw.mu.RUnlock()
+	return aw.shortTermTaxes
+}
+
+// OfYear returns a copy of aw with only the items realised in year, and the same positions, so the
+// totals are the ones of that tax year.
+func (aw *AggregatorWriter) OfYear(year int) *AggregatorWriter {
+	out := NewAggregatorWriter()
+	for ri := range aw.Iter() {
+		if ri.SellTimestamp.Year() == year {
+			// Writing to an AggregatorWriter never fails
+			_ = out.Write(context.Background(), ri)
+		}
+	}
+
+	for p := range aw.Positions() {
+		_ = out.WritePosition(context.Background(), p)
+	}
+
+	return out
+}
+
+// WritePosition keeps the positions, in the same order, to be listed with Positions.
+func (aw *AggregatorWriter) WritePosition(_ context.Context, p Position) error {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+
+	aw.positions = append(aw.positions, p)
+
+	return nil
+}
+
+func (aw *AggregatorWriter) Positions() iter.Seq[Position] {
+	aw.mu.RLock()
+	positionsCopy := make([]Position, len(aw.positions))
+	copy(positionsCopy, aw.positions)
+	aw.mu.RUnlock()
+
+	return func(yield func(Position) bool) {
+		for _, p := range positionsCopy {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
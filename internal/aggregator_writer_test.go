@@ -239,6 +239,29 @@ func TestAggregatorWriter_Items(t *testing.T) {
 	}
 }
 
+func TestAggregatorWriter_ShortTerm(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+	ctx := context.Background()
+
+	bought := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	items := []internal.ReportItem{
+		{BuyTimestamp: bought, SellTimestamp: bought.AddDate(0, 6, 0), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(120), Fees: decimal.NewFromInt(1), Taxes: decimal.NewFromInt(2)},
+		{BuyTimestamp: bought, SellTimestamp: bought.AddDate(2, 0, 0), BuyValue: decimal.NewFromInt(200), SellValue: decimal.NewFromInt(300), Fees: decimal.NewFromInt(3), Taxes: decimal.NewFromInt(4)},
+	}
+	for _, ri := range items {
+		if err := aw.Write(ctx, ri); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	assertDecimalEqual(t, "short-term earned", decimal.NewFromInt(120), aw.ShortTermEarned())
+	assertDecimalEqual(t, "short-term spent", decimal.NewFromInt(100), aw.ShortTermSpent())
+	assertDecimalEqual(t, "short-term fees", decimal.NewFromInt(1), aw.ShortTermFees())
+	assertDecimalEqual(t, "short-term taxes", decimal.NewFromInt(2), aw.ShortTermTaxes())
+	assertDecimalEqual(t, "total earned", decimal.NewFromInt(420), aw.TotalEarned())
+}
+
 func TestAggregatorWriter_ThreadSafety(t *testing.T) {
 	aw := &internal.AggregatorWriter{}
 	ctx := context.Background()
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LineRecord is implemented by Records that know where they are in their statement.
+type LineRecord interface {
+	// Line is the line, or row, of the statement starting at 1.
+	Line() int
+}
+
+// LineOf returns the line of the statement where r was found or 0 if unknown.
+func LineOf(r Record) int {
+	lr, ok := RecordAs[LineRecord](r)
+	if !ok {
+		return 0
+	}
+	return lr.Line()
+}
+
+// SourceRecord is implemented by Records that know which statement they were read from.
+type SourceRecord interface {
+	Source() string
+}
+
+// SourceOf returns the statement r was read from or an empty string if unknown.
+func SourceOf(r Record) string {
+	sr, ok := RecordAs[SourceRecord](r)
+	if !ok {
+		return ""
+	}
+	return sr.Source()
+}
+
+// ExchangeRateRecord is implemented by Records whose values were converted to EUR from another
+// currency.
+type ExchangeRateRecord interface {
+	// Currency is the ISO 4217 code of the currency of the statement values.
+	Currency() string
+	// ExchangeRate is the units of Currency per EUR.
+	ExchangeRate() decimal.Decimal
+}
+
+// ExchangeRateOf returns the currency and exchange rate used to convert the values of r to EUR.
+// Returns an empty currency if r was not converted or the rate is unknown.
+func ExchangeRateOf(r Record) (string, decimal.Decimal) {
+	er, ok := RecordAs[ExchangeRateRecord](r)
+	if !ok || er.Currency() == "" || er.ExchangeRate().IsZero() {
+		return "", decimal.Decimal{}
+	}
+	return er.Currency(), er.ExchangeRate()
+}
+
+// SourceReader tags the Records of another RecordReader with the statement they were read from.
+type SourceReader struct {
+	reader RecordReader
+	source string
+}
+
+func NewSourceReader(r RecordReader, source string) *SourceReader {
+	return &SourceReader{
+		reader: r,
+		source: source,
+	}
+}
+
+func (sr *SourceReader) ReadRecord(ctx context.Context) (Record, error) {
+	rec, err := sr.reader.ReadRecord(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sourceRecord{
+		Record: rec,
+		source: sr.source,
+	}, nil
+}
+
+type sourceRecord struct {
+	Record
+
+	source string
+}
+
+func (sr sourceRecord) Unwrap() Record {
+	return sr.Record
+}
+
+func (sr sourceRecord) Source() string {
+	return sr.source
+}
+
+// AuditRecord identifies a Record in its statement.
+type AuditRecord struct {
+	Source    string
+	Line      int
+	Side      Side
+	Timestamp time.Time
+	// Quantity is the quantity of the whole Record, or of the lot for acquisitions.
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+	// Currency and ExchangeRate are how the values were converted to EUR. Currency is empty for
+	// Records in EUR or when the rate is unknown.
+	Currency     string
+	ExchangeRate decimal.Decimal
+	NatureSource NatureSource
+}
+
+func newAuditRecord(r Record) AuditRecord {
+	currency, rate := ExchangeRateOf(r)
+	return AuditRecord{
+		Source:       SourceOf(r),
+		Line:         LineOf(r),
+		Side:         r.Side(),
+		Timestamp:    r.Timestamp(),
+		Quantity:     r.Quantity(),
+		Price:        r.Price(),
+		Currency:     currency,
+		ExchangeRate: rate,
+		NatureSource: NatureSourceOf(r),
+	}
+}
+
+// AuditEntry explains how a ReportItem was matched.
+type AuditEntry struct {
+	Item ReportItem
+	// Realisation is the Record that realised the result: the sell or, for short positions, the
+	// closing buy.
+	Realisation AuditRecord
+	// Acquisition is the lot matched: the buy or, for short positions, the opening sell. Lots moved
+	// by transfers or corporate actions keep the statement and line of the original Record.
+	Acquisition AuditRecord
+	// Quantity is how much of the lot was matched by the ReportItem.
+	Quantity decimal.Decimal
+	// LotFilledBefore and LotFilledAfter are how much of the lot was matched before and after the
+	// ReportItem.
+	LotFilledBefore decimal.Decimal
+	LotFilledAfter  decimal.Decimal
+}
+
+type AuditWriter interface {
+	// WriteAudit writes how a report item was matched
+	WriteAudit(context.Context, AuditEntry) error
+}
+
+// WithAudit writes an AuditEntry to w for every ReportItem, right after the item is written.
+func WithAudit(w AuditWriter) ReportOption {
+	return func(o *reportOptions) {
+		o.audit = w
+	}
+}
+
+// match is what produced a ReportItem.
+type match struct {
+	realisation Record
+	acquisition AuditRecord
+	quantity    decimal.Decimal
+	// filledBefore and filledAfter are the fill state of the lot matched.
+	filledBefore decimal.Decimal
+	filledAfter  decimal.Decimal
+}
+
+// lotMatch returns the match of quantity of the lot, which was filled before.
+func lotMatch(realisation Record, lot *Filler, quantity, before decimal.Decimal) match {
+	return match{
+		realisation:  realisation,
+		acquisition:  newAuditRecord(lot.Record),
+		quantity:     quantity,
+		filledBefore: before,
+		filledAfter:  lot.Filled(),
+	}
+}
+
+// write writes the item, with the monetary correction if enabled, and its AuditEntry if enabled.
+func (l *ledger) write(ctx context.Context, writer ReportWriter, item ReportItem, m match) error {
+	if l.options.correction != nil {
+		var err error
+		item, err = l.options.correction.correct(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := writer.Write(ctx, item)
+	if err != nil {
+		return fmt.Errorf("write report item: %w", err)
+	}
+
+	if l.options.audit == nil {
+		return nil
+	}
+
+	err = l.options.audit.WriteAudit(ctx, AuditEntry{
+		Item:            item,
+		Realisation:     newAuditRecord(m.realisation),
+		Acquisition:     m.acquisition,
+		Quantity:        m.quantity,
+		LotFilledBefore: m.filledBefore,
+		LotFilledAfter:  m.filledAfter,
+	})
+	if err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+
+	return nil
+}
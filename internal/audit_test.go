@@ -0,0 +1,219 @@
+package internal_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBuildReport_Audit(t *testing.T) {
+	now := time.Now()
+
+	type entry struct {
+		realisationLine int
+		acquisitionLine int
+		quantity        float64
+		filledBefore    float64
+		filledAfter     float64
+		short           bool
+	}
+
+	tests := []struct {
+		name    string
+		records func(ctrl *gomock.Controller) []internal.Record
+		opts    []internal.ReportOption
+		want    []entry
+	}{
+		{
+			name: "sell matches two buys",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					lineRecord(mockRecord(ctrl, 10.0, 5.0, internal.SideBuy, now), 2),
+					lineRecord(mockRecord(ctrl, 12.0, 5.0, internal.SideBuy, now.Add(1)), 3),
+					lineRecord(mockRecord(ctrl, 15.0, 8.0, internal.SideSell, now.Add(2)), 4),
+				}
+			},
+			want: []entry{
+				{realisationLine: 4, acquisitionLine: 2, quantity: 5, filledBefore: 0, filledAfter: 5},
+				{realisationLine: 4, acquisitionLine: 3, quantity: 3, filledBefore: 0, filledAfter: 3},
+			},
+		},
+		{
+			name: "partially filled lot",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					lineRecord(mockRecord(ctrl, 10.0, 5.0, internal.SideBuy, now), 2),
+					lineRecord(mockRecord(ctrl, 15.0, 2.0, internal.SideSell, now.Add(1)), 3),
+					lineRecord(mockRecord(ctrl, 15.0, 2.0, internal.SideSell, now.Add(2)), 4),
+				}
+			},
+			want: []entry{
+				{realisationLine: 3, acquisitionLine: 2, quantity: 2, filledBefore: 0, filledAfter: 2},
+				{realisationLine: 4, acquisitionLine: 2, quantity: 2, filledBefore: 2, filledAfter: 4},
+			},
+		},
+		{
+			name: "short position",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					lineRecord(mockRecord(ctrl, 15.0, 2.0, internal.SideSell, now), 2),
+					lineRecord(mockRecord(ctrl, 10.0, 2.0, internal.SideBuy, now.Add(1)), 3),
+				}
+			},
+			opts: []internal.ReportOption{internal.WithShortSelling()},
+			want: []entry{
+				{realisationLine: 3, acquisitionLine: 2, quantity: 2, filledBefore: 0, filledAfter: 2, short: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			audit := &auditTestWriter{}
+			opts := append(tt.opts, internal.WithAudit(audit))
+
+			err := internal.BuildReport(t.Context(), newSliceReader(tt.records(ctrl)), internal.NewAggregatorWriter(), opts...)
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+
+			var got []entry
+			for _, e := range audit.entries {
+				got = append(got, entry{
+					realisationLine: e.Realisation.Line,
+					acquisitionLine: e.Acquisition.Line,
+					quantity:        e.Quantity.InexactFloat64(),
+					filledBefore:    e.LotFilledBefore.InexactFloat64(),
+					filledAfter:     e.LotFilledAfter.InexactFloat64(),
+					short:           e.Item.Short,
+				})
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("want audit entries %+v but got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSourceReader_ReadRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	rec := lineRecord(mockRecord(ctrl, 1, 1, internal.SideBuy, time.Now()), 7)
+	sr := internal.NewSourceReader(newSliceReader([]internal.Record{rec}), "statement.csv")
+
+	got, err := sr.ReadRecord(t.Context())
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	if internal.SourceOf(got) != "statement.csv" {
+		t.Fatalf("want source %q but got %q", "statement.csv", internal.SourceOf(got))
+	}
+
+	if internal.LineOf(got) != 7 {
+		t.Fatalf("want line 7 but got %d", internal.LineOf(got))
+	}
+}
+
+func TestExchangeRateOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	rec := mockRecord(ctrl, 1, 1, internal.SideBuy, time.Now())
+
+	tests := []struct {
+		name         string
+		rec          internal.Record
+		wantCurrency string
+		wantRate     decimal.Decimal
+	}{
+		{"without capability", rec, "", decimal.Decimal{}},
+		{"converted", provenanceTestRecord{Record: rec, currency: "USD", rate: decimal.RequireFromString("1.1")}, "USD", decimal.RequireFromString("1.1")},
+		{"unknown rate", provenanceTestRecord{Record: rec, currency: "USD"}, "", decimal.Decimal{}},
+		{"wrapped", sourceTestRecord(t, provenanceTestRecord{Record: rec, currency: "GBP", rate: decimal.RequireFromString("0.85")}), "GBP", decimal.RequireFromString("0.85")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currency, rate := internal.ExchangeRateOf(tt.rec)
+			if currency != tt.wantCurrency || !rate.Equal(tt.wantRate) {
+				t.Fatalf("want %v %v but got %v %v", tt.wantRate, tt.wantCurrency, rate, currency)
+			}
+		})
+	}
+}
+
+func TestNatureSourceOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	rec := mockRecord(ctrl, 1, 1, internal.SideBuy, time.Now())
+
+	if got := internal.NatureSourceOf(rec); got != internal.NatureSourceUnknown {
+		t.Fatalf("want unknown nature source but got %v", got)
+	}
+
+	wrapped := sourceTestRecord(t, provenanceTestRecord{Record: rec, natureSource: internal.NatureSourceOpenFIGI})
+	if got := internal.NatureSourceOf(wrapped); got != internal.NatureSourceOpenFIGI {
+		t.Fatalf("want nature source %v but got %v", internal.NatureSourceOpenFIGI, got)
+	}
+}
+
+// sourceTestRecord returns rec wrapped by a SourceReader.
+func sourceTestRecord(t *testing.T, rec internal.Record) internal.Record {
+	t.Helper()
+
+	got, err := internal.NewSourceReader(newSliceReader([]internal.Record{rec}), "statement.csv").ReadRecord(t.Context())
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	return got
+}
+
+type provenanceTestRecord struct {
+	internal.Record
+
+	currency     string
+	rate         decimal.Decimal
+	natureSource internal.NatureSource
+}
+
+func (r provenanceTestRecord) Currency() string {
+	return r.currency
+}
+
+func (r provenanceTestRecord) ExchangeRate() decimal.Decimal {
+	return r.rate
+}
+
+func (r provenanceTestRecord) NatureSource() internal.NatureSource {
+	return r.natureSource
+}
+
+type auditTestWriter struct {
+	entries []internal.AuditEntry
+}
+
+func (w *auditTestWriter) WriteAudit(_ context.Context, e internal.AuditEntry) error {
+	w.entries = append(w.entries, e)
+	return nil
+}
+
+func lineRecord(rec internal.Record, line int) internal.Record {
+	return lineTestRecord{Record: rec, line: line}
+}
+
+type lineTestRecord struct {
+	internal.Record
+
+	line int
+}
+
+func (r lineTestRecord) Line() int {
+	return r.line
+}
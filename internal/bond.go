@@ -0,0 +1,36 @@
+package internal
+
+import "github.com/shopspring/decimal"
+
+// AccruedInterestRecord is implemented by Records of bonds. The Price of these Records is the clean
+// price, without the interest accrued since the last coupon.
+type AccruedInterestRecord interface {
+	Record
+
+	// AccruedInterest is the total interest paid on a buy or received on a sell, for the whole
+	// quantity of the Record.
+	AccruedInterest() decimal.Decimal
+}
+
+// accruedInterestPerUnit returns the accrued interest of a single unit of r or zero if r doesn't
+// have accrued interest. It looks for the original Record because wrappers might change the
+// quantity (i.e.: partially matched lots).
+func accruedInterestPerUnit(r Record) decimal.Decimal {
+	ai, ok := RecordAs[AccruedInterestRecord](r)
+	if !ok || ai.Quantity().IsZero() {
+		return decimal.Decimal{}
+	}
+
+	return ai.AccruedInterest().Div(ai.Quantity())
+}
+
+// netAccruedInterest returns the interest received on the sell minus the interest paid on the buy
+// for the quantity matched between the two. The buy might be nil when it's unknown.
+func netAccruedInterest(sell, buy Record, quantity decimal.Decimal) decimal.Decimal {
+	interest := accruedInterestPerUnit(sell)
+	if buy != nil {
+		interest = interest.Sub(accruedInterestPerUnit(buy))
+	}
+
+	return interest.Mul(quantity)
+}
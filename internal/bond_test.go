@@ -0,0 +1,72 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBuildReport_AccruedInterest(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		bondRecord(mockRecord(ctrl, 100.0, 10.0, internal.SideBuy, now), 5.0),
+		bondRecord(mockRecord(ctrl, 105.0, 4.0, internal.SideSell, now.Add(1)), 8.0),
+		bondRecord(mockRecord(ctrl, 110.0, 6.0, internal.SideSell, now.Add(2)), 0),
+	}
+
+	writer := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), newSliceReader(records), writer)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	// The buy and sell values use the clean price and only the interest of the matched quantity of
+	// the buy is deducted
+	want := []struct {
+		buyValue, sellValue, accruedInterest float64
+	}{
+		{400.0, 420.0, 6.0},
+		{600.0, 660.0, -3.0},
+	}
+
+	var i int
+	for ri := range writer.Iter() {
+		if i >= len(want) {
+			t.Fatalf("want %d report items but got more", len(want))
+		}
+
+		w := want[i]
+		if !ri.BuyValue.Equal(decimal.NewFromFloat(w.buyValue)) || !ri.SellValue.Equal(decimal.NewFromFloat(w.sellValue)) || !ri.AccruedInterest.Equal(decimal.NewFromFloat(w.accruedInterest)) {
+			t.Fatalf("want item %d with buy value %v, sell value %v and accrued interest %v but got %v, %v and %v", i, w.buyValue, w.sellValue, w.accruedInterest, ri.BuyValue, ri.SellValue, ri.AccruedInterest)
+		}
+		i++
+	}
+
+	if i != len(want) {
+		t.Fatalf("want %d report items but got %d", len(want), i)
+	}
+
+	if !writer.TotalAccruedInterest().Equal(decimal.NewFromFloat(3.0)) {
+		t.Fatalf("want total accrued interest 3 but got %v", writer.TotalAccruedInterest())
+	}
+}
+
+func bondRecord(rec internal.Record, accruedInterest float64) internal.Record {
+	return bondTestRecord{Record: rec, accruedInterest: decimal.NewFromFloat(accruedInterest)}
+}
+
+type bondTestRecord struct {
+	internal.Record
+
+	accruedInterest decimal.Decimal
+}
+
+func (r bondTestRecord) AccruedInterest() decimal.Decimal {
+	return r.accruedInterest
+}
@@ -0,0 +1,312 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/biter777/countries"
+	"github.com/shopspring/decimal"
+)
+
+type CorporateActionKind uint
+
+const (
+	CorporateActionUnknown CorporateActionKind = iota
+	// CorporateActionRename replaces the security with another, usually a new ISIN. Using the same
+	// symbol with a ratio other than 1 is a split.
+	CorporateActionRename
+	// CorporateActionSpinOff gives shares of a new security while keeping the original ones.
+	CorporateActionSpinOff
+	// CorporateActionMerger replaces the security with shares of another and, optionally, cash.
+	CorporateActionMerger
+)
+
+func (k CorporateActionKind) String() string {
+	switch k {
+	case CorporateActionRename:
+		return "rename"
+	case CorporateActionSpinOff:
+		return "spin-off"
+	case CorporateActionMerger:
+		return "merger"
+	default:
+		return "unknown"
+	}
+}
+
+// CorporateAction describes an event that changes the lots held of a security without a trade.
+// The lots keep their acquisition date and, except for the cash of mergers, their total cost.
+type CorporateAction struct {
+	Kind CorporateActionKind
+	// NewSymbol is the security received. It's the same as the Symbol of the Record for splits.
+	NewSymbol string
+	// NewNature is the nature of the security received. When unknown it's the same as the lots.
+	NewNature Nature
+	// Ratio is the number of new shares received per share held. It can only be zero for mergers
+	// paid exclusively in cash.
+	Ratio decimal.Decimal
+	// CostFraction is the fraction of the cost of the lots that goes to the spin-off shares or, in
+	// mergers, to the cash received. The remaining cost stays with the original or new shares. It's
+	// required for spin-offs and mergers paid with both shares and cash.
+	CostFraction decimal.Decimal
+	// Cash is the amount received per share held in mergers, in EUR. It's realised as a disposal.
+	Cash decimal.Decimal
+}
+
+// CorporateActionRecord is implemented by Records with side SideCorporateAction. The Symbol is the
+// security affected. Records without an account, or any record when using global FIFO, apply to the
+// lots of every account.
+type CorporateActionRecord interface {
+	CorporateAction() CorporateAction
+}
+
+// processCorporateAction applies the action to every queue with lots of the security.
+func (l *ledger) processCorporateAction(ctx context.Context, rec Record, action CorporateAction, writer ReportWriter) error {
+	if !rec.Side().IsCorporateAction() {
+		return fmt.Errorf("corporate action with side: %v", rec.Side())
+	}
+
+	switch action.Kind {
+	case CorporateActionRename:
+		if !action.Ratio.IsPositive() {
+			return fmt.Errorf("%v with invalid ratio: %v", action.Kind, action.Ratio)
+		}
+	case CorporateActionSpinOff:
+		if !action.Ratio.IsPositive() {
+			return fmt.Errorf("%v with invalid ratio: %v", action.Kind, action.Ratio)
+		}
+		// Otherwise the new shares would have no cost and their whole value would be a gain.
+		if action.CostFraction.IsZero() {
+			return fmt.Errorf("%v without cost fraction", action.Kind)
+		}
+	case CorporateActionMerger:
+		if action.Ratio.IsNegative() {
+			return fmt.Errorf("%v with invalid ratio: %v", action.Kind, action.Ratio)
+		}
+		if action.Ratio.IsZero() {
+			// Without new shares the cash takes the whole cost.
+			action.CostFraction = decimal.NewFromInt(1)
+		}
+		if action.Cash.IsPositive() && action.CostFraction.IsZero() {
+			return fmt.Errorf("%v with cash and new shares without cost fraction", action.Kind)
+		}
+	default:
+		return fmt.Errorf("unknown corporate action: %v", action.Kind)
+	}
+
+	if action.NewSymbol == "" && !action.Ratio.IsZero() {
+		return fmt.Errorf("%v without new symbol", action.Kind)
+	}
+
+	if action.CostFraction.IsNegative() || action.CostFraction.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%v with invalid cost fraction: %v", action.Kind, action.CostFraction)
+	}
+
+	// With global FIFO the lots of every account share the same key, without account.
+	account := l.key(rec).account
+
+	var keys []lotKey
+	for key := range l.buys {
+		if key.symbol == rec.Symbol() && (account == "" || key.account == account) {
+			keys = append(keys, key)
+		}
+	}
+
+	// Only the lots held are adjusted so the action can't be applied while some are elsewhere.
+	for key, shorts := range l.shorts {
+		if key.symbol == rec.Symbol() && (account == "" || key.account == account) && shorts.Len() > 0 {
+			return fmt.Errorf("%v of %s with open short positions", action.Kind, rec.Symbol())
+		}
+	}
+
+	if l.transit[rec.Symbol()].Unfilled().IsPositive() {
+		return fmt.Errorf("%v of %s with lots in transit", action.Kind, rec.Symbol())
+	}
+
+	// Sorted so that the report items of mergers are always written in the same order.
+	slices.SortFunc(keys, func(a, b lotKey) int {
+		return cmp.Compare(a.account, b.account)
+	})
+
+	for _, key := range keys {
+		err := l.applyCorporateAction(ctx, key, rec, action, writer)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *ledger) applyCorporateAction(ctx context.Context, key lotKey, rec Record, action CorporateAction, writer ReportWriter) error {
+	q := l.buys[key]
+
+	var lots []*Filler
+	for q.Len() > 0 {
+		lot, _ := q.Pop()
+		lots = append(lots, lot)
+	}
+
+	newKey := lotKey{account: key.account, symbol: action.NewSymbol}
+	newQueue, ok := l.buys[newKey]
+	if !ok && !action.Ratio.IsZero() {
+		newQueue = new(FillerQueue)
+		l.buys[newKey] = newQueue
+	}
+
+	one := decimal.NewFromInt(1)
+
+	for _, lot := range lots {
+		quantity := lot.Unfilled()
+		cost := quantity.Mul(lot.Price())
+		fees := prorate(lot.Fees(), quantity, lot.Quantity())
+		taxes := prorate(lot.Taxes(), quantity, lot.Quantity())
+
+		newNature := action.NewNature
+		if newNature == NatureUnknown {
+			newNature = lot.Nature()
+		}
+
+		switch action.Kind {
+		case CorporateActionRename:
+			newQueue.Insert(NewFiller(adjustedRecord{
+				Record:   lot.Record,
+				symbol:   action.NewSymbol,
+				nature:   newNature,
+				quantity: quantity.Mul(action.Ratio),
+				price:    lot.Price().Div(action.Ratio),
+				fees:     fees,
+				taxes:    taxes,
+			}))
+
+		case CorporateActionSpinOff:
+			// The fees and taxes of the acquisition stay with the original shares.
+			q.Push(NewFiller(adjustedRecord{
+				Record:   lot.Record,
+				symbol:   lot.Symbol(),
+				nature:   lot.Nature(),
+				quantity: quantity,
+				price:    lot.Price().Mul(one.Sub(action.CostFraction)),
+				fees:     fees,
+				taxes:    taxes,
+			}))
+
+			newQueue.Insert(NewFiller(adjustedRecord{
+				Record:   lot.Record,
+				symbol:   action.NewSymbol,
+				nature:   newNature,
+				quantity: quantity.Mul(action.Ratio),
+				price:    cost.Mul(action.CostFraction).Div(quantity.Mul(action.Ratio)),
+			}))
+
+		case CorporateActionMerger:
+			// The fees and taxes of the acquisition are split like the cost.
+			var cashFees, cashTaxes decimal.Decimal
+			if action.Cash.IsPositive() {
+				cashFees = fees.Mul(action.CostFraction)
+				cashTaxes = taxes.Mul(action.CostFraction)
+
+				filledBefore := lot.Filled()
+				lot.Fill(quantity)
+
+				err := l.write(ctx, writer, ReportItem{
+					Symbol:        lot.Symbol(),
+					Account:       key.account,
+					BrokerCountry: lot.BrokerCountry(),
+					AssetCountry:  lot.AssetCountry(),
+					Quantity:      quantity,
+					BuyValue:      cost.Mul(action.CostFraction),
+					BuyTimestamp:  lot.Timestamp(),
+					SellValue:     quantity.Mul(action.Cash),
+					SellTimestamp: rec.Timestamp(),
+					Fees:          cashFees,
+					Taxes:         cashTaxes,
+					Nature:        lot.Nature(),
+				}, lotMatch(rec, lot, quantity, filledBefore))
+				if err != nil {
+					return err
+				}
+			}
+
+			if action.Ratio.IsZero() {
+				continue
+			}
+
+			newQueue.Insert(NewFiller(adjustedRecord{
+				Record:   lot.Record,
+				symbol:   action.NewSymbol,
+				nature:   newNature,
+				quantity: quantity.Mul(action.Ratio),
+				price:    cost.Mul(one.Sub(action.CostFraction)).Div(quantity.Mul(action.Ratio)),
+				fees:     fees.Sub(cashFees),
+				taxes:    taxes.Sub(cashTaxes),
+			}))
+		}
+	}
+
+	return nil
+}
+
+// adjustedRecord is a lot, or part of it, changed by a corporate action. It keeps the acquisition
+// date of the original lot.
+type adjustedRecord struct {
+	Record
+
+	symbol   string
+	nature   Nature
+	quantity decimal.Decimal
+	price    decimal.Decimal
+	// fees and taxes are the share of the original lot that goes with the adjusted one.
+	fees  decimal.Decimal
+	taxes decimal.Decimal
+}
+
+func (ar adjustedRecord) Unwrap() Record {
+	return ar.Record
+}
+
+func (ar adjustedRecord) Symbol() string {
+	return ar.symbol
+}
+
+func (ar adjustedRecord) Nature() Nature {
+	return ar.nature
+}
+
+// NatureSource is the one of the original lot unless the nature was changed by the corporate action.
+func (ar adjustedRecord) NatureSource() NatureSource {
+	if ar.nature != ar.Record.Nature() {
+		return NatureSourceStatement
+	}
+	return NatureSourceOf(ar.Record)
+}
+
+// AssetCountry is the country of the new ISIN when the security changed to one, otherwise the one of
+// the original lot.
+func (ar adjustedRecord) AssetCountry() int64 {
+	if ar.symbol != ar.Record.Symbol() && len(ar.symbol) == 12 {
+		country := countries.ByName(ar.symbol[:2])
+		if country != countries.Unknown {
+			return int64(country)
+		}
+	}
+	return ar.Record.AssetCountry()
+}
+
+func (ar adjustedRecord) Fees() decimal.Decimal {
+	return ar.fees
+}
+
+func (ar adjustedRecord) Taxes() decimal.Decimal {
+	return ar.taxes
+}
+
+func (ar adjustedRecord) Quantity() decimal.Decimal {
+	return ar.quantity
+}
+
+func (ar adjustedRecord) Price() decimal.Decimal {
+	return ar.price
+}
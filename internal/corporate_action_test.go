@@ -0,0 +1,279 @@
+package internal_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBuildReport_CorporateActions(t *testing.T) {
+	now := time.Now()
+
+	type item struct {
+		symbol    string
+		buyValue  float64
+		sellValue float64
+	}
+
+	tests := []struct {
+		name    string
+		records func(ctrl *gomock.Controller) []internal.Record
+		opts    []internal.ReportOption
+		want    []item
+		wantErr error
+	}{
+		{
+			name: "isin change",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+					actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1)}),
+					symbolRecord(mockRecord(ctrl, 25.0, 10.0, internal.SideSell, now.Add(2)), "NEW"),
+				}
+			},
+			want: []item{{"NEW", 200.0, 250.0}},
+		},
+		{
+			name: "split",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+					actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "TEST", Ratio: decimal.NewFromInt(2)}),
+					mockRecord(ctrl, 12.0, 20.0, internal.SideSell, now.Add(2)),
+				}
+			},
+			want: []item{{"TEST", 200.0, 240.0}},
+		},
+		{
+			name: "spin-off",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+					actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionSpinOff, NewSymbol: "NEW", Ratio: decimal.NewFromFloat(0.5), CostFraction: decimal.NewFromFloat(0.25)}),
+					mockRecord(ctrl, 18.0, 10.0, internal.SideSell, now.Add(2)),
+					symbolRecord(mockRecord(ctrl, 12.0, 5.0, internal.SideSell, now.Add(3)), "NEW"),
+				}
+			},
+			want: []item{{"TEST", 150.0, 180.0}, {"NEW", 50.0, 60.0}},
+		},
+		{
+			name: "merger with cash",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+					actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionMerger, NewSymbol: "NEW", Ratio: decimal.NewFromInt(2), CostFraction: decimal.NewFromFloat(0.1), Cash: decimal.NewFromInt(3)}),
+					symbolRecord(mockRecord(ctrl, 10.0, 20.0, internal.SideSell, now.Add(2)), "NEW"),
+				}
+			},
+			want: []item{{"TEST", 20.0, 30.0}, {"NEW", 180.0, 200.0}},
+		},
+		{
+			name: "cash only merger",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+					actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionMerger, Cash: decimal.NewFromInt(25)}),
+					mockRecord(ctrl, 25.0, 1.0, internal.SideSell, now.Add(2)),
+				}
+			},
+			want:    []item{{"TEST", 200.0, 250.0}},
+			wantErr: internal.ErrInsufficientBoughtVolume,
+		},
+		{
+			name: "only the account of the action",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					accountTestRecord{Record: mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now), account: "a"},
+					accountTestRecord{Record: actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1)}), account: "b"},
+					accountTestRecord{Record: symbolRecord(mockRecord(ctrl, 25.0, 10.0, internal.SideSell, now.Add(2)), "NEW"), account: "a"},
+				}
+			},
+			wantErr: internal.ErrInsufficientBoughtVolume,
+		},
+		{
+			name: "account of the action with global fifo",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					accountTestRecord{Record: mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now), account: "a"},
+					accountTestRecord{Record: actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "TEST", Ratio: decimal.NewFromInt(2)}), account: "a"},
+					accountTestRecord{Record: mockRecord(ctrl, 12.0, 20.0, internal.SideSell, now.Add(2)), account: "a"},
+				}
+			},
+			opts: []internal.ReportOption{internal.WithGlobalFIFO()},
+			want: []item{{"TEST", 200.0, 240.0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			writer := internal.NewAggregatorWriter()
+
+			gotErr := internal.BuildReport(t.Context(), newSliceReader(tt.records(ctrl)), writer, tt.opts...)
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("want error %v but got %v", tt.wantErr, gotErr)
+			}
+
+			var got []item
+			for ri := range writer.Iter() {
+				got = append(got, item{ri.Symbol, ri.BuyValue.InexactFloat64(), ri.SellValue.InexactFloat64()})
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("want report items %+v but got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBuildReport_CorporateActionErrors(t *testing.T) {
+	now := time.Now()
+	rename := internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1)}
+
+	tests := []struct {
+		name   string
+		before func(ctrl *gomock.Controller) []internal.Record
+		action internal.CorporateAction
+	}{
+		{"unknown kind", nil, internal.CorporateAction{NewSymbol: "NEW", Ratio: decimal.NewFromInt(1)}},
+		{"rename without ratio", nil, internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "NEW"}},
+		{"spin-off without new symbol", nil, internal.CorporateAction{Kind: internal.CorporateActionSpinOff, Ratio: decimal.NewFromInt(1)}},
+		{"spin-off without cost fraction", nil, internal.CorporateAction{Kind: internal.CorporateActionSpinOff, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1)}},
+		{"merger with cash and shares without cost fraction", nil, internal.CorporateAction{Kind: internal.CorporateActionMerger, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1), Cash: decimal.NewFromInt(3)}},
+		{"cost fraction above 1", nil, internal.CorporateAction{Kind: internal.CorporateActionSpinOff, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1), CostFraction: decimal.NewFromInt(2)}},
+		{"open short position", func(ctrl *gomock.Controller) []internal.Record {
+			return []internal.Record{mockRecord(ctrl, 20.0, 10.0, internal.SideSell, now)}
+		}, rename},
+		{"lots in transit", func(ctrl *gomock.Controller) []internal.Record {
+			return []internal.Record{
+				mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+				mockRecord(ctrl, 0, 10.0, internal.SideTransferOut, now),
+			}
+		}, rename},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			var records []internal.Record
+			if tt.before != nil {
+				records = tt.before(ctrl)
+			}
+			records = append(records, actionRecord(ctrl, now.Add(1), tt.action))
+
+			gotErr := internal.BuildReport(t.Context(), newSliceReader(records), internal.NewAggregatorWriter(), internal.WithShortSelling())
+			if gotErr == nil {
+				t.Fatalf("want error but got nil")
+			}
+		})
+	}
+}
+
+func TestBuildReport_CorporateActionLots(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		costsRecord(mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now), 1, 0),
+		mockRecord(ctrl, 25.0, 4.0, internal.SideSell, now.Add(1)),
+		actionRecord(ctrl, now.Add(2), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "IE00B4L5Y983", Ratio: decimal.NewFromInt(2)}),
+		symbolRecord(mockRecord(ctrl, 15.0, 6.0, internal.SideSell, now.Add(3)), "IE00B4L5Y983"),
+	}
+
+	writer := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), newSliceReader(records), writer, internal.WithPositions(writer))
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	var got []internal.Position
+	for p := range writer.Positions() {
+		got = append(got, p)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("want 1 position but got %+v", got)
+	}
+
+	// The country comes from the new ISIN
+	if got[0].Symbol != "IE00B4L5Y983" || got[0].AssetCountry != int64(countries.Ireland) || !got[0].Quantity.Equal(decimal.NewFromInt(6)) {
+		t.Errorf("want 6 shares of IE00B4L5Y983 from Ireland but got %v of %s from %d", got[0].Quantity, got[0].Symbol, got[0].AssetCountry)
+	}
+
+	// Only the fees of the 6 shares left after the first sale go with the lot, split between both sales
+	if want := decimal.NewFromFloat(0.4 + 0.3); !writer.TotalFees().Equal(want) {
+		t.Errorf("want fees of %v but got %v", want, writer.TotalFees())
+	}
+}
+
+func TestBuildReport_MergerCosts(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		costsRecord(mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now), 1, 0.4),
+		actionRecord(ctrl, now.Add(1), internal.CorporateAction{Kind: internal.CorporateActionMerger, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1), CostFraction: decimal.NewFromFloat(0.25), Cash: decimal.NewFromInt(3)}),
+		symbolRecord(mockRecord(ctrl, 25.0, 10.0, internal.SideSell, now.Add(2)), "NEW"),
+	}
+
+	writer := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), newSliceReader(records), writer)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	// The fees and taxes of the lot are split between the cash and the new shares like the cost
+	want := []struct {
+		symbol   string
+		buyValue float64
+		fees     float64
+		taxes    float64
+	}{
+		{"TEST", 50, 0.25, 0.1},
+		{"NEW", 150, 0.75, 0.3},
+	}
+
+	var i int
+	for ri := range writer.Iter() {
+		if i >= len(want) {
+			t.Fatalf("want %d items but got more", len(want))
+		}
+
+		w := want[i]
+		if ri.Symbol != w.symbol || !ri.BuyValue.Equal(decimal.NewFromFloat(w.buyValue)) ||
+			!ri.Fees.Equal(decimal.NewFromFloat(w.fees)) || !ri.Taxes.Equal(decimal.NewFromFloat(w.taxes)) {
+			t.Errorf("want item %d to be %+v but got %+v", i, w, ri)
+		}
+		i++
+	}
+
+	if i != len(want) {
+		t.Fatalf("want %d items but got %d", len(want), i)
+	}
+}
+
+func actionRecord(ctrl *gomock.Controller, ts time.Time, action internal.CorporateAction) internal.Record {
+	return actionTestRecord{Record: mockRecord(ctrl, 0, 0, internal.SideCorporateAction, ts), action: action}
+}
+
+type actionTestRecord struct {
+	internal.Record
+
+	action internal.CorporateAction
+}
+
+func (r actionTestRecord) CorporateAction() internal.CorporateAction {
+	return r.action
+}
+
+func symbolRecord(rec internal.Record, symbol string) internal.Record {
+	return symbolTestRecord{Record: rec, symbol: symbol}
+}
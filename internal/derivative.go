@@ -0,0 +1,337 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type DerivativeKind uint
+
+const (
+	DerivativeUnknown DerivativeKind = iota
+	DerivativeOption
+	DerivativeFuture
+)
+
+func (k DerivativeKind) String() string {
+	switch k {
+	case DerivativeOption:
+		return "option"
+	case DerivativeFuture:
+		return "future"
+	default:
+		return "unknown"
+	}
+}
+
+type OptionRight uint
+
+const (
+	OptionRightUnknown OptionRight = iota
+	OptionRightCall
+	OptionRightPut
+)
+
+func (r OptionRight) String() string {
+	switch r {
+	case OptionRightCall:
+		return "call"
+	case OptionRightPut:
+		return "put"
+	default:
+		return "unknown"
+	}
+}
+
+// Derivative describes an option or future contract.
+type Derivative struct {
+	Kind DerivativeKind
+	// Right is only set for options.
+	Right OptionRight
+	// Underlying is the symbol of the security delivered when an option is exercised or assigned.
+	Underlying string
+	// UnderlyingNature is the nature of the underlying security. Only required for options that are
+	// exercised or assigned.
+	UnderlyingNature Nature
+	// Strike is the price per unit of the underlying at which an option is exercised.
+	Strike decimal.Decimal
+	Expiry time.Time
+	// Multiplier is the number of units of the underlying per contract. Zero is the same as 1.
+	Multiplier decimal.Decimal
+}
+
+func (d Derivative) multiplier() decimal.Decimal {
+	if d.Multiplier.IsZero() {
+		return decimal.NewFromInt(1)
+	}
+	return d.Multiplier
+}
+
+// DerivativeRecord is implemented by Records of options and futures. The Quantity of these Records
+// is the number of contracts and the Price is per unit of the underlying, as quoted by the exchange.
+//
+// Options and futures can always be sold to open a position (i.e.: writing covered calls) and the
+// results are reported with NatureG40. Besides buys and sells, these Records may have the sides:
+//   - SideExpire closes the position at the Price of the Record, which is zero for options expiring
+//     worthless or the settlement price of futures.
+//   - SideExercise closes a long option position and delivers the underlying at the strike price.
+//   - SideAssign closes a short option position and delivers the underlying at the strike price.
+//
+// When an option is exercised or assigned there's no result for the option itself. Instead, the
+// premium adjusts the acquisition or realisation value of the underlying.
+type DerivativeRecord interface {
+	Derivative() Derivative
+}
+
+// contractRecord makes the values of a derivative Record per contract so that matching contracts
+// works like matching any other security.
+type contractRecord struct {
+	Record
+
+	derivative Derivative
+}
+
+func (cr contractRecord) Unwrap() Record {
+	return cr.Record
+}
+
+func (cr contractRecord) Derivative() Derivative {
+	return cr.derivative
+}
+
+func (cr contractRecord) Price() decimal.Decimal {
+	return cr.Record.Price().Mul(cr.derivative.multiplier())
+}
+
+func (cr contractRecord) Nature() Nature {
+	return NatureG40
+}
+
+func (cr contractRecord) NatureSource() NatureSource {
+	return NatureSourceStatement
+}
+
+// processDerivative handles the Records of options and futures.
+func (l *ledger) processDerivative(ctx context.Context, rec Record, d Derivative, writer ReportWriter) error {
+	rec = contractRecord{Record: rec, derivative: d}
+
+	q, shorts, transit := l.queues(rec, true)
+
+	switch rec.Side() {
+	case SideExpire:
+		// Expiring closes whatever position is open.
+		side := SideSell
+		if q.Len() == 0 {
+			side = SideBuy
+		}
+
+		return l.closePosition(ctx, q, shorts, transit, rec, side, writer)
+
+	case SideExercise, SideAssign:
+		if d.Kind != DerivativeOption {
+			return fmt.Errorf("%v of %v", rec.Side(), d.Kind)
+		}
+
+		return l.deliverUnderlying(ctx, q, shorts, rec, d, writer)
+
+	default:
+		return l.processRecord(ctx, q, shorts, transit, rec, writer)
+	}
+}
+
+// closePosition processes rec as if it had the given side but without opening a new position.
+func (l *ledger) closePosition(ctx context.Context, q, shorts, transit *FillerQueue, rec Record, side Side, writer ReportWriter) error {
+	if side.IsBuy() && shorts.Unfilled().LessThan(rec.Quantity()) {
+		return ErrInsufficientSoldVolume
+	}
+
+	// Without the short positions, a sell fails instead of opening one when there are not enough lots
+	if side.IsSell() {
+		shorts = nil
+	}
+
+	return l.processRecord(ctx, q, shorts, transit, sidedRecord{Record: rec, side: side}, writer)
+}
+
+// deliverUnderlying closes the option position and processes the trade of the underlying at the
+// strike price adjusted by the premium paid or received.
+func (l *ledger) deliverUnderlying(ctx context.Context, q, shorts *FillerQueue, rec Record, d Derivative, writer ReportWriter) error {
+	if d.Underlying == "" {
+		return fmt.Errorf("%v without underlying", rec.Side())
+	}
+
+	// The underlying position would otherwise be reported without a nature.
+	if d.UnderlyingNature == NatureUnknown {
+		return fmt.Errorf("%v of %s: %w", rec.Side(), d.Underlying, ErrUnknownUnderlyingNature)
+	}
+
+	// The holder exercises long positions and the writer is assigned on short positions.
+	lots, insufficientErr := q, ErrInsufficientBoughtVolume
+	if rec.Side().IsAssign() {
+		lots, insufficientErr = shorts, ErrInsufficientSoldVolume
+	}
+
+	premium, fees, err := consumeLots(lots, rec.Quantity())
+	if err != nil {
+		if errors.Is(err, errEmptyQueue) {
+			return insufficientErr
+		}
+		return err
+	}
+
+	units := rec.Quantity().Mul(d.multiplier())
+	premiumPerUnit := premium.Div(units)
+
+	// Exercising a call or being assigned on a put buys the underlying while exercising a put or
+	// being assigned on a call sells it. The premium paid increases the cost of buys and reduces the
+	// proceeds of sells, the premium received does the opposite.
+	var side Side
+	switch d.Right {
+	case OptionRightCall:
+		side = SideBuy
+		if rec.Side().IsAssign() {
+			side = SideSell
+		}
+	case OptionRightPut:
+		side = SideSell
+		if rec.Side().IsAssign() {
+			side = SideBuy
+		}
+	default:
+		return fmt.Errorf("%v of option with %v right", rec.Side(), d.Right)
+	}
+
+	price := d.Strike
+	if side.IsBuy() == rec.Side().IsExercise() {
+		price = price.Add(premiumPerUnit)
+	} else {
+		price = price.Sub(premiumPerUnit)
+	}
+
+	underlying := underlyingRecord{
+		option:   rec,
+		symbol:   d.Underlying,
+		nature:   d.UnderlyingNature,
+		side:     side,
+		quantity: units,
+		price:    price,
+		fees:     rec.Fees().Add(fees),
+		account:  AccountOf(rec),
+	}
+
+	return l.process(ctx, underlying, writer)
+}
+
+// consumeLots removes quantity from the front of q and returns the total value and fees of the
+// lots removed. The fees of lots only partly removed are prorated.
+func consumeLots(q *FillerQueue, quantity decimal.Decimal) (decimal.Decimal, decimal.Decimal, error) {
+	var value, fees decimal.Decimal
+
+	for quantity.IsPositive() {
+		lot, ok := q.Peek()
+		if !ok {
+			return decimal.Decimal{}, decimal.Decimal{}, errEmptyQueue
+		}
+
+		matchedQty, filled := lot.Fill(quantity)
+
+		if filled {
+			_, ok := q.Pop()
+			if !ok {
+				return decimal.Decimal{}, decimal.Decimal{}, fmt.Errorf("pop empty filler queue")
+			}
+		}
+
+		quantity = quantity.Sub(matchedQty)
+		value = value.Add(matchedQty.Mul(lot.Price()))
+		fees = fees.Add(prorate(lot.Fees(), matchedQty, lot.Quantity()))
+	}
+
+	return value, fees, nil
+}
+
+// sidedRecord overrides the side of a Record.
+type sidedRecord struct {
+	Record
+
+	side Side
+}
+
+func (sr sidedRecord) Unwrap() Record {
+	return sr.Record
+}
+
+func (sr sidedRecord) Side() Side {
+	return sr.side
+}
+
+// underlyingRecord is the trade of the underlying caused by the exercise or assignment of an
+// option. It doesn't embed nor unwrap to the option Record on purpose so that it is processed as a
+// trade of the underlying security.
+type underlyingRecord struct {
+	option Record
+
+	symbol   string
+	nature   Nature
+	side     Side
+	quantity decimal.Decimal
+	price    decimal.Decimal
+	fees     decimal.Decimal
+	account  string
+}
+
+func (ur underlyingRecord) Symbol() string {
+	return ur.symbol
+}
+
+func (ur underlyingRecord) Nature() Nature {
+	return ur.nature
+}
+
+func (ur underlyingRecord) BrokerCountry() int64 {
+	return ur.option.BrokerCountry()
+}
+
+func (ur underlyingRecord) AssetCountry() int64 {
+	return ur.option.AssetCountry()
+}
+
+func (ur underlyingRecord) Side() Side {
+	return ur.side
+}
+
+func (ur underlyingRecord) Price() decimal.Decimal {
+	return ur.price
+}
+
+func (ur underlyingRecord) Quantity() decimal.Decimal {
+	return ur.quantity
+}
+
+func (ur underlyingRecord) Timestamp() time.Time {
+	return ur.option.Timestamp()
+}
+
+func (ur underlyingRecord) Fees() decimal.Decimal {
+	return ur.fees
+}
+
+func (ur underlyingRecord) Taxes() decimal.Decimal {
+	return ur.option.Taxes()
+}
+
+func (ur underlyingRecord) Account() string {
+	return ur.account
+}
+
+func (ur underlyingRecord) Source() string {
+	return SourceOf(ur.option)
+}
+
+func (ur underlyingRecord) Line() int {
+	return LineOf(ur.option)
+}
@@ -0,0 +1,232 @@
+package internal_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBuildReport_Derivatives(t *testing.T) {
+	now := time.Now()
+
+	call := internal.Derivative{
+		Kind:             internal.DerivativeOption,
+		Right:            internal.OptionRightCall,
+		Underlying:       "UNDERLYING",
+		UnderlyingNature: internal.NatureG01,
+		Strike:           decimal.NewFromFloat(160.0),
+		Multiplier:       decimal.NewFromInt(100),
+	}
+	put := call
+	put.Right = internal.OptionRightPut
+	unknownCall := call
+	unknownCall.UnderlyingNature = internal.NatureUnknown
+	future := internal.Derivative{
+		Kind:       internal.DerivativeFuture,
+		Underlying: "UNDERLYING",
+		Multiplier: decimal.NewFromInt(10),
+	}
+
+	type item struct {
+		symbol    string
+		nature    internal.Nature
+		buyValue  float64
+		sellValue float64
+		short     bool
+	}
+
+	tests := []struct {
+		name    string
+		records func(ctrl *gomock.Controller) []internal.Record
+		want    []item
+		wantErr error
+	}{
+		{
+			name: "long option expires worthless",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 1.5, 2.0, internal.SideBuy, now), put),
+					derivativeRecord(mockRecord(ctrl, 0, 2.0, internal.SideExpire, now.Add(1)), put),
+				}
+			},
+			want: []item{{symbol: "TEST", nature: internal.NatureG40, buyValue: 300.0, sellValue: 0}},
+		},
+		{
+			name: "written option expires worthless",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 2.0, 1.0, internal.SideSell, now), call),
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideExpire, now.Add(1)), call),
+				}
+			},
+			want: []item{{symbol: "TEST", nature: internal.NatureG40, buyValue: 0, sellValue: 200.0, short: true}},
+		},
+		{
+			name: "covered call is assigned",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					underlyingRecord(mockRecord(ctrl, 150.0, 100.0, internal.SideBuy, now)),
+					derivativeRecord(mockRecord(ctrl, 2.0, 1.0, internal.SideSell, now.Add(1)), call),
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideAssign, now.Add(2)), call),
+				}
+			},
+			// The premium received increases the proceeds of the shares sold at the strike price
+			want: []item{{symbol: "UNDERLYING", nature: internal.NatureG01, buyValue: 15000.0, sellValue: 16200.0}},
+		},
+		{
+			name: "call is exercised",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 3.0, 1.0, internal.SideBuy, now), call),
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideExercise, now.Add(1)), call),
+					underlyingRecord(mockRecord(ctrl, 170.0, 100.0, internal.SideSell, now.Add(2))),
+				}
+			},
+			// The premium paid increases the cost of the shares bought at the strike price
+			want: []item{{symbol: "UNDERLYING", nature: internal.NatureG01, buyValue: 16300.0, sellValue: 17000.0}},
+		},
+		{
+			name: "put is assigned",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 4.0, 1.0, internal.SideSell, now), put),
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideAssign, now.Add(1)), put),
+					underlyingRecord(mockRecord(ctrl, 150.0, 100.0, internal.SideSell, now.Add(2))),
+				}
+			},
+			want: []item{{symbol: "UNDERLYING", nature: internal.NatureG01, buyValue: 15600.0, sellValue: 15000.0}},
+		},
+		{
+			name: "future settles at expiry",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 100.0, 1.0, internal.SideBuy, now), future),
+					derivativeRecord(mockRecord(ctrl, 105.0, 1.0, internal.SideExpire, now.Add(1)), future),
+				}
+			},
+			want: []item{{symbol: "TEST", nature: internal.NatureG40, buyValue: 1000.0, sellValue: 1050.0}},
+		},
+		{
+			name: "exercise without position",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideExercise, now), call),
+				}
+			},
+			wantErr: internal.ErrInsufficientBoughtVolume,
+		},
+		{
+			name: "assignment without position",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 1.0, 1.0, internal.SideBuy, now), call),
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideAssign, now.Add(1)), call),
+				}
+			},
+			wantErr: internal.ErrInsufficientSoldVolume,
+		},
+		{
+			name: "exercise without underlying nature",
+			records: func(ctrl *gomock.Controller) []internal.Record {
+				return []internal.Record{
+					derivativeRecord(mockRecord(ctrl, 3.0, 1.0, internal.SideBuy, now), unknownCall),
+					derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideExercise, now.Add(1)), unknownCall),
+				}
+			},
+			wantErr: internal.ErrUnknownUnderlyingNature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			writer := internal.NewAggregatorWriter()
+
+			gotErr := internal.BuildReport(t.Context(), newSliceReader(tt.records(ctrl)), writer)
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("want error %v but got %v", tt.wantErr, gotErr)
+			}
+
+			var got []item
+			for ri := range writer.Iter() {
+				got = append(got, item{
+					symbol:    ri.Symbol,
+					nature:    ri.Nature,
+					buyValue:  ri.BuyValue.InexactFloat64(),
+					sellValue: ri.SellValue.InexactFloat64(),
+					short:     ri.Short,
+				})
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("want report items %+v but got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBuildReport_DerivativePartialExercise(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := time.Now()
+
+	call := internal.Derivative{
+		Kind:             internal.DerivativeOption,
+		Right:            internal.OptionRightCall,
+		Underlying:       "UNDERLYING",
+		UnderlyingNature: internal.NatureG01,
+		Strike:           decimal.NewFromFloat(160.0),
+		Multiplier:       decimal.NewFromInt(100),
+	}
+
+	records := []internal.Record{
+		derivativeRecord(costsRecord(mockRecord(ctrl, 3.0, 4.0, internal.SideBuy, now), 4, 0), call),
+		derivativeRecord(mockRecord(ctrl, 0, 1.0, internal.SideExercise, now.Add(1)), call),
+		underlyingRecord(mockRecord(ctrl, 170.0, 100.0, internal.SideSell, now.Add(2))),
+	}
+
+	writer := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), newSliceReader(records), writer)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	// Only the fees of the contract exercised go with the shares bought
+	if got := writer.TotalFees(); !got.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("want fees of 1 but got %v", got)
+	}
+}
+
+func derivativeRecord(rec internal.Record, d internal.Derivative) internal.Record {
+	return derivativeTestRecord{Record: rec, derivative: d}
+}
+
+type derivativeTestRecord struct {
+	internal.Record
+
+	derivative internal.Derivative
+}
+
+func (r derivativeTestRecord) Derivative() internal.Derivative {
+	return r.derivative
+}
+
+// underlyingRecord returns a Record of the security underlying the derivatives of the test.
+func underlyingRecord(rec internal.Record) internal.Record {
+	return symbolTestRecord{Record: rec, symbol: "UNDERLYING"}
+}
+
+type symbolTestRecord struct {
+	internal.Record
+
+	symbol string
+}
+
+func (r symbolTestRecord) Symbol() string {
+	return r.symbol
+}
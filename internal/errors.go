@@ -3,3 +3,13 @@ package internal
 import "fmt"
 
 var ErrInsufficientBoughtVolume = fmt.Errorf("insufficient bought volume")
+
+var ErrInsufficientSoldVolume = fmt.Errorf("insufficient sold volume")
+
+var ErrInsufficientTransferredVolume = fmt.Errorf("insufficient transferred volume")
+
+var ErrUnknownUnderlyingNature = fmt.Errorf("unknown underlying nature")
+
+var ErrUnknownTaxYear = fmt.Errorf("unknown tax year")
+
+var ErrUnknownMonetaryCorrection = fmt.Errorf("unknown monetary correction coefficient")
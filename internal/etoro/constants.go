@@ -0,0 +1,10 @@
+package etoro
+
+import (
+	"github.com/biter777/countries"
+)
+
+const Country = countries.Cyprus
+
+// Currency of eToro accounts in which every value of the statement is reported.
+const Currency = "USD"
@@ -0,0 +1,345 @@
+package etoro
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/xlsx"
+	"github.com/shopspring/decimal"
+)
+
+type Record struct {
+	symbol    string
+	timestamp time.Time
+	side      internal.Side
+	quantity  decimal.Decimal
+	price     decimal.Decimal
+	fees      decimal.Decimal
+	// exchangeRate is the USD per EUR rate used to convert the values of the record.
+	exchangeRate decimal.Decimal
+
+	// natureGetter allows us to defer the operation of figuring out the nature to only when/if needed.
+	natureGetter func() internal.Nature
+	natureSource internal.NatureSource
+	// line is where the record is in the statement, starting at 1.
+	line int
+}
+
+func (r Record) Symbol() string {
+	return r.symbol
+}
+
+func (r Record) Timestamp() time.Time {
+	return r.timestamp
+}
+
+func (r Record) BrokerCountry() int64 {
+	return int64(Country)
+}
+
+// AssetCountry is derived from the ISIN. Returns 0 for instruments without one (i.e.: crypto).
+func (r Record) AssetCountry() int64 {
+	if len(r.symbol) != 12 {
+		return 0
+	}
+	return int64(countries.ByName(r.Symbol()[:2]).Info().Code)
+}
+
+func (r Record) Side() internal.Side {
+	return r.side
+}
+
+func (r Record) Quantity() decimal.Decimal {
+	return r.quantity
+}
+
+func (r Record) Price() decimal.Decimal {
+	return r.price
+}
+
+func (r Record) Fees() decimal.Decimal {
+	return r.fees
+}
+
+func (r Record) Taxes() decimal.Decimal {
+	return decimal.Decimal{}
+}
+
+func (r Record) Nature() internal.Nature {
+	return r.natureGetter()
+}
+
+func (r Record) NatureSource() internal.NatureSource {
+	return r.natureSource
+}
+
+// Currency is always USD, the currency of eToro accounts.
+func (r Record) Currency() string {
+	return Currency
+}
+
+func (r Record) ExchangeRate() decimal.Decimal {
+	return r.exchangeRate
+}
+
+func (r Record) Line() int {
+	return r.line
+}
+
+// ClosedPosition is a sell Record that carries the opening leg of the position as matched by eToro.
+type ClosedPosition struct {
+	Record
+
+	openPrice     decimal.Decimal
+	openTimestamp time.Time
+	// openExchangeRate is the rate used to convert the open price.
+	openExchangeRate decimal.Decimal
+}
+
+func (cp ClosedPosition) OpenPrice() decimal.Decimal {
+	return cp.openPrice
+}
+
+func (cp ClosedPosition) OpenTimestamp() time.Time {
+	return cp.openTimestamp
+}
+
+// RecordReader reads the closed positions from the eToro account statement workbook. Values are
+// reported in USD, the currency of eToro accounts, and are converted to EUR with the rate of the
+// day of each leg.
+type RecordReader struct {
+	reader  io.Reader
+	figi    *internal.OpenFIGI
+	matched bool
+	rates   internal.ExchangeRates
+
+	records []internal.Record
+	loaded  bool
+}
+
+// NewRecordReader returns a reader for eToro statements. When matched is true each closed position
+// is returned as a single ClosedPosition, otherwise the position is split into a buy and a sell
+// Record and these are returned in chronological order so the matching can be redone. The USD
+// values are converted to EUR with rates.
+func NewRecordReader(r io.Reader, f *internal.OpenFIGI, matched bool, rates internal.ExchangeRates) *RecordReader {
+	return &RecordReader{
+		reader:  r,
+		figi:    f,
+		matched: matched,
+		rates:   rates,
+	}
+}
+
+const ClosedPositionsSheet = "closed positions"
+
+const (
+	DirectionLong = "long"
+
+	TypeStocks = "stocks"
+	TypeETF    = "etf"
+)
+
+// Columns of the closed positions table.
+const (
+	colAction     = "action"
+	colDirection  = "long / short"
+	colUnits      = "units"
+	colOpenDate   = "open date"
+	colCloseDate  = "close date"
+	colOpenRate   = "open rate"
+	colCloseRate  = "close rate"
+	colLeverage   = "leverage"
+	colSpreadFees = "spread fees (usd)"
+	colType       = "type"
+	colISIN       = "isin"
+)
+
+// timeLayout is used when the statement has dates formatted as text instead of date cells.
+const timeLayout = "02/01/2006 15:04:05"
+
+func (rr *RecordReader) ReadRecord(ctx context.Context) (internal.Record, error) {
+	if !rr.loaded {
+		err := rr.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rr.records) == 0 {
+		return nil, fmt.Errorf("read record: %w", io.EOF)
+	}
+
+	rec := rr.records[0]
+	rr.records = rr.records[1:]
+
+	return rec, nil
+}
+
+// load parses the whole sheet at once because positions are sorted by close date and, when not
+// using the broker matching, the opening legs must be interleaved in chronological order.
+func (rr *RecordReader) load(ctx context.Context) error {
+	rr.loaded = true
+
+	wb, err := xlsx.ReadWorkbook(rr.reader)
+	if err != nil {
+		return fmt.Errorf("read statement: %w", err)
+	}
+
+	rows, err := wb.Rows(ClosedPositionsSheet)
+	if err != nil {
+		return fmt.Errorf("read statement: %w", err)
+	}
+
+	start, header, err := xlsx.FindHeader(rows, colAction, colDirection, colUnits, colOpenDate, colCloseDate, colOpenRate, colCloseRate)
+	if err != nil {
+		return fmt.Errorf("read sheet: %w", err)
+	}
+
+	for i, row := range rows[start+1:] {
+		if header.Get(row, colAction) == "" {
+			continue
+		}
+
+		line := start + i + 2
+
+		cp, err := rr.parsePosition(ctx, header, row)
+		if err != nil {
+			return fmt.Errorf("parse row %d: %w", line, err)
+		}
+
+		cp.line = line
+
+		if rr.matched {
+			rr.records = append(rr.records, cp)
+		} else {
+			buy := cp.Record
+			buy.side = internal.SideBuy
+			buy.timestamp = cp.openTimestamp
+			buy.price = cp.openPrice
+			buy.exchangeRate = cp.openExchangeRate
+			// The statement only has the total fees of the position so we attribute them to the
+			// closing leg which is the one where the expenses are declared.
+			buy.fees = decimal.Decimal{}
+
+			rr.records = append(rr.records, buy, cp.Record)
+		}
+	}
+
+	slices.SortStableFunc(rr.records, func(a, b internal.Record) int {
+		return cmp.Compare(a.Timestamp().UnixNano(), b.Timestamp().UnixNano())
+	})
+
+	return nil
+}
+
+func (rr *RecordReader) parsePosition(ctx context.Context, header xlsx.Header, row []string) (ClosedPosition, error) {
+	if !strings.EqualFold(header.Get(row, colDirection), DirectionLong) {
+		return ClosedPosition{}, fmt.Errorf("unsupported position direction: %s", header.Get(row, colDirection))
+	}
+
+	if leverage := header.Get(row, colLeverage); leverage != "" && leverage != "1" {
+		return ClosedPosition{}, fmt.Errorf("unsupported leveraged position: %s", leverage)
+	}
+
+	units, err := decimal.NewFromString(header.Get(row, colUnits))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse units: %w", err)
+	}
+
+	openRate, err := decimal.NewFromString(header.Get(row, colOpenRate))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse open rate: %w", err)
+	}
+
+	closeRate, err := decimal.NewFromString(header.Get(row, colCloseRate))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse close rate: %w", err)
+	}
+
+	openDate, err := parseTime(header.Get(row, colOpenDate))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse open date: %w", err)
+	}
+
+	closeDate, err := parseTime(header.Get(row, colCloseDate))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse close date: %w", err)
+	}
+
+	var fees decimal.Decimal
+	if raw := header.Get(row, colSpreadFees); raw != "" {
+		fees, err = decimal.NewFromString(raw)
+		if err != nil {
+			return ClosedPosition{}, fmt.Errorf("parse spread fees: %w", err)
+		}
+	}
+
+	openExchangeRate, err := rr.rates.Rate(openDate)
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("convert open rate: %w", err)
+	}
+
+	closeExchangeRate, err := rr.rates.Rate(closeDate)
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("convert close rate: %w", err)
+	}
+
+	// Older statements do not have the ISIN so we fallback to the instrument name.
+	symbol := header.Get(row, colISIN)
+	if symbol == "" {
+		_, symbol, _ = strings.Cut(header.Get(row, colAction), " ")
+	}
+
+	natureGetter, natureSource := rr.natureGetter(ctx, header.Get(row, colType), header.Get(row, colISIN))
+
+	return ClosedPosition{
+		Record: Record{
+			symbol:       symbol,
+			timestamp:    closeDate,
+			side:         internal.SideSell,
+			quantity:     units,
+			price:        closeRate.Div(closeExchangeRate),
+			fees:         fees.Abs().Div(closeExchangeRate),
+			exchangeRate: closeExchangeRate,
+			natureGetter: natureGetter,
+			natureSource: natureSource,
+		},
+		openPrice:        openRate.Div(openExchangeRate),
+		openTimestamp:    openDate,
+		openExchangeRate: openExchangeRate,
+	}, nil
+}
+
+// natureGetter uses the instrument type from the statement when possible and only falls back to
+// OpenFIGI for other types that have an ISIN. It also returns where the nature comes from.
+func (rr *RecordReader) natureGetter(ctx context.Context, instrumentType, isin string) (func() internal.Nature, internal.NatureSource) {
+	switch strings.ToLower(instrumentType) {
+	case TypeStocks:
+		return func() internal.Nature { return internal.NatureG01 }, internal.NatureSourceStatement
+	case TypeETF:
+		return func() internal.Nature { return internal.NatureG20 }, internal.NatureSourceStatement
+	}
+
+	if isin == "" {
+		return func() internal.Nature { return internal.NatureUnknown }, internal.NatureSourceUnknown
+	}
+
+	return rr.figi.NatureGetter(ctx, isin), internal.NatureSourceOpenFIGI
+}
+
+// parseTime accepts both date cells and dates formatted as text.
+func parseTime(s string) (time.Time, error) {
+	ts, err := time.Parse(timeLayout, s)
+	if err == nil {
+		return ts, nil
+	}
+
+	return xlsx.ParseSerialTime(s)
+}
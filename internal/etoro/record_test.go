@@ -0,0 +1,245 @@
+package etoro
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+// testRates has no rate on the 1st of January so the rate of the last business day is used.
+var testRates = internal.ExchangeRates{
+	Currency: "USD",
+	Rates: map[string]decimal.Decimal{
+		"2023-12-29": decimal.RequireFromString("1.2"),
+		"2024-01-02": decimal.RequireFromString("1.25"),
+		"2024-01-09": decimal.RequireFromString("1.25"),
+		"2024-03-05": decimal.RequireFromString("1.25"),
+	},
+}
+
+var header = []string{"Position ID", "Action", "Long / Short", "Amount", "Units", "Open Date", "Close Date", "Leverage", "Spread Fees (USD)", "Market Spread (USD)", "Profit(USD)", "Open Rate", "Close Rate", "Type", "ISIN"}
+
+func TestRecordReader_ReadRecord(t *testing.T) {
+	rows := [][]string{
+		header,
+		{"2001", "Buy Vanguard FTSE All-World", "Long", "200", "2", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "1", "0.5", "0", "20", "100", "110", "ETF", "IE00BK5BQT80"},
+		{"2000", "Buy Apple", "Long", "270", "1.5", "45292.5", "45300", "1", "", "0", "15", "180", "190", "Stocks", ""},
+	}
+
+	t.Run("matched", func(t *testing.T) {
+		rr := NewRecordReader(bytes.NewReader(NewTestStatement(t, rows)), NewFigiClientSecurityTypeStub(t, "Other"), true, testRates)
+
+		got := readAll(t, rr)
+		if len(got) != 2 {
+			t.Fatalf("want 2 records but got %d", len(got))
+		}
+
+		cp, ok := got[0].(internal.ClosedPosition)
+		if !ok {
+			t.Fatalf("want a ClosedPosition but got %T", got[0])
+		}
+
+		assertRecord(t, cp, "Apple", internal.SideSell, "1.5", "152", "0", time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC))
+		if !cp.OpenPrice().Equal(decimal.RequireFromString("150")) {
+			t.Fatalf("want open price 150 but got %v", cp.OpenPrice())
+		}
+		if currency, rate := internal.ExchangeRateOf(cp); currency != "USD" || !rate.Equal(decimal.RequireFromString("1.25")) {
+			t.Fatalf("want the USD rate 1.25 but got %s %v", currency, rate)
+		}
+		if !cp.OpenTimestamp().Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+			t.Fatalf("want open timestamp 2024-01-01 12:00:00 but got %v", cp.OpenTimestamp())
+		}
+		if cp.Nature() != internal.NatureG01 {
+			t.Fatalf("want nature %v but got %v", internal.NatureG01, cp.Nature())
+		}
+
+		assertRecord(t, got[1], "IE00BK5BQT80", internal.SideSell, "2", "88", "0.4", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC))
+		if got[1].Nature() != internal.NatureG20 {
+			t.Fatalf("want nature %v but got %v", internal.NatureG20, got[1].Nature())
+		}
+		if got[1].AssetCountry() != int64(countries.Ireland) {
+			t.Fatalf("want asset country %d but got %d", countries.Ireland, got[1].AssetCountry())
+		}
+	})
+
+	t.Run("individual", func(t *testing.T) {
+		rr := NewRecordReader(bytes.NewReader(NewTestStatement(t, rows)), NewFigiClientSecurityTypeStub(t, "Other"), false, testRates)
+
+		got := readAll(t, rr)
+		if len(got) != 4 {
+			t.Fatalf("want 4 records but got %d", len(got))
+		}
+
+		for _, rec := range got {
+			if _, ok := rec.(internal.ClosedPosition); ok {
+				t.Fatalf("want plain records but got a ClosedPosition")
+			}
+		}
+
+		assertRecord(t, got[0], "Apple", internal.SideBuy, "1.5", "150", "0", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+		assertRecord(t, got[1], "IE00BK5BQT80", internal.SideBuy, "2", "80", "0", time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+		assertRecord(t, got[2], "Apple", internal.SideSell, "1.5", "152", "0", time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC))
+		assertRecord(t, got[3], "IE00BK5BQT80", internal.SideSell, "2", "88", "0.4", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC))
+
+		if _, rate := internal.ExchangeRateOf(got[0]); !rate.Equal(decimal.RequireFromString("1.2")) {
+			t.Fatalf("want the buy converted with the rate of its day but got %v", rate)
+		}
+	})
+}
+
+func TestRecordReader_ReadRecordErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]string
+	}{
+		{"missing header", [][]string{{"Position ID", "Action"}}},
+		{"short position", [][]string{header, {"1", "Sell Apple", "Short", "100", "1", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "1", "", "", "", "100", "110"}}},
+		{"leveraged position", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "2", "", "", "", "100", "110"}}},
+		{"malformed units", [][]string{header, {"1", "Buy Apple", "Long", "100", "BAD", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "1", "", "", "", "100", "110"}}},
+		{"malformed open rate", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "1", "", "", "", "", "110"}}},
+		{"malformed close rate", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "1", "", "", "", "100", "BAD"}}},
+		{"malformed open date", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "2024-01-02", "05/03/2024 10:00:00", "1", "", "", "", "100", "110"}}},
+		{"malformed close date", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "02/01/2024 09:00:00", "", "1", "", "", "", "100", "110"}}},
+		{"missing exchange rate", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "02/01/2023 09:00:00", "05/03/2024 10:00:00", "1", "", "", "", "100", "110"}}},
+		{"malformed spread fees", [][]string{header, {"1", "Buy Apple", "Long", "100", "1", "02/01/2024 09:00:00", "05/03/2024 10:00:00", "1", "BAD", "", "", "100", "110"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := NewRecordReader(bytes.NewReader(NewTestStatement(t, tt.rows)), NewFigiClientSecurityTypeStub(t, "Common Stock"), true, testRates)
+			_, err := rr.ReadRecord(t.Context())
+			if err == nil || errors.Is(err, io.EOF) {
+				t.Fatalf("want parsing error but got %v", err)
+			}
+		})
+	}
+}
+
+func readAll(t testing.TB, rr *RecordReader) []internal.Record {
+	t.Helper()
+
+	var records []internal.Record
+	for {
+		rec, err := rr.ReadRecord(t.Context())
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("ReadRecord() failed: %v", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func assertRecord(t testing.TB, got internal.Record, symbol string, side internal.Side, quantity, price, fees string, ts time.Time) {
+	t.Helper()
+
+	if got.Symbol() != symbol {
+		t.Fatalf("want symbol %v but got %v", symbol, got.Symbol())
+	}
+
+	if got.Side() != side {
+		t.Fatalf("want side %v but got %v", side, got.Side())
+	}
+
+	if !got.Quantity().Equal(decimal.RequireFromString(quantity)) {
+		t.Fatalf("want quantity %v but got %v", quantity, got.Quantity())
+	}
+
+	if !got.Price().Equal(decimal.RequireFromString(price)) {
+		t.Fatalf("want price %v but got %v", price, got.Price())
+	}
+
+	if !got.Fees().Equal(decimal.RequireFromString(fees)) {
+		t.Fatalf("want fees %v but got %v", fees, got.Fees())
+	}
+
+	if !got.Timestamp().Equal(ts) {
+		t.Fatalf("want timestamp %v but got %v", ts, got.Timestamp())
+	}
+
+	if got.BrokerCountry() != int64(Country) {
+		t.Fatalf("want broker country %v but got %v", int64(Country), got.BrokerCountry())
+	}
+}
+
+// NewTestStatement builds a minimal workbook with a closed positions sheet containing rows.
+func NewTestStatement(t testing.TB, rows [][]string) []byte {
+	t.Helper()
+
+	var sheet strings.Builder
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, val := range row {
+			if val == "" {
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%c%d" t="inlineStr"><is><t>`, 'A'+j, i+1)
+			_ = xml.EscapeText(&sheet, []byte(val))
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Closed Positions" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   sheet.String(),
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		_, err = w.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("close zip writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func NewFigiClientSecurityTypeStub(t testing.TB, securityType string) *internal.OpenFIGI {
+	t.Helper()
+
+	c := &http.Client{
+		Timeout: time.Second,
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"data":[{"securityType":%q}]}]`, securityType))),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	return internal.NewOpenFIGI(c)
+}
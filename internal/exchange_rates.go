@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRatesMaxGap is how many days before a date are searched for a rate since there are no
+// reference rates on weekends and holidays.
+const ExchangeRatesMaxGap = 7
+
+// ExchangeRates are the daily reference rates of a currency as units of that currency per EUR.
+type ExchangeRates struct {
+	Currency string
+	// Rates by date formatted as time.DateOnly.
+	Rates map[string]decimal.Decimal
+}
+
+// Rate returns the rate of the day of t or, if there is none, of the closest previous day up to
+// ExchangeRatesMaxGap days before.
+func (er ExchangeRates) Rate(t time.Time) (decimal.Decimal, error) {
+	for i := range ExchangeRatesMaxGap + 1 {
+		rate, ok := er.Rates[t.AddDate(0, 0, -i).Format(time.DateOnly)]
+		if ok {
+			return rate, nil
+		}
+	}
+
+	return decimal.Decimal{}, fmt.Errorf("missing %s exchange rate for %s", er.Currency, t.Format(time.DateOnly))
+}
+
+// LoadExchangeRates reads the rates of currency from the CSV file in path.
+func LoadExchangeRates(path, currency string) (ExchangeRates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ExchangeRates{}, fmt.Errorf("open exchange rates: %w", err)
+	}
+	defer f.Close()
+
+	return ReadExchangeRates(f, currency)
+}
+
+// ReadExchangeRates reads the rates of currency in the format of the ECB euro foreign exchange
+// reference rates history (eurofxref-hist.csv): a Date column followed by a column per currency.
+// Days without a rate for the currency (i.e.: N/A) are skipped.
+func ReadExchangeRates(r io.Reader, currency string) (ExchangeRates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return ExchangeRates{}, fmt.Errorf("read exchange rates header: %w", err)
+	}
+
+	dateCol, rateCol := -1, -1
+	for i, name := range header {
+		switch {
+		case strings.EqualFold(strings.TrimSpace(name), "date"):
+			dateCol = i
+		case strings.EqualFold(strings.TrimSpace(name), currency):
+			rateCol = i
+		}
+	}
+
+	if dateCol < 0 {
+		return ExchangeRates{}, fmt.Errorf("missing exchange rates column: Date")
+	}
+
+	if rateCol < 0 {
+		return ExchangeRates{}, fmt.Errorf("missing exchange rates column: %s", currency)
+	}
+
+	er := ExchangeRates{
+		Currency: strings.ToUpper(currency),
+		Rates:    make(map[string]decimal.Decimal),
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return ExchangeRates{}, fmt.Errorf("read exchange rates: %w", err)
+		}
+
+		if len(row) <= max(dateCol, rateCol) {
+			return ExchangeRates{}, fmt.Errorf("read exchange rates: short row: %v", row)
+		}
+
+		raw := strings.TrimSpace(row[rateCol])
+		if raw == "" || strings.EqualFold(raw, "N/A") {
+			continue
+		}
+
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(row[dateCol]))
+		if err != nil {
+			return ExchangeRates{}, fmt.Errorf("parse exchange rate date: %w", err)
+		}
+
+		rate, err := decimal.NewFromString(raw)
+		if err != nil {
+			return ExchangeRates{}, fmt.Errorf("parse exchange rate: %w", err)
+		}
+
+		if !rate.IsPositive() {
+			return ExchangeRates{}, fmt.Errorf("invalid exchange rate of %s: %s", date.Format(time.DateOnly), raw)
+		}
+
+		er.Rates[date.Format(time.DateOnly)] = rate
+	}
+
+	return er, nil
+}
@@ -0,0 +1,70 @@
+package internal_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+)
+
+func TestReadExchangeRates(t *testing.T) {
+	input := `Date,USD,JPY,BGN,
+2024-01-05,1.0921,158.1,N/A,
+2024-01-04,1.0953,157.93,1.9558,
+2024-01-03,N/A,156.59,1.9558,
+2024-01-02,1.0956,155.36,1.9558,
+`
+
+	er, err := internal.ReadExchangeRates(strings.NewReader(input), "usd")
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		want    string
+		wantErr bool
+	}{
+		{"same day", time.Date(2024, 1, 4, 15, 0, 0, 0, time.UTC), "1.0953", false},
+		{"missing rate", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), "1.0956", false},
+		{"weekend", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC), "1.0921", false},
+		{"before the first rate", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "", true},
+		{"too far after the last rate", time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := er.Rate(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("want %s but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadExchangeRates_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"missing currency", "Date,JPY\n2024-01-02,155.36\n"},
+		{"missing date", "Day,USD\n2024-01-02,1.0956\n"},
+		{"malformed date", "Date,USD\n02/01/2024,1.0956\n"},
+		{"malformed rate", "Date,USD\n2024-01-02,BAD\n"},
+		{"negative rate", "Date,USD\n2024-01-02,-1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := internal.ReadExchangeRates(strings.NewReader(tt.input), "USD")
+			if err == nil {
+				t.Fatalf("want an error")
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"container/list"
+	"iter"
 
 	"github.com/shopspring/decimal"
 )
@@ -27,6 +28,16 @@ func (f *Filler) Fill(quantity decimal.Decimal) (decimal.Decimal, bool) {
 	return delta, f.IsFilled()
 }
 
+// Filled returns the quantity filled so far.
+func (f *Filler) Filled() decimal.Decimal {
+	return f.filled
+}
+
+// Unfilled returns the quantity that was not filled yet.
+func (f *Filler) Unfilled() decimal.Decimal {
+	return f.Quantity().Sub(f.filled)
+}
+
 // IsFilled returns true if the fill is equal to the record quantity.
 func (f *Filler) IsFilled() bool {
 	return f.filled.Equal(f.Quantity())
@@ -54,6 +65,32 @@ func (fq *FillerQueue) Push(f *Filler) {
 	fq.l.PushBack(f)
 }
 
+// Insert adds the Filler right before the first Filler with a later timestamp. This keeps the queue
+// in chronological order when adding lots acquired in the past.
+func (fq *FillerQueue) Insert(f *Filler) {
+	if f == nil {
+		return
+	}
+
+	if fq == nil {
+		// This would cause a panic anyway so, we panic with a more meaningful message
+		panic("Insert to nil FillerQueue")
+	}
+
+	if fq.l == nil {
+		fq.l = list.New()
+	}
+
+	for el := fq.l.Front(); el != nil; el = el.Next() {
+		if el.Value.(*Filler).Timestamp().After(f.Timestamp()) {
+			fq.l.InsertBefore(f, el)
+			return
+		}
+	}
+
+	fq.l.PushBack(f)
+}
+
 // Pop removes and returns the first Filler of the queue in the 1st return value. If the list is
 // empty returns false on the 2nd return value, true otherwise.
 func (fq *FillerQueue) Pop() (*Filler, bool) {
@@ -86,6 +123,21 @@ func (fq *FillerQueue) frontElement() *list.Element {
 	return fq.l.Front()
 }
 
+// All returns an iterator over the Fillers of the queue, from front to back.
+func (fq *FillerQueue) All() iter.Seq[*Filler] {
+	return func(yield func(*Filler) bool) {
+		if fq == nil || fq.l == nil {
+			return
+		}
+
+		for el := fq.l.Front(); el != nil; el = el.Next() {
+			if !yield(el.Value.(*Filler)) {
+				return
+			}
+		}
+	}
+}
+
 // Len returns how many elements are currently on the queue
 func (fq *FillerQueue) Len() int {
 	if fq == nil || fq.l == nil {
@@ -94,3 +146,13 @@ func (fq *FillerQueue) Len() int {
 
 	return fq.l.Len()
 }
+
+// Unfilled returns the sum of the quantity not yet filled of every Filler in the queue.
+func (fq *FillerQueue) Unfilled() decimal.Decimal {
+	var total decimal.Decimal
+	for f := range fq.All() {
+		total = total.Add(f.Unfilled())
+	}
+
+	return total
+}
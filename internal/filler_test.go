@@ -2,6 +2,7 @@ package internal
 
 import (
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -120,14 +121,45 @@ func TestFillerQueueNilReceiver(t *testing.T) {
 type testRecord struct {
 	Record
 
-	id       int
-	quantity decimal.Decimal
+	id        int
+	quantity  decimal.Decimal
+	timestamp time.Time
 }
 
 func (tr testRecord) Quantity() decimal.Decimal {
 	return tr.quantity
 }
 
+func (tr testRecord) Timestamp() time.Time {
+	return tr.timestamp
+}
+
+func TestFillerQueue_Insert(t *testing.T) {
+	now := time.Now()
+
+	var rq FillerQueue
+	rq.Insert(nil)
+	if rq.Len() != 0 {
+		t.Fatalf("inserting nil should be a no-op")
+	}
+
+	rq.Insert(NewFiller(testRecord{id: 1, timestamp: now.Add(2)}))
+	rq.Insert(NewFiller(testRecord{id: 2, timestamp: now}))
+	rq.Insert(NewFiller(testRecord{id: 3, timestamp: now.Add(3)}))
+	rq.Insert(NewFiller(testRecord{id: 4, timestamp: now.Add(2)}))
+
+	for _, want := range []int{2, 1, 4, 3} {
+		f, ok := rq.Pop()
+		if !ok {
+			t.Fatalf("want record %d but queue is empty", want)
+		}
+
+		if got := f.Record.(testRecord).id; got != want {
+			t.Fatalf("want record %d but got %d", want, got)
+		}
+	}
+}
+
 func TestFiller_Fill(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,185 @@
+package generic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/biter777/countries"
+)
+
+// Config describes how to read the CSV statement of a broker without a dedicated reader.
+type Config struct {
+	// Delimiter separating the fields of each line. Defaults to a comma.
+	Delimiter string `json:"delimiter"`
+	// DecimalSeparator is either a dot or a comma. Defaults to a dot. The other one is assumed to be
+	// used as thousands separator and is ignored.
+	DecimalSeparator string `json:"decimal_separator"`
+	// TimestampFormat uses the Go reference time layout (i.e.: 2006-01-02 15:04:05 or 02/01/2006).
+	TimestampFormat string `json:"timestamp_format"`
+	// BrokerCountry is the ISO 3166-1 alpha-2 code of the broker's country (i.e.: DE). It's only
+	// optional when the broker country column is configured, in which case it's used as a fallback for
+	// empty cells.
+	BrokerCountry string `json:"broker_country"`
+	// Side lists the values of the side column that identify buys and sells. Lines with any other
+	// value are skipped. The comparison is case insensitive.
+	Side SideConfig `json:"side"`
+	// Columns maps each field to a column name in the header line.
+	Columns ColumnsConfig `json:"columns"`
+}
+
+type SideConfig struct {
+	Buy  []string `json:"buy"`
+	Sell []string `json:"sell"`
+	// TransferIn is optional and identifies securities received from another broker.
+	TransferIn []string `json:"transfer_in"`
+	// TransferOut is optional and identifies securities sent to another broker.
+	TransferOut []string `json:"transfer_out"`
+	// Expire is optional and identifies options and futures that reached their expiry.
+	Expire []string `json:"expire"`
+	// Exercise is optional and identifies options exercised by the holder.
+	Exercise []string `json:"exercise"`
+	// Assign is optional and identifies options assigned to the writer.
+	Assign []string `json:"assign"`
+	// Rename is optional and identifies ISIN changes and splits.
+	Rename []string `json:"rename"`
+	// SpinOff is optional and identifies spin-offs.
+	SpinOff []string `json:"spin_off"`
+	// Merger is optional and identifies mergers.
+	Merger []string `json:"merger"`
+}
+
+type ColumnsConfig struct {
+	Timestamp string `json:"timestamp"`
+	Side      string `json:"side"`
+	ISIN      string `json:"isin"`
+	Quantity  string `json:"quantity"`
+	Price     string `json:"price"`
+	// Fees is optional.
+	Fees string `json:"fees"`
+	// Taxes is optional.
+	Taxes string `json:"taxes"`
+	// Currency is optional. When set, lines not in EUR must have an exchange rate.
+	Currency string `json:"currency"`
+	// ExchangeRate is optional and is expressed as units of the line's currency per EUR, like the
+	// ECB reference rates. It's used to convert the price, fees and taxes to EUR.
+	ExchangeRate string `json:"exchange_rate"`
+	// BrokerCountry is optional and takes precedence over the broker country of the Config.
+	BrokerCountry string `json:"broker_country"`
+	// AssetCountry is optional. When empty the country is derived from the ISIN.
+	AssetCountry string `json:"asset_country"`
+	// Nature is optional. When empty the nature is resolved using the ISIN.
+	Nature string `json:"nature"`
+
+	// The following columns are optional and, unlike the others, may be missing from the header.
+
+	// Account identifies the account of the line when the input has more than one.
+	Account string `json:"account"`
+	// Instrument is either "option" or "future" for derivatives. Any other line is a security.
+	Instrument string `json:"instrument"`
+	// OptionRight is either "call" or "put" and is required for options.
+	OptionRight string `json:"option_right"`
+	// Underlying is the symbol of the security of the derivative.
+	Underlying string `json:"underlying"`
+	// UnderlyingNature is the nature of the underlying, required to exercise or assign options.
+	UnderlyingNature string `json:"underlying_nature"`
+	// Strike is the strike price of options, in the currency of the line.
+	Strike string `json:"strike"`
+	// Expiry is the expiry date of the derivative, in the timestamp format or as 2006-01-02.
+	Expiry string `json:"expiry"`
+	// Multiplier is the number of units of the underlying per contract. Defaults to 1.
+	Multiplier string `json:"multiplier"`
+	// AccruedInterest is the total interest paid or received when trading bonds, in the currency of
+	// the line. The price must then be the clean price.
+	AccruedInterest string `json:"accrued_interest"`
+	// NewSymbol is the security received in corporate actions. Defaults to the symbol of the line
+	// for renames, which are then splits.
+	NewSymbol string `json:"new_symbol"`
+	// NewNature is the nature of the security received in corporate actions.
+	NewNature string `json:"new_nature"`
+	// Ratio is the number of new shares per share held in corporate actions.
+	Ratio string `json:"ratio"`
+	// CostFraction is the fraction of the cost that goes to the spin-off or to the cash of mergers.
+	CostFraction string `json:"cost_fraction"`
+	// Cash is the amount received per share held in mergers, in the currency of the line.
+	Cash string `json:"cash"`
+}
+
+// LoadConfig reads and validates a JSON Config from the file at path.
+func LoadConfig(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	return ReadConfig(f)
+}
+
+// ReadConfig reads and validates a JSON Config.
+func ReadConfig(r io.Reader) (Config, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var cfg Config
+	err := dec.Decode(&cfg)
+	if err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// Validate returns an error describing the first problem found in the Config.
+func (c Config) Validate() error {
+	if len([]rune(c.Delimiter)) > 1 {
+		return fmt.Errorf("delimiter must be a single character: %q", c.Delimiter)
+	}
+
+	switch c.DecimalSeparator {
+	case "", ".", ",":
+	default:
+		return fmt.Errorf("decimal separator must be either a dot or a comma: %q", c.DecimalSeparator)
+	}
+
+	if c.TimestampFormat == "" {
+		return fmt.Errorf("missing timestamp format")
+	}
+
+	if (c.BrokerCountry != "" || c.Columns.BrokerCountry == "") && !countries.ByName(c.BrokerCountry).IsValid() {
+		return fmt.Errorf("invalid broker country: %q", c.BrokerCountry)
+	}
+
+	if len(c.Side.Buy) == 0 || len(c.Side.Sell) == 0 {
+		return fmt.Errorf("missing buy or sell side values")
+	}
+
+	required := []struct{ field, column string }{
+		{"timestamp", c.Columns.Timestamp},
+		{"side", c.Columns.Side},
+		{"isin", c.Columns.ISIN},
+		{"quantity", c.Columns.Quantity},
+		{"price", c.Columns.Price},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.column) == "" {
+			return fmt.Errorf("missing column for %s", r.field)
+		}
+	}
+
+	return nil
+}
+
+func (c Config) delimiter() rune {
+	if c.Delimiter == "" {
+		return ','
+	}
+	return []rune(c.Delimiter)[0]
+}
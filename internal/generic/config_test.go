@@ -0,0 +1,103 @@
+package generic
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name: "minimal config",
+			config: `{
+				"timestamp_format": "2006-01-02",
+				"broker_country": "DE",
+				"side": {"buy": ["Buy"], "sell": ["Sell"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares", "price": "Price"}
+			}`,
+		},
+		{
+			name:    "malformed json",
+			config:  `{"timestamp_format":`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			config:  `{"timestamp_format": "2006-01-02", "foo": "bar"}`,
+			wantErr: true,
+		},
+		{
+			name: "long delimiter",
+			config: `{
+				"delimiter": ";;",
+				"timestamp_format": "2006-01-02",
+				"broker_country": "DE",
+				"side": {"buy": ["Buy"], "sell": ["Sell"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares", "price": "Price"}
+			}`,
+			wantErr: true,
+		},
+		{
+			name: "bad decimal separator",
+			config: `{
+				"decimal_separator": "'",
+				"timestamp_format": "2006-01-02",
+				"broker_country": "DE",
+				"side": {"buy": ["Buy"], "sell": ["Sell"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares", "price": "Price"}
+			}`,
+			wantErr: true,
+		},
+		{
+			name: "missing timestamp format",
+			config: `{
+				"broker_country": "DE",
+				"side": {"buy": ["Buy"], "sell": ["Sell"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares", "price": "Price"}
+			}`,
+			wantErr: true,
+		},
+		{
+			name: "bad broker country",
+			config: `{
+				"timestamp_format": "2006-01-02",
+				"broker_country": "ZZ",
+				"side": {"buy": ["Buy"], "sell": ["Sell"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares", "price": "Price"}
+			}`,
+			wantErr: true,
+		},
+		{
+			name: "missing sell side",
+			config: `{
+				"timestamp_format": "2006-01-02",
+				"broker_country": "DE",
+				"side": {"buy": ["Buy"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares", "price": "Price"}
+			}`,
+			wantErr: true,
+		},
+		{
+			name: "missing price column",
+			config: `{
+				"timestamp_format": "2006-01-02",
+				"broker_country": "DE",
+				"side": {"buy": ["Buy"], "sell": ["Sell"]},
+				"columns": {"timestamp": "Date", "side": "Type", "isin": "ISIN", "quantity": "Shares"}
+			}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadConfig(bytes.NewBufferString(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error to be %v but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
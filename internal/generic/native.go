@@ -0,0 +1,83 @@
+package generic
+
+import "time"
+
+// NativeConfig describes the first-party CSV format which mirrors the internal.Record interface. It
+// is meant for data converted from sources without a reader and, unlike broker statements, is kept
+// stable across releases. See the README for the full description of each column.
+func NativeConfig() Config {
+	return Config{
+		Delimiter:        ",",
+		DecimalSeparator: ".",
+		TimestampFormat:  time.RFC3339,
+		Side: SideConfig{
+			Buy:         []string{"buy"},
+			Sell:        []string{"sell"},
+			TransferIn:  []string{"transfer_in"},
+			TransferOut: []string{"transfer_out"},
+			Expire:      []string{"expire"},
+			Exercise:    []string{"exercise"},
+			Assign:      []string{"assign"},
+			Rename:      []string{"rename"},
+			SpinOff:     []string{"spin_off"},
+			Merger:      []string{"merger"},
+		},
+		Columns: ColumnsConfig{
+			Timestamp:        "timestamp",
+			Side:             "side",
+			ISIN:             "symbol",
+			Quantity:         "quantity",
+			Price:            "price",
+			Fees:             "fees",
+			Taxes:            "taxes",
+			Currency:         "currency",
+			ExchangeRate:     "exchange_rate",
+			BrokerCountry:    "broker_country",
+			AssetCountry:     "asset_country",
+			Nature:           "nature",
+			Account:          "account",
+			Instrument:       "instrument",
+			OptionRight:      "option_right",
+			Underlying:       "underlying",
+			UnderlyingNature: "underlying_nature",
+			Strike:           "strike",
+			Expiry:           "expiry",
+			Multiplier:       "multiplier",
+			AccruedInterest:  "accrued_interest",
+			NewSymbol:        "new_symbol",
+			NewNature:        "new_nature",
+			Ratio:            "ratio",
+			CostFraction:     "cost_fraction",
+			Cash:             "cash",
+		},
+	}
+}
+
+// ActionsConfig describes the CSV format of the corporate actions file. Each line has the date of
+// the action, its kind (rename, split, spin_off or merger) and the values described in the README.
+// Unlike the native format, lines don't have quantities, prices nor countries.
+func ActionsConfig() Config {
+	return Config{
+		Delimiter:        ",",
+		DecimalSeparator: ".",
+		TimestampFormat:  time.DateOnly,
+		Side: SideConfig{
+			Rename:  []string{"rename", "split"},
+			SpinOff: []string{"spin_off"},
+			Merger:  []string{"merger"},
+		},
+		Columns: ColumnsConfig{
+			Timestamp:    "date",
+			Side:         "action",
+			ISIN:         "symbol",
+			Currency:     "currency",
+			ExchangeRate: "exchange_rate",
+			Account:      "account",
+			NewSymbol:    "new_symbol",
+			NewNature:    "new_nature",
+			Ratio:        "ratio",
+			CostFraction: "cost_fraction",
+			Cash:         "cash",
+		},
+	}
+}
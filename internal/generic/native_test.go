@@ -0,0 +1,260 @@
+package generic
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestNativeConfig(t *testing.T) {
+	err := NativeConfig().Validate()
+	if err != nil {
+		t.Fatalf("want native config to be valid but got: %v", err)
+	}
+
+	csv := `timestamp,side,symbol,quantity,price,currency,exchange_rate,fees,taxes,broker_country,asset_country,nature
+2024-01-02T10:00:00Z,buy,US0378331005,10,150.5,EUR,,1,,IE,,G01
+2024-03-04T15:30:00+01:00,sell,MY-PRIVATE-FUND,5,220,USD,1.1,1.1,0.22,US,US,G20
+2024-03-05T15:30:00Z,sell,US0378331005,5,200,EUR,,,,DE,,BAD
+`
+
+	rr := NewRecordReader(bytes.NewBufferString(csv), NewFigiClientSecurityTypeStub(t, "Other"), NativeConfig())
+
+	got, err := rr.ReadRecord(t.Context())
+	if err != nil {
+		t.Fatalf("want 1st record but got error: %v", err)
+	}
+
+	if got.Side() != internal.SideBuy || !got.Price().Equal(decimal.RequireFromString("150.5")) || !got.Fees().Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("want buy of 10 at 150.5 with 1 of fees but got %v of %v at %v with %v of fees", got.Side(), got.Quantity(), got.Price(), got.Fees())
+	}
+
+	if got.BrokerCountry() != int64(countries.Ireland) || got.AssetCountry() != int64(countries.USA) {
+		t.Fatalf("want broker country %d and asset country %d but got %d and %d", countries.Ireland, countries.USA, got.BrokerCountry(), got.AssetCountry())
+	}
+
+	if got.Nature() != internal.NatureG01 {
+		t.Fatalf("want nature %v but got %v", internal.NatureG01, got.Nature())
+	}
+
+	got, err = rr.ReadRecord(t.Context())
+	if err != nil {
+		t.Fatalf("want 2nd record but got error: %v", err)
+	}
+
+	if got.Symbol() != "MY-PRIVATE-FUND" || got.Side() != internal.SideSell {
+		t.Fatalf("want sell of MY-PRIVATE-FUND but got %v of %v", got.Side(), got.Symbol())
+	}
+
+	if !got.Timestamp().Equal(time.Date(2024, 3, 4, 14, 30, 0, 0, time.UTC)) {
+		t.Fatalf("want timestamp 2024-03-04 14:30 UTC but got %v", got.Timestamp())
+	}
+
+	if !got.Price().Equal(decimal.NewFromInt(200)) || !got.Fees().Equal(decimal.NewFromInt(1)) || !got.Taxes().Equal(decimal.RequireFromString("0.2")) {
+		t.Fatalf("want values converted to EUR but got price %v, fees %v and taxes %v", got.Price(), got.Fees(), got.Taxes())
+	}
+
+	if got.AssetCountry() != int64(countries.USA) || got.Nature() != internal.NatureG20 {
+		t.Fatalf("want asset country %d and nature %v but got %d and %v", countries.USA, internal.NatureG20, got.AssetCountry(), got.Nature())
+	}
+
+	if got := internal.NatureSourceOf(got); got != internal.NatureSourceStatement {
+		t.Fatalf("want nature source %v but got %v", internal.NatureSourceStatement, got)
+	}
+
+	if currency, rate := internal.ExchangeRateOf(got); currency != "USD" || !rate.Equal(decimal.RequireFromString("1.1")) {
+		t.Fatalf("want exchange rate 1.1 USD but got %v %v", rate, currency)
+	}
+
+	_, err = rr.ReadRecord(t.Context())
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("want error for unknown nature but got %v", err)
+	}
+}
+
+func TestNativeConfig_Account(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{
+			name: "without account column",
+			csv:  "timestamp,side,symbol,quantity,price,currency,exchange_rate,fees,taxes,broker_country,asset_country,nature\n2024-01-02T10:00:00Z,buy,US0378331005,10,150.5,,,,,IE,,G01\n",
+			want: "",
+		},
+		{
+			name: "with account column",
+			csv:  "timestamp,side,symbol,quantity,price,currency,exchange_rate,fees,taxes,broker_country,asset_country,nature,account\n2024-01-02T10:00:00Z,buy,US0378331005,10,150.5,,,,,IE,,G01,joint\n",
+			want: "joint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := NewRecordReader(bytes.NewBufferString(tt.csv), NewFigiClientSecurityTypeStub(t, "Other"), NativeConfig())
+
+			got, err := rr.ReadRecord(t.Context())
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+
+			if internal.AccountOf(got) != tt.want {
+				t.Fatalf("want account %q but got %q", tt.want, internal.AccountOf(got))
+			}
+		})
+	}
+}
+
+func TestNativeConfig_Derivatives(t *testing.T) {
+	csv := `timestamp,side,symbol,quantity,price,currency,exchange_rate,fees,taxes,broker_country,asset_country,nature,instrument,option_right,underlying,underlying_nature,strike,expiry,multiplier
+2024-01-02T10:00:00Z,sell,AAPL 240621C00200000,1,2.5,USD,1.1,0.7,,IE,,,option,call,US0378331005,G01,220,2024-06-21,100
+2024-06-21T22:00:00Z,assign,AAPL 240621C00200000,1,,USD,1.1,,,IE,,,option,call,US0378331005,G01,220,2024-06-21,100
+2024-06-21T22:00:00Z,expire,FESX,2,5000,EUR,,,,IE,DE,,future,,,,,,10
+2024-06-21T22:00:00Z,sell,US0378331005,100,200,EUR,,,,IE,,G01,,,,,,,
+2024-06-21T22:00:00Z,buy,AAPL 240621P00200000,1,2,EUR,,,,IE,,,option,,US0378331005,G01,200,2024-06-21,100
+`
+
+	rr := NewRecordReader(bytes.NewBufferString(csv), NewFigiClientSecurityTypeStub(t, "Other"), NativeConfig())
+
+	want := []struct {
+		side       internal.Side
+		derivative *internal.Derivative
+	}{
+		{internal.SideSell, &internal.Derivative{
+			Kind:             internal.DerivativeOption,
+			Right:            internal.OptionRightCall,
+			Underlying:       "US0378331005",
+			UnderlyingNature: internal.NatureG01,
+			Strike:           decimal.NewFromInt(200),
+			Expiry:           time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+			Multiplier:       decimal.NewFromInt(100),
+		}},
+		{internal.SideAssign, &internal.Derivative{
+			Kind:             internal.DerivativeOption,
+			Right:            internal.OptionRightCall,
+			Underlying:       "US0378331005",
+			UnderlyingNature: internal.NatureG01,
+			Strike:           decimal.NewFromInt(200),
+			Expiry:           time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC),
+			Multiplier:       decimal.NewFromInt(100),
+		}},
+		{internal.SideExpire, &internal.Derivative{
+			Kind:       internal.DerivativeFuture,
+			Multiplier: decimal.NewFromInt(10),
+		}},
+		{internal.SideSell, nil},
+	}
+	for i, w := range want {
+		got, err := rr.ReadRecord(t.Context())
+		if err != nil {
+			t.Fatalf("want record %d but got error: %v", i, err)
+		}
+
+		if got.Side() != w.side {
+			t.Fatalf("want record %d to be a %v but got %v", i, w.side, got.Side())
+		}
+
+		dr, ok := got.(internal.DerivativeRecord)
+		if ok != (w.derivative != nil) {
+			t.Fatalf("want record %d to be a derivative %v but got %v", i, w.derivative != nil, ok)
+		}
+
+		if !ok {
+			continue
+		}
+
+		d := dr.Derivative()
+		if d.Kind != w.derivative.Kind || d.Right != w.derivative.Right || d.Underlying != w.derivative.Underlying ||
+			d.UnderlyingNature != w.derivative.UnderlyingNature || !d.Strike.Equal(w.derivative.Strike) ||
+			!d.Expiry.Equal(w.derivative.Expiry) || !d.Multiplier.Equal(w.derivative.Multiplier) {
+			t.Fatalf("want record %d to have derivative %+v but got %+v", i, *w.derivative, d)
+		}
+
+		if got.Nature() != internal.NatureG40 {
+			t.Fatalf("want record %d to have nature %v but got %v", i, internal.NatureG40, got.Nature())
+		}
+	}
+
+	_, err := rr.ReadRecord(t.Context())
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("want error for option without right but got %v", err)
+	}
+}
+
+func TestNativeConfig_AccruedInterest(t *testing.T) {
+	csv := `timestamp,side,symbol,quantity,price,currency,exchange_rate,fees,taxes,broker_country,asset_country,nature,accrued_interest
+2024-01-02T10:00:00Z,buy,DE0001102580,1000,0.98,USD,1.1,,,IE,,G10,-11
+`
+
+	rr := NewRecordReader(bytes.NewBufferString(csv), NewFigiClientSecurityTypeStub(t, "Other"), NativeConfig())
+
+	got, err := rr.ReadRecord(t.Context())
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	ai, ok := got.(internal.AccruedInterestRecord)
+	if !ok || !ai.AccruedInterest().Equal(decimal.NewFromInt(10)) {
+		t.Fatalf("want accrued interest of 10 EUR but got %v", got)
+	}
+
+	if got.Nature() != internal.NatureG10 {
+		t.Fatalf("want nature %v but got %v", internal.NatureG10, got.Nature())
+	}
+}
+
+func TestActionsConfig(t *testing.T) {
+	csv := `date,action,symbol,new_symbol,ratio,cost_fraction,cash,currency,exchange_rate
+2024-01-02,rename,US0000000001,US0000000002,1,,,,
+2024-02-03,split,US0000000002,,4,,,,
+2024-03-04,spin_off,US0000000002,US0000000003,0.5,0.2,,,
+2024-04-05,merger,US0000000003,US0000000004,2,0.1,2.2,USD,1.1
+2024-05-06,dividend,US0000000004,,,,,,
+2024-05-06,merger,US0000000004,,,,abc,,
+`
+
+	rr := NewRecordReader(bytes.NewBufferString(csv), NewFigiClientSecurityTypeStub(t, "Other"), ActionsConfig())
+
+	want := []struct {
+		symbol string
+		ts     time.Time
+		action internal.CorporateAction
+	}{
+		{"US0000000001", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "US0000000002", Ratio: decimal.NewFromInt(1)}},
+		{"US0000000002", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "US0000000002", Ratio: decimal.NewFromInt(4)}},
+		{"US0000000002", time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), internal.CorporateAction{Kind: internal.CorporateActionSpinOff, NewSymbol: "US0000000003", Ratio: decimal.RequireFromString("0.5"), CostFraction: decimal.RequireFromString("0.2")}},
+		{"US0000000003", time.Date(2024, 4, 5, 0, 0, 0, 0, time.UTC), internal.CorporateAction{Kind: internal.CorporateActionMerger, NewSymbol: "US0000000004", Ratio: decimal.NewFromInt(2), CostFraction: decimal.RequireFromString("0.1"), Cash: decimal.NewFromInt(2)}},
+	}
+	for i, w := range want {
+		got, err := rr.ReadRecord(t.Context())
+		if err != nil {
+			t.Fatalf("want record %d but got error: %v", i, err)
+		}
+
+		car, ok := got.(internal.CorporateActionRecord)
+		if !ok || !got.Side().IsCorporateAction() {
+			t.Fatalf("want record %d to be a corporate action but got %v", i, got.Side())
+		}
+
+		if got.Symbol() != w.symbol || !got.Timestamp().Equal(w.ts) {
+			t.Fatalf("want record %d of %s at %v but got %s at %v", i, w.symbol, w.ts, got.Symbol(), got.Timestamp())
+		}
+
+		a := car.CorporateAction()
+		if a.Kind != w.action.Kind || a.NewSymbol != w.action.NewSymbol || !a.Ratio.Equal(w.action.Ratio) ||
+			!a.CostFraction.Equal(w.action.CostFraction) || !a.Cash.Equal(w.action.Cash) {
+			t.Fatalf("want record %d to have action %+v but got %+v", i, w.action, a)
+		}
+	}
+
+	_, err := rr.ReadRecord(t.Context())
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("want error for invalid cash but got %v", err)
+	}
+}
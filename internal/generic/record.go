@@ -0,0 +1,591 @@
+package generic
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+type Record struct {
+	symbol        string
+	timestamp     time.Time
+	side          internal.Side
+	quantity      decimal.Decimal
+	price         decimal.Decimal
+	fees          decimal.Decimal
+	taxes         decimal.Decimal
+	brokerCountry int64
+	assetCountry  int64
+	account       string
+	// accruedInterest is only set for bonds.
+	accruedInterest decimal.Decimal
+
+	// currency and exchangeRate are only set when the values were converted to EUR.
+	currency     string
+	exchangeRate decimal.Decimal
+
+	// natureGetter allows us to defer the operation of figuring out the nature to only when/if needed.
+	natureGetter func() internal.Nature
+	natureSource internal.NatureSource
+	// line is where the record is in the statement, starting at 1.
+	line int
+}
+
+func (r Record) Symbol() string {
+	return r.symbol
+}
+
+func (r Record) Timestamp() time.Time {
+	return r.timestamp
+}
+
+func (r Record) BrokerCountry() int64 {
+	return r.brokerCountry
+}
+
+func (r Record) AssetCountry() int64 {
+	return r.assetCountry
+}
+
+func (r Record) Side() internal.Side {
+	return r.side
+}
+
+func (r Record) Quantity() decimal.Decimal {
+	return r.quantity
+}
+
+func (r Record) Price() decimal.Decimal {
+	return r.price
+}
+
+func (r Record) Fees() decimal.Decimal {
+	return r.fees
+}
+
+func (r Record) Taxes() decimal.Decimal {
+	return r.taxes
+}
+
+func (r Record) Nature() internal.Nature {
+	return r.natureGetter()
+}
+
+func (r Record) NatureSource() internal.NatureSource {
+	return r.natureSource
+}
+
+func (r Record) Currency() string {
+	return r.currency
+}
+
+func (r Record) ExchangeRate() decimal.Decimal {
+	return r.exchangeRate
+}
+
+func (r Record) Line() int {
+	return r.line
+}
+
+// Account is empty unless the account column is configured.
+func (r Record) Account() string {
+	return r.account
+}
+
+// AccruedInterest is zero unless the accrued interest column is configured.
+func (r Record) AccruedInterest() decimal.Decimal {
+	return r.accruedInterest
+}
+
+// DerivativeRecord is a Record of an option or future.
+type DerivativeRecord struct {
+	Record
+
+	derivative internal.Derivative
+}
+
+func (dr DerivativeRecord) Derivative() internal.Derivative {
+	return dr.derivative
+}
+
+// RecordReader reads CSV statements as described by a Config.
+type RecordReader struct {
+	reader *csv.Reader
+	figi   *internal.OpenFIGI
+	config Config
+
+	columns map[string]int
+	sides   map[string]internal.Side
+	actions map[string]internal.CorporateActionKind
+}
+
+// NewRecordReader returns a RecordReader for the CSV in r. The config is assumed to be valid.
+func NewRecordReader(r io.Reader, f *internal.OpenFIGI, cfg Config) *RecordReader {
+	reader := csv.NewReader(r)
+	reader.Comma = cfg.delimiter()
+
+	sides := make(map[string]internal.Side)
+	for _, v := range cfg.Side.Buy {
+		sides[strings.ToLower(v)] = internal.SideBuy
+	}
+	for _, v := range cfg.Side.Sell {
+		sides[strings.ToLower(v)] = internal.SideSell
+	}
+	for _, v := range cfg.Side.TransferIn {
+		sides[strings.ToLower(v)] = internal.SideTransferIn
+	}
+	for _, v := range cfg.Side.TransferOut {
+		sides[strings.ToLower(v)] = internal.SideTransferOut
+	}
+	for _, v := range cfg.Side.Expire {
+		sides[strings.ToLower(v)] = internal.SideExpire
+	}
+	for _, v := range cfg.Side.Exercise {
+		sides[strings.ToLower(v)] = internal.SideExercise
+	}
+	for _, v := range cfg.Side.Assign {
+		sides[strings.ToLower(v)] = internal.SideAssign
+	}
+
+	actions := make(map[string]internal.CorporateActionKind)
+	for _, v := range cfg.Side.Rename {
+		actions[strings.ToLower(v)] = internal.CorporateActionRename
+	}
+	for _, v := range cfg.Side.SpinOff {
+		actions[strings.ToLower(v)] = internal.CorporateActionSpinOff
+	}
+	for _, v := range cfg.Side.Merger {
+		actions[strings.ToLower(v)] = internal.CorporateActionMerger
+	}
+	for v := range actions {
+		sides[v] = internal.SideCorporateAction
+	}
+
+	return &RecordReader{
+		reader:  reader,
+		figi:    f,
+		config:  cfg,
+		sides:   sides,
+		actions: actions,
+	}
+}
+
+func (rr *RecordReader) ReadRecord(ctx context.Context) (internal.Record, error) {
+	if rr.columns == nil {
+		err := rr.readHeader()
+		if err != nil {
+			return Record{}, err
+		}
+	}
+
+	cols := rr.config.Columns
+
+	for {
+		raw, err := rr.reader.Read()
+		if err != nil {
+			return Record{}, fmt.Errorf("read record: %w", err)
+		}
+
+		rawSide := strings.ToLower(rr.field(raw, cols.Side))
+
+		side, ok := rr.sides[rawSide]
+		if !ok {
+			continue
+		}
+
+		if side.IsCorporateAction() {
+			rec, err := rr.parseCorporateAction(raw, rr.actions[rawSide])
+			if err != nil {
+				return Record{}, err
+			}
+			return rec, nil
+		}
+
+		qant, err := rr.parseDecimal(rr.field(raw, cols.Quantity))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record quantity: %w", err)
+		}
+
+		// Transfers keep the acquisition price of the lots being moved so they usually don't have one.
+		// The same goes for options expiring worthless, exercised or assigned.
+		parsePrice := rr.parseDecimal
+		switch side {
+		case internal.SideTransferIn, internal.SideTransferOut, internal.SideExpire, internal.SideExercise, internal.SideAssign:
+			parsePrice = rr.parseOptionalDecimal
+		}
+
+		price, err := parsePrice(rr.field(raw, cols.Price))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record price: %w", err)
+		}
+
+		ts, err := time.Parse(rr.config.TimestampFormat, rr.field(raw, cols.Timestamp))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record timestamp: %w", err)
+		}
+
+		fees, err := rr.parseOptionalDecimal(rr.field(raw, cols.Fees))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record fees: %w", err)
+		}
+
+		taxes, err := rr.parseOptionalDecimal(rr.field(raw, cols.Taxes))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record taxes: %w", err)
+		}
+
+		rate, err := rr.exchangeRate(raw)
+		if err != nil {
+			return Record{}, err
+		}
+
+		symbol := rr.field(raw, cols.ISIN)
+
+		brokerCountry, err := parseCountry(rr.field(raw, cols.BrokerCountry), rr.config.BrokerCountry)
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record broker country: %w", err)
+		}
+
+		accruedInterest, err := rr.parseOptionalDecimal(rr.optionalField(raw, cols.AccruedInterest))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record accrued interest: %w", err)
+		}
+
+		derivative, isDerivative, err := rr.parseDerivative(raw, rate)
+		if err != nil {
+			return Record{}, err
+		}
+
+		// Derivatives don't have an ISIN of their own so the country defaults to the underlying's.
+		defaultAssetCountry := isinCountry(symbol)
+		if isDerivative {
+			defaultAssetCountry = isinCountry(derivative.Underlying)
+		}
+
+		assetCountry, err := parseCountry(rr.field(raw, cols.AssetCountry), defaultAssetCountry)
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record asset country: %w", err)
+		}
+
+		natureGetter, natureSource := rr.figi.NatureGetter(ctx, symbol), internal.NatureSourceOpenFIGI
+		if isDerivative {
+			natureGetter, natureSource = func() internal.Nature { return internal.NatureG40 }, internal.NatureSourceStatement
+		}
+		if rawNature := rr.field(raw, cols.Nature); rawNature != "" {
+			nature, err := internal.ParseNature(rawNature)
+			if err != nil {
+				return Record{}, fmt.Errorf("parse record nature: %w", err)
+			}
+			natureGetter, natureSource = func() internal.Nature { return nature }, internal.NatureSourceStatement
+		}
+
+		currency := strings.ToUpper(rr.field(raw, cols.Currency))
+		if currency == "EUR" {
+			currency = ""
+		}
+
+		// Statements are not consistent regarding the sign of sells and costs but internally we
+		// always deal with absolute values.
+		rec := Record{
+			symbol:          symbol,
+			side:            side,
+			quantity:        qant.Abs(),
+			price:           price.Abs().Div(rate),
+			fees:            fees.Abs().Div(rate),
+			taxes:           taxes.Abs().Div(rate),
+			timestamp:       ts,
+			brokerCountry:   brokerCountry,
+			assetCountry:    assetCountry,
+			account:         rr.optionalField(raw, cols.Account),
+			accruedInterest: accruedInterest.Abs().Div(rate),
+			currency:        currency,
+			natureGetter:    natureGetter,
+			natureSource:    natureSource,
+			line:            rr.line(),
+		}
+
+		if currency != "" {
+			rec.exchangeRate = rate
+		}
+
+		if isDerivative {
+			return DerivativeRecord{Record: rec, derivative: derivative}, nil
+		}
+
+		return rec, nil
+	}
+}
+
+// CorporateActionRecord is a line with a corporate action. Other than the symbol, timestamp and
+// account, the Record values are empty.
+type CorporateActionRecord struct {
+	Record
+
+	action internal.CorporateAction
+}
+
+func (car CorporateActionRecord) CorporateAction() internal.CorporateAction {
+	return car.action
+}
+
+// line returns the line of the last record read from the statement.
+func (rr *RecordReader) line() int {
+	line, _ := rr.reader.FieldPos(0)
+	return line
+}
+
+// parseCorporateAction parses lines of corporate actions, which don't have the usual trade values.
+func (rr *RecordReader) parseCorporateAction(raw []string, kind internal.CorporateActionKind) (CorporateActionRecord, error) {
+	cols := rr.config.Columns
+
+	ts, err := time.Parse(rr.config.TimestampFormat, rr.field(raw, cols.Timestamp))
+	if err != nil {
+		return CorporateActionRecord{}, fmt.Errorf("parse record timestamp: %w", err)
+	}
+
+	rate, err := rr.exchangeRate(raw)
+	if err != nil {
+		return CorporateActionRecord{}, err
+	}
+
+	ratio, err := rr.parseOptionalDecimal(rr.optionalField(raw, cols.Ratio))
+	if err != nil {
+		return CorporateActionRecord{}, fmt.Errorf("parse record ratio: %w", err)
+	}
+
+	costFraction, err := rr.parseOptionalDecimal(rr.optionalField(raw, cols.CostFraction))
+	if err != nil {
+		return CorporateActionRecord{}, fmt.Errorf("parse record cost fraction: %w", err)
+	}
+
+	cash, err := rr.parseOptionalDecimal(rr.optionalField(raw, cols.Cash))
+	if err != nil {
+		return CorporateActionRecord{}, fmt.Errorf("parse record cash: %w", err)
+	}
+
+	var newNature internal.Nature
+	if rawNature := rr.optionalField(raw, cols.NewNature); rawNature != "" {
+		newNature, err = internal.ParseNature(rawNature)
+		if err != nil {
+			return CorporateActionRecord{}, fmt.Errorf("parse record new nature: %w", err)
+		}
+	}
+
+	symbol := rr.field(raw, cols.ISIN)
+
+	newSymbol := rr.optionalField(raw, cols.NewSymbol)
+	if newSymbol == "" && kind == internal.CorporateActionRename {
+		newSymbol = symbol
+	}
+
+	return CorporateActionRecord{
+		Record: Record{
+			symbol:       symbol,
+			side:         internal.SideCorporateAction,
+			timestamp:    ts,
+			account:      rr.optionalField(raw, cols.Account),
+			natureGetter: func() internal.Nature { return internal.NatureUnknown },
+			line:         rr.line(),
+		},
+		action: internal.CorporateAction{
+			Kind:         kind,
+			NewSymbol:    newSymbol,
+			NewNature:    newNature,
+			Ratio:        ratio,
+			CostFraction: costFraction,
+			Cash:         cash.Abs().Div(rate),
+		},
+	}, nil
+}
+
+// Values of the instrument column.
+const (
+	InstrumentOption = "option"
+	InstrumentFuture = "future"
+)
+
+// parseDerivative returns the derivative described by the line. Returns false on the 2nd return
+// value for lines of other securities.
+func (rr *RecordReader) parseDerivative(raw []string, rate decimal.Decimal) (internal.Derivative, bool, error) {
+	cols := rr.config.Columns
+
+	var d internal.Derivative
+
+	switch strings.ToLower(rr.optionalField(raw, cols.Instrument)) {
+	case InstrumentOption:
+		d.Kind = internal.DerivativeOption
+	case InstrumentFuture:
+		d.Kind = internal.DerivativeFuture
+	default:
+		return internal.Derivative{}, false, nil
+	}
+
+	d.Underlying = rr.optionalField(raw, cols.Underlying)
+
+	if rawNature := rr.optionalField(raw, cols.UnderlyingNature); rawNature != "" {
+		nature, err := internal.ParseNature(rawNature)
+		if err != nil {
+			return internal.Derivative{}, false, fmt.Errorf("parse record underlying nature: %w", err)
+		}
+		d.UnderlyingNature = nature
+	}
+
+	multiplier, err := rr.parseOptionalDecimal(rr.optionalField(raw, cols.Multiplier))
+	if err != nil {
+		return internal.Derivative{}, false, fmt.Errorf("parse record multiplier: %w", err)
+	}
+	d.Multiplier = multiplier
+
+	if rawExpiry := rr.optionalField(raw, cols.Expiry); rawExpiry != "" {
+		expiry, err := time.Parse(rr.config.TimestampFormat, rawExpiry)
+		if err != nil {
+			expiry, err = time.Parse(time.DateOnly, rawExpiry)
+			if err != nil {
+				return internal.Derivative{}, false, fmt.Errorf("parse record expiry: %w", err)
+			}
+		}
+		d.Expiry = expiry
+	}
+
+	if d.Kind != internal.DerivativeOption {
+		return d, true, nil
+	}
+
+	switch strings.ToLower(rr.optionalField(raw, cols.OptionRight)) {
+	case "call", "c":
+		d.Right = internal.OptionRightCall
+	case "put", "p":
+		d.Right = internal.OptionRightPut
+	default:
+		return internal.Derivative{}, false, fmt.Errorf("invalid option right: %q", rr.optionalField(raw, cols.OptionRight))
+	}
+
+	strike, err := rr.parseDecimal(rr.optionalField(raw, cols.Strike))
+	if err != nil {
+		return internal.Derivative{}, false, fmt.Errorf("parse record strike: %w", err)
+	}
+	d.Strike = strike.Abs().Div(rate)
+
+	return d, true, nil
+}
+
+// exchangeRate returns the rate to convert the line values to EUR.
+func (rr *RecordReader) exchangeRate(raw []string) (decimal.Decimal, error) {
+	currency := rr.field(raw, rr.config.Columns.Currency)
+	if currency == "" || strings.EqualFold(currency, "EUR") {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rawRate := rr.field(raw, rr.config.Columns.ExchangeRate)
+	if rawRate == "" {
+		return decimal.Decimal{}, fmt.Errorf("missing exchange rate for currency: %s", currency)
+	}
+
+	rate, err := rr.parseDecimal(rawRate)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("parse record exchange rate: %w", err)
+	}
+
+	if !rate.IsPositive() {
+		return decimal.Decimal{}, fmt.Errorf("invalid exchange rate: %s", rawRate)
+	}
+
+	return rate, nil
+}
+
+func (rr *RecordReader) readHeader() error {
+	header, err := rr.reader.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	cols := rr.config.Columns
+	for _, name := range []string{cols.Timestamp, cols.Side, cols.ISIN, cols.Quantity, cols.Price, cols.Fees, cols.Taxes, cols.Currency, cols.ExchangeRate, cols.BrokerCountry, cols.AssetCountry, cols.Nature} {
+		if name == "" {
+			continue
+		}
+		if _, ok := columns[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("missing column in header: %s", name)
+		}
+	}
+
+	rr.columns = columns
+
+	return nil
+}
+
+// field returns the value of the named column or an empty string for optional columns that were
+// not configured.
+func (rr *RecordReader) field(raw []string, name string) string {
+	if name == "" {
+		return ""
+	}
+	return strings.TrimSpace(raw[rr.columns[strings.ToLower(name)]])
+}
+
+// optionalField behaves the same as field but also returns an empty string when the column is
+// missing from the header.
+func (rr *RecordReader) optionalField(raw []string, name string) string {
+	if _, ok := rr.columns[strings.ToLower(name)]; !ok {
+		return ""
+	}
+	return rr.field(raw, name)
+}
+
+// parseCountry returns the numeric code of the country with the ISO 3166-1 alpha-2 code in s or,
+// if s is empty, in fallback.
+func parseCountry(s, fallback string) (int64, error) {
+	if s == "" {
+		s = fallback
+	}
+
+	country := countries.ByName(s)
+	if !country.IsValid() {
+		return 0, fmt.Errorf("invalid country: %q", s)
+	}
+
+	return int64(country), nil
+}
+
+// isinCountry returns the country prefix of an ISIN or an empty string if s is not an ISIN.
+func isinCountry(s string) string {
+	if len(s) != 12 {
+		return ""
+	}
+	return s[:2]
+}
+
+// parseDecimal parses numbers according to the configured decimal separator. The other separator
+// is assumed to be the thousands separator and is discarded.
+func (rr *RecordReader) parseDecimal(s string) (decimal.Decimal, error) {
+	if rr.config.DecimalSeparator == "," {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	return decimal.NewFromString(s)
+}
+
+// parseOptionalDecimal behaves the same as parseDecimal but returns 0 when len(s) is 0 instead of
+// error.
+func (rr *RecordReader) parseOptionalDecimal(s string) (decimal.Decimal, error) {
+	if len(s) == 0 {
+		return decimal.Decimal{}, nil
+	}
+
+	return rr.parseDecimal(s)
+}
@@ -0,0 +1,236 @@
+package generic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+var testConfig = Config{
+	Delimiter:        ";",
+	DecimalSeparator: ",",
+	TimestampFormat:  "02/01/2006 15:04",
+	BrokerCountry:    "AT",
+	Side: SideConfig{
+		Buy:         []string{"Kauf", "Sparplan"},
+		Sell:        []string{"Verkauf"},
+		TransferIn:  []string{"Einlieferung"},
+		TransferOut: []string{"Auslieferung"},
+	},
+	Columns: ColumnsConfig{
+		Timestamp:    "Datum",
+		Side:         "Typ",
+		ISIN:         "ISIN",
+		Quantity:     "Stück",
+		Price:        "Kurs",
+		Fees:         "Gebühren",
+		Taxes:        "Steuern",
+		Currency:     "Währung",
+		ExchangeRate: "Devisenkurs",
+	},
+}
+
+const header = "Datum;Typ;ISIN;Stück;Kurs;Gebühren;Steuern;Währung;Devisenkurs\n"
+
+func TestRecordReader_ReadRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       io.Reader
+		want    Record
+		wantErr bool
+	}{
+		{
+			name:    "empty reader",
+			r:       bytes.NewBufferString(""),
+			wantErr: true,
+		},
+		{
+			name:    "missing configured column",
+			r:       bytes.NewBufferString("Datum;Typ;ISIN;Stück;Kurs\n"),
+			wantErr: true,
+		},
+		{
+			name: "well-formed buy",
+			r:    bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;1.002,5;7,369;-1,50;;EUR;`),
+			want: Record{
+				symbol:    "XX1234567890",
+				side:      internal.SideBuy,
+				quantity:  decimal.RequireFromString("1002.5"),
+				price:     decimal.RequireFromString("7.369"),
+				timestamp: time.Date(2025, 7, 3, 10, 44, 0, 0, time.UTC),
+				fees:      decimal.RequireFromString("1.5"),
+				taxes:     decimal.Decimal{},
+			},
+		},
+		{
+			name: "sell in foreign currency skipping unknown sides",
+			r: bytes.NewBufferString(header +
+				"01/08/2025 09:00;Dividende;XX1234567890;;;;;EUR;\n" +
+				"04/08/2025 11:45;verkauf;XX1234567890;-2;10;-1;0,5;USD;1,25\n"),
+			want: Record{
+				symbol:    "XX1234567890",
+				side:      internal.SideSell,
+				quantity:  decimal.RequireFromString("2"),
+				price:     decimal.RequireFromString("8"),
+				timestamp: time.Date(2025, 8, 4, 11, 45, 0, 0, time.UTC),
+				fees:      decimal.RequireFromString("0.8"),
+				taxes:     decimal.RequireFromString("0.4"),
+
+				currency:     "USD",
+				exchangeRate: decimal.RequireFromString("1.25"),
+			},
+		},
+		{
+			name: "transfer in without price",
+			r:    bytes.NewBufferString(header + `03/07/2025 10:44;Einlieferung;XX1234567890;3;;;;EUR;`),
+			want: Record{
+				symbol:    "XX1234567890",
+				side:      internal.SideTransferIn,
+				quantity:  decimal.RequireFromString("3"),
+				timestamp: time.Date(2025, 7, 3, 10, 44, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "transfer out without price",
+			r:    bytes.NewBufferString(header + `03/07/2025 10:44;Auslieferung;XX1234567890;-3;;;;EUR;`),
+			want: Record{
+				symbol:    "XX1234567890",
+				side:      internal.SideTransferOut,
+				quantity:  decimal.RequireFromString("3"),
+				timestamp: time.Date(2025, 7, 3, 10, 44, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "missing exchange rate",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;1;7,369;;;USD;`),
+			wantErr: true,
+		},
+		{
+			name:    "zero exchange rate",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;1;7,369;;;USD;0`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed quantity",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;BAD;7,369;;;EUR;`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed price",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;1;;;;EUR;`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed fees",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;1;7,369;BAD;;EUR;`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed taxes",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX1234567890;1;7,369;;BAD;EUR;`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed timestamp",
+			r:       bytes.NewBufferString(header + `2025-07-03;Kauf;XX1234567890;1;7,369;;;EUR;`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed isin",
+			r:       bytes.NewBufferString(header + `03/07/2025 10:44;Kauf;XX12;1;7,369;;;EUR;`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := NewRecordReader(tt.r, NewFigiClientSecurityTypeStub(t, "Common Stock"), testConfig)
+			got, gotErr := rr.ReadRecord(t.Context())
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Fatalf("ReadRecord() failed: %v", gotErr)
+				}
+				return
+			}
+
+			if tt.wantErr {
+				t.Fatalf("ReadRecord() expected an error")
+			}
+
+			if got.Symbol() != tt.want.symbol {
+				t.Fatalf("want symbol %v but got %v", tt.want.symbol, got.Symbol())
+			}
+
+			if got.Side() != tt.want.side {
+				t.Fatalf("want side %v but got %v", tt.want.side, got.Side())
+			}
+
+			if got.Price().Cmp(tt.want.price) != 0 {
+				t.Fatalf("want price %v but got %v", tt.want.price, got.Price())
+			}
+
+			if got.Quantity().Cmp(tt.want.quantity) != 0 {
+				t.Fatalf("want quantity %v but got %v", tt.want.quantity, got.Quantity())
+			}
+
+			if !got.Timestamp().Equal(tt.want.timestamp) {
+				t.Fatalf("want timestamp %v but got %v", tt.want.timestamp, got.Timestamp())
+			}
+
+			if got.Fees().Cmp(tt.want.fees) != 0 {
+				t.Fatalf("want fees %v but got %v", tt.want.fees, got.Fees())
+			}
+
+			if got.Taxes().Cmp(tt.want.taxes) != 0 {
+				t.Fatalf("want taxes %v but got %v", tt.want.taxes, got.Taxes())
+			}
+
+			if got.BrokerCountry() != int64(countries.Austria) {
+				t.Fatalf("want broker country %v but got %v", int64(countries.Austria), got.BrokerCountry())
+			}
+
+			if got.Nature() != internal.NatureG01 {
+				t.Fatalf("want nature %v but got %v", internal.NatureG01, got.Nature())
+			}
+
+			if got := internal.NatureSourceOf(got); got != internal.NatureSourceOpenFIGI {
+				t.Fatalf("want nature source %v but got %v", internal.NatureSourceOpenFIGI, got)
+			}
+
+			currency, rate := internal.ExchangeRateOf(got)
+			if currency != tt.want.currency || rate.Cmp(tt.want.exchangeRate) != 0 {
+				t.Fatalf("want exchange rate %v %v but got %v %v", tt.want.exchangeRate, tt.want.currency, rate, currency)
+			}
+		})
+	}
+}
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func NewFigiClientSecurityTypeStub(t testing.TB, securityType string) *internal.OpenFIGI {
+	t.Helper()
+
+	c := &http.Client{
+		Timeout: time.Second,
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"data":[{"securityType":%q}]}]`, securityType))),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	return internal.NewOpenFIGI(c)
+}
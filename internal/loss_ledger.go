@@ -0,0 +1,227 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+// LossCarryForwardYears is how many years the losses declared with englobamento can be deducted
+// from the gains of the following years.
+const LossCarryForwardYears = 5
+
+// LossLedger keeps the net result of the capital gains of each year, and whether they were declared
+// with englobamento, so that the losses of past years can be deducted from the gains of the
+// following ones.
+type LossLedger struct {
+	results map[int]lossLedgerResult
+}
+
+type lossLedgerResult struct {
+	netResult    decimal.Decimal
+	englobamento bool
+}
+
+func NewLossLedger() *LossLedger {
+	return &LossLedger{
+		results: make(map[int]lossLedgerResult),
+	}
+}
+
+// LoadLossLedger reads the ledger from the JSON file in path. Returns an empty ledger if the file
+// doesn't exist yet.
+func LoadLossLedger(path string) (*LossLedger, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return NewLossLedger(), nil
+		}
+		return nil, fmt.Errorf("open loss ledger: %w", err)
+	}
+	defer f.Close()
+
+	return ReadLossLedger(f)
+}
+
+type lossLedgerYear struct {
+	Year         int             `json:"year"`
+	NetResult    decimal.Decimal `json:"net_result"`
+	Englobamento bool            `json:"englobamento"`
+}
+
+// ReadLossLedger reads a JSON ledger.
+func ReadLossLedger(r io.Reader) (*LossLedger, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var years []lossLedgerYear
+	err := dec.Decode(&years)
+	if err != nil {
+		return nil, fmt.Errorf("decode loss ledger: %w", err)
+	}
+
+	ll := NewLossLedger()
+	for _, y := range years {
+		ll.Record(y.Year, y.NetResult, y.Englobamento)
+	}
+
+	return ll, nil
+}
+
+// SaveLossLedger writes the ledger to the JSON file in path, replacing its content.
+func SaveLossLedger(path string, ll *LossLedger) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create loss ledger: %w", err)
+	}
+	defer f.Close()
+
+	err = WriteLossLedger(f, ll)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// WriteLossLedger writes the ledger as JSON, sorted by year.
+func WriteLossLedger(w io.Writer, ll *LossLedger) error {
+	years := make([]lossLedgerYear, 0, len(ll.results))
+	for _, year := range ll.Years() {
+		r := ll.results[year]
+		years = append(years, lossLedgerYear{Year: year, NetResult: r.netResult, Englobamento: r.englobamento})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	err := enc.Encode(years)
+	if err != nil {
+		return fmt.Errorf("encode loss ledger: %w", err)
+	}
+
+	return nil
+}
+
+// Record sets the net result of the year and whether it was declared with englobamento, replacing
+// the ones recorded before, if any.
+func (ll *LossLedger) Record(year int, netResult decimal.Decimal, englobamento bool) {
+	ll.results[year] = lossLedgerResult{netResult: netResult.Round(2), englobamento: englobamento}
+}
+
+// RecordYear records the net result of the items of aw realised in year with its englobamento
+// option. The other years of aw are left as they are in the ledger, since the option of each year
+// is only known when it's declared.
+func (ll *LossLedger) RecordYear(aw *AggregatorWriter, year int, englobamento bool) {
+	ll.Record(year, NetResultsByYear(aw)[year], englobamento)
+}
+
+// Years returns the years recorded in ascending order.
+func (ll *LossLedger) Years() []int {
+	return slices.Sorted(maps.Keys(ll.results))
+}
+
+// NetResultsByYear returns the sell values minus the acquisition values and the fees of the items
+// in aw, by year of the realisation.
+func NetResultsByYear(aw *AggregatorWriter) map[int]decimal.Decimal {
+	results := make(map[int]decimal.Decimal)
+	for ri := range aw.Iter() {
+		year := ri.SellTimestamp.Year()
+		results[year] = results[year].Add(ri.SellValue.Round(2)).Sub(ri.AcquisitionValue().Round(2)).Sub(ri.Fees.Round(2))
+	}
+	return results
+}
+
+// LossBalance is what is left of the loss of a year.
+type LossBalance struct {
+	// Year is when the loss was declared.
+	Year int
+	Loss decimal.Decimal
+	// Used is how much of the Loss was deducted from the gains of the following years.
+	Used      decimal.Decimal
+	Remaining decimal.Decimal
+	// Expires is the last year the loss can be deducted.
+	Expires int
+}
+
+// CarryForward is the result of deducting the losses of past years from the gains of a year.
+type CarryForward struct {
+	Year      int
+	NetResult decimal.Decimal
+	// Englobamento is whether the year was declared with englobamento, without which no losses are
+	// deducted.
+	Englobamento bool
+	// Deducted is the sum of the losses of past years deducted from the NetResult.
+	Deducted decimal.Decimal
+	// Taxable is the NetResult minus the Deducted losses, or zero for losses.
+	Taxable decimal.Decimal
+	// Losses are the losses still available for the years after Year, oldest first.
+	Losses []LossBalance
+}
+
+// CarryForward deducts the losses of the past years, oldest first, from the gains of each year up
+// to the given year and returns the result of that year. Only the years declared with englobamento
+// carry their losses forward or deduct the ones of past years, the others are ignored.
+func (ll *LossLedger) CarryForward(year int) CarryForward {
+	cf := CarryForward{
+		Year:         year,
+		NetResult:    ll.results[year].netResult,
+		Englobamento: ll.results[year].englobamento,
+	}
+
+	var balances []*LossBalance
+
+	for _, y := range ll.Years() {
+		if y > year {
+			break
+		}
+
+		if !ll.results[y].englobamento {
+			continue
+		}
+
+		net := ll.results[y].netResult
+
+		if net.IsNegative() {
+			balances = append(balances, &LossBalance{
+				Year:      y,
+				Loss:      net.Neg(),
+				Remaining: net.Neg(),
+				Expires:   y + LossCarryForwardYears,
+			})
+			continue
+		}
+
+		var deducted decimal.Decimal
+		for _, b := range balances {
+			if b.Expires < y || !b.Remaining.IsPositive() {
+				continue
+			}
+
+			used := decimal.Min(b.Remaining, net.Sub(deducted))
+			b.Used = b.Used.Add(used)
+			b.Remaining = b.Remaining.Sub(used)
+			deducted = deducted.Add(used)
+		}
+
+		if y == year {
+			cf.Deducted = deducted
+		}
+	}
+
+	cf.Taxable = decimal.Max(cf.NetResult.Sub(cf.Deducted), decimal.Decimal{})
+
+	for _, b := range balances {
+		if b.Expires > year && b.Remaining.IsPositive() {
+			cf.Losses = append(cf.Losses, *b)
+		}
+	}
+
+	return cf
+}
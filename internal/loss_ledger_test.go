@@ -0,0 +1,262 @@
+package internal_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestLossLedger_CarryForward(t *testing.T) {
+	type balance struct {
+		year      int
+		used      float64
+		remaining float64
+		expires   int
+	}
+
+	tests := []struct {
+		name    string
+		results map[int]float64
+		// withoutEnglobamento are the years of results declared without englobamento.
+		withoutEnglobamento []int
+		year                int
+		wantDeducted        float64
+		wantTaxable         float64
+		wantLosses          []balance
+	}{
+		{
+			name:         "losses are deducted oldest first",
+			results:      map[int]float64{2020: -1000, 2021: 300, 2022: -500, 2023: 2000},
+			year:         2023,
+			wantDeducted: 1200,
+			wantTaxable:  800,
+		},
+		{
+			name:       "losses of the year",
+			results:    map[int]float64{2020: -1000, 2021: 300, 2022: -500, 2023: 2000},
+			year:       2022,
+			wantLosses: []balance{{2020, 300, 700, 2025}, {2022, 0, 500, 2027}},
+		},
+		{
+			name:         "partially deducted",
+			results:      map[int]float64{2020: -1000, 2021: 400},
+			year:         2021,
+			wantDeducted: 400,
+			wantLosses:   []balance{{2020, 400, 600, 2025}},
+		},
+		{
+			name:        "expired losses",
+			results:     map[int]float64{2018: -1000, 2024: 500},
+			year:        2024,
+			wantTaxable: 500,
+		},
+		{
+			name:         "last year of a loss",
+			results:      map[int]float64{2018: -1000, 2023: 500},
+			year:         2023,
+			wantDeducted: 500,
+		},
+		{
+			name:                "losses without englobamento are not carried forward",
+			results:             map[int]float64{2020: -1000, 2021: -500, 2022: 2000},
+			withoutEnglobamento: []int{2020},
+			year:                2022,
+			wantDeducted:        500,
+			wantTaxable:         1500,
+		},
+		{
+			name:                "gains without englobamento deduct nothing",
+			results:             map[int]float64{2020: -1000, 2021: 400, 2022: 300},
+			withoutEnglobamento: []int{2021, 2022},
+			year:                2022,
+			wantTaxable:         300,
+			wantLosses:          []balance{{2020, 0, 1000, 2025}},
+		},
+		{
+			name:        "later years are ignored",
+			results:     map[int]float64{2020: 100, 2021: -1000},
+			year:        2020,
+			wantTaxable: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ll := internal.NewLossLedger()
+			for year, result := range tt.results {
+				ll.Record(year, decimal.NewFromFloat(result), !slices.Contains(tt.withoutEnglobamento, year))
+			}
+
+			got := ll.CarryForward(tt.year)
+
+			assertDecimalEqual(t, "deducted", decimal.NewFromFloat(tt.wantDeducted), got.Deducted)
+			assertDecimalEqual(t, "taxable", decimal.NewFromFloat(tt.wantTaxable), got.Taxable)
+
+			var gotLosses []balance
+			for _, b := range got.Losses {
+				gotLosses = append(gotLosses, balance{b.Year, b.Used.InexactFloat64(), b.Remaining.InexactFloat64(), b.Expires})
+			}
+
+			if !slices.Equal(gotLosses, tt.wantLosses) {
+				t.Fatalf("want losses %+v but got %+v", tt.wantLosses, gotLosses)
+			}
+		})
+	}
+}
+
+func TestLossLedger_RecordYear(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	items := []internal.ReportItem{
+		{SellTimestamp: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(50), Fees: decimal.NewFromInt(1)},
+		{SellTimestamp: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(150)},
+		{SellTimestamp: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), BuyValue: decimal.NewFromInt(100), SellValue: decimal.NewFromInt(90)},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		recorded         map[int]bool
+		englobamento     bool
+		wantYears        []int
+		wantEnglobamento bool
+		wantDeducted     float64
+	}{
+		{
+			name:             "new ledger",
+			englobamento:     true,
+			wantYears:        []int{2024},
+			wantEnglobamento: true,
+		},
+		{
+			name:             "previous year without englobamento is kept",
+			recorded:         map[int]bool{2023: false, 2024: false},
+			englobamento:     true,
+			wantYears:        []int{2023, 2024},
+			wantEnglobamento: true,
+		},
+		{
+			name:             "previous year with englobamento is kept",
+			recorded:         map[int]bool{2023: true, 2024: true},
+			englobamento:     false,
+			wantYears:        []int{2023, 2024},
+			wantEnglobamento: false,
+		},
+		{
+			name:             "previous year with englobamento deducted",
+			recorded:         map[int]bool{2023: true},
+			englobamento:     true,
+			wantYears:        []int{2023, 2024},
+			wantEnglobamento: true,
+			wantDeducted:     20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ll := internal.NewLossLedger()
+			for year, englobamento := range tt.recorded {
+				ll.Record(year, decimal.NewFromInt(-20), englobamento)
+			}
+
+			ll.RecordYear(aw, 2024, tt.englobamento)
+
+			if !slices.Equal(ll.Years(), tt.wantYears) {
+				t.Fatalf("want years %v but got %v", tt.wantYears, ll.Years())
+			}
+
+			if _, ok := tt.recorded[2023]; ok {
+				prev := ll.CarryForward(2023)
+				if prev.Englobamento != tt.recorded[2023] {
+					t.Fatalf("want 2023 englobamento %v but got %v", tt.recorded[2023], prev.Englobamento)
+				}
+				assertDecimalEqual(t, "2023 net result", decimal.NewFromInt(-20), prev.NetResult)
+			}
+
+			got := ll.CarryForward(2024)
+			if got.Englobamento != tt.wantEnglobamento {
+				t.Fatalf("want 2024 englobamento %v but got %v", tt.wantEnglobamento, got.Englobamento)
+			}
+			assertDecimalEqual(t, "net result", decimal.NewFromInt(40), got.NetResult)
+			assertDecimalEqual(t, "deducted", decimal.NewFromFloat(tt.wantDeducted), got.Deducted)
+		})
+	}
+}
+
+func TestLossLedger_ReadWrite(t *testing.T) {
+	ll := internal.NewLossLedger()
+	ll.Record(2024, decimal.NewFromFloat(-10.5), true)
+	ll.Record(2023, decimal.NewFromInt(20), false)
+
+	var buf bytes.Buffer
+	err := internal.WriteLossLedger(&buf, ll)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	want := `[
+  {
+    "year": 2023,
+    "net_result": "20",
+    "englobamento": false
+  },
+  {
+    "year": 2024,
+    "net_result": "-10.5",
+    "englobamento": true
+  }
+]
+`
+	if buf.String() != want {
+		t.Fatalf("want:\n%s\nbut got:\n%s", want, buf.String())
+	}
+
+	got, err := internal.ReadLossLedger(&buf)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	cf := got.CarryForward(2024)
+	assertDecimalEqual(t, "2024", decimal.NewFromFloat(-10.5), cf.NetResult)
+
+	if !cf.Englobamento {
+		t.Fatalf("want 2024 read with englobamento")
+	}
+}
+
+func TestLoadLossLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "losses.json")
+
+	ll, err := internal.LoadLossLedger(path)
+	if err != nil {
+		t.Fatalf("want an empty ledger for a missing file but got err: %v", err)
+	}
+
+	if len(ll.Years()) != 0 {
+		t.Fatalf("want no years but got %v", ll.Years())
+	}
+
+	ll.Record(2024, decimal.NewFromInt(-100), true)
+
+	err = internal.SaveLossLedger(path, ll)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	ll, err = internal.LoadLossLedger(path)
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	if !slices.Equal(ll.Years(), []int{2024}) {
+		t.Fatalf("want year 2024 but got %v", ll.Years())
+	}
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MergeReader combines multiple RecordReaders into a single chronological stream. Each reader must
+// already return its Records in chronological order. Records with the same timestamp are returned
+// in the same order as their readers were given.
+type MergeReader struct {
+	readers []RecordReader
+	heads   []Record
+	started bool
+}
+
+func NewMergeReader(readers ...RecordReader) *MergeReader {
+	return &MergeReader{
+		readers: readers,
+		heads:   make([]Record, len(readers)),
+	}
+}
+
+func (mr *MergeReader) ReadRecord(ctx context.Context) (Record, error) {
+	if !mr.started {
+		mr.started = true
+		for i := range mr.readers {
+			err := mr.advance(ctx, i)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	next := -1
+	for i, head := range mr.heads {
+		if head == nil {
+			continue
+		}
+
+		if next < 0 || head.Timestamp().Before(mr.heads[next].Timestamp()) {
+			next = i
+		}
+	}
+
+	if next < 0 {
+		return nil, io.EOF
+	}
+
+	rec := mr.heads[next]
+
+	err := mr.advance(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// advance replaces the head of the i-th reader with its next Record or nil once exhausted.
+func (mr *MergeReader) advance(ctx context.Context, i int) error {
+	rec, err := mr.readers[i].ReadRecord(ctx)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			mr.heads[i] = nil
+			return nil
+		}
+		return fmt.Errorf("read record from reader %d: %w", i, err)
+	}
+
+	mr.heads[i] = rec
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMergeReader_ReadRecord(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	statement := []internal.Record{
+		mockRecord(ctrl, 1, 1, internal.SideBuy, now.Add(2)),
+		mockRecord(ctrl, 2, 1, internal.SideSell, now.Add(4)),
+	}
+	adjustments := []internal.Record{
+		mockRecord(ctrl, 3, 1, internal.SideBuy, now),
+		mockRecord(ctrl, 4, 1, internal.SideBuy, now.Add(2)),
+		mockRecord(ctrl, 5, 1, internal.SideBuy, now.Add(5)),
+	}
+
+	mr := internal.NewMergeReader(newSliceReader(statement), newSliceReader(nil), newSliceReader(adjustments))
+
+	want := []internal.Record{adjustments[0], statement[0], adjustments[1], statement[1], adjustments[2]}
+	for i, w := range want {
+		got, err := mr.ReadRecord(t.Context())
+		if err != nil {
+			t.Fatalf("want record %d but got error: %v", i, err)
+		}
+
+		if got != w {
+			t.Fatalf("want record %d to have price %v but got %v", i, w.Price(), got.Price())
+		}
+	}
+
+	_, err := mr.ReadRecord(t.Context())
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("want io.EOF but got %v", err)
+	}
+}
+
+func TestMergeReader_ReadRecordError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	failing := mocks.NewMockRecordReader(ctrl)
+	failing.EXPECT().ReadRecord(gomock.Any()).Return(nil, fmt.Errorf("boom")).Times(1)
+
+	mr := internal.NewMergeReader(newSliceReader(nil), failing)
+
+	_, err := mr.ReadRecord(t.Context())
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("want reader error but got %v", err)
+	}
+}
+
+// sliceReader is a RecordReader that returns the records of a slice followed by io.EOF.
+type sliceReader struct {
+	records []internal.Record
+}
+
+func newSliceReader(records []internal.Record) *sliceReader {
+	return &sliceReader{records: records}
+}
+
+func (sr *sliceReader) ReadRecord(context.Context) (internal.Record, error) {
+	if len(sr.records) == 0 {
+		return nil, io.EOF
+	}
+
+	rec := sr.records[0]
+	sr.records = sr.records[1:]
+
+	return rec, nil
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/nmoniz/any2anexoj/internal (interfaces: RecordReader,Record,ReportWriter)
+// Source: github.com/nmoniz/any2anexoj/internal (interfaces: RecordReader,Record,ClosedPosition,ReportWriter)
 //
 // Generated by this command:
 //
-//	mockgen -destination=mocks/mocks_gen.go -package=mocks -typed . RecordReader,Record,ReportWriter
+//	mockgen -destination=mocks/mocks_gen.go -package=mocks -typed . RecordReader,Record,ClosedPosition,ReportWriter
 //
 
 // Package mocks is a generated GoMock package.
@@ -486,6 +486,486 @@ func (c *MockRecordTimestampCall) DoAndReturn(f func() time.Time) *MockRecordTim
 	return c
 }
 
+// MockClosedPosition is a mock of ClosedPosition interface.
+type MockClosedPosition struct {
+	ctrl     *gomock.Controller
+	recorder *MockClosedPositionMockRecorder
+	isgomock struct{}
+}
+
+// MockClosedPositionMockRecorder is the mock recorder for MockClosedPosition.
+type MockClosedPositionMockRecorder struct {
+	mock *MockClosedPosition
+}
+
+// NewMockClosedPosition creates a new mock instance.
+func NewMockClosedPosition(ctrl *gomock.Controller) *MockClosedPosition {
+	mock := &MockClosedPosition{ctrl: ctrl}
+	mock.recorder = &MockClosedPositionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClosedPosition) EXPECT() *MockClosedPositionMockRecorder {
+	return m.recorder
+}
+
+// AssetCountry mocks base method.
+func (m *MockClosedPosition) AssetCountry() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssetCountry")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// AssetCountry indicates an expected call of AssetCountry.
+func (mr *MockClosedPositionMockRecorder) AssetCountry() *MockClosedPositionAssetCountryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssetCountry", reflect.TypeOf((*MockClosedPosition)(nil).AssetCountry))
+	return &MockClosedPositionAssetCountryCall{Call: call}
+}
+
+// MockClosedPositionAssetCountryCall wrap *gomock.Call
+type MockClosedPositionAssetCountryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionAssetCountryCall) Return(arg0 int64) *MockClosedPositionAssetCountryCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionAssetCountryCall) Do(f func() int64) *MockClosedPositionAssetCountryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionAssetCountryCall) DoAndReturn(f func() int64) *MockClosedPositionAssetCountryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// BrokerCountry mocks base method.
+func (m *MockClosedPosition) BrokerCountry() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BrokerCountry")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// BrokerCountry indicates an expected call of BrokerCountry.
+func (mr *MockClosedPositionMockRecorder) BrokerCountry() *MockClosedPositionBrokerCountryCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BrokerCountry", reflect.TypeOf((*MockClosedPosition)(nil).BrokerCountry))
+	return &MockClosedPositionBrokerCountryCall{Call: call}
+}
+
+// MockClosedPositionBrokerCountryCall wrap *gomock.Call
+type MockClosedPositionBrokerCountryCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionBrokerCountryCall) Return(arg0 int64) *MockClosedPositionBrokerCountryCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionBrokerCountryCall) Do(f func() int64) *MockClosedPositionBrokerCountryCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionBrokerCountryCall) DoAndReturn(f func() int64) *MockClosedPositionBrokerCountryCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Fees mocks base method.
+func (m *MockClosedPosition) Fees() decimal.Decimal {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fees")
+	ret0, _ := ret[0].(decimal.Decimal)
+	return ret0
+}
+
+// Fees indicates an expected call of Fees.
+func (mr *MockClosedPositionMockRecorder) Fees() *MockClosedPositionFeesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fees", reflect.TypeOf((*MockClosedPosition)(nil).Fees))
+	return &MockClosedPositionFeesCall{Call: call}
+}
+
+// MockClosedPositionFeesCall wrap *gomock.Call
+type MockClosedPositionFeesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionFeesCall) Return(arg0 decimal.Decimal) *MockClosedPositionFeesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionFeesCall) Do(f func() decimal.Decimal) *MockClosedPositionFeesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionFeesCall) DoAndReturn(f func() decimal.Decimal) *MockClosedPositionFeesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Nature mocks base method.
+func (m *MockClosedPosition) Nature() internal.Nature {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nature")
+	ret0, _ := ret[0].(internal.Nature)
+	return ret0
+}
+
+// Nature indicates an expected call of Nature.
+func (mr *MockClosedPositionMockRecorder) Nature() *MockClosedPositionNatureCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nature", reflect.TypeOf((*MockClosedPosition)(nil).Nature))
+	return &MockClosedPositionNatureCall{Call: call}
+}
+
+// MockClosedPositionNatureCall wrap *gomock.Call
+type MockClosedPositionNatureCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionNatureCall) Return(arg0 internal.Nature) *MockClosedPositionNatureCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionNatureCall) Do(f func() internal.Nature) *MockClosedPositionNatureCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionNatureCall) DoAndReturn(f func() internal.Nature) *MockClosedPositionNatureCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// OpenPrice mocks base method.
+func (m *MockClosedPosition) OpenPrice() decimal.Decimal {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenPrice")
+	ret0, _ := ret[0].(decimal.Decimal)
+	return ret0
+}
+
+// OpenPrice indicates an expected call of OpenPrice.
+func (mr *MockClosedPositionMockRecorder) OpenPrice() *MockClosedPositionOpenPriceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenPrice", reflect.TypeOf((*MockClosedPosition)(nil).OpenPrice))
+	return &MockClosedPositionOpenPriceCall{Call: call}
+}
+
+// MockClosedPositionOpenPriceCall wrap *gomock.Call
+type MockClosedPositionOpenPriceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionOpenPriceCall) Return(arg0 decimal.Decimal) *MockClosedPositionOpenPriceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionOpenPriceCall) Do(f func() decimal.Decimal) *MockClosedPositionOpenPriceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionOpenPriceCall) DoAndReturn(f func() decimal.Decimal) *MockClosedPositionOpenPriceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// OpenTimestamp mocks base method.
+func (m *MockClosedPosition) OpenTimestamp() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenTimestamp")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// OpenTimestamp indicates an expected call of OpenTimestamp.
+func (mr *MockClosedPositionMockRecorder) OpenTimestamp() *MockClosedPositionOpenTimestampCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenTimestamp", reflect.TypeOf((*MockClosedPosition)(nil).OpenTimestamp))
+	return &MockClosedPositionOpenTimestampCall{Call: call}
+}
+
+// MockClosedPositionOpenTimestampCall wrap *gomock.Call
+type MockClosedPositionOpenTimestampCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionOpenTimestampCall) Return(arg0 time.Time) *MockClosedPositionOpenTimestampCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionOpenTimestampCall) Do(f func() time.Time) *MockClosedPositionOpenTimestampCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionOpenTimestampCall) DoAndReturn(f func() time.Time) *MockClosedPositionOpenTimestampCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Price mocks base method.
+func (m *MockClosedPosition) Price() decimal.Decimal {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Price")
+	ret0, _ := ret[0].(decimal.Decimal)
+	return ret0
+}
+
+// Price indicates an expected call of Price.
+func (mr *MockClosedPositionMockRecorder) Price() *MockClosedPositionPriceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Price", reflect.TypeOf((*MockClosedPosition)(nil).Price))
+	return &MockClosedPositionPriceCall{Call: call}
+}
+
+// MockClosedPositionPriceCall wrap *gomock.Call
+type MockClosedPositionPriceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionPriceCall) Return(arg0 decimal.Decimal) *MockClosedPositionPriceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionPriceCall) Do(f func() decimal.Decimal) *MockClosedPositionPriceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionPriceCall) DoAndReturn(f func() decimal.Decimal) *MockClosedPositionPriceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Quantity mocks base method.
+func (m *MockClosedPosition) Quantity() decimal.Decimal {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quantity")
+	ret0, _ := ret[0].(decimal.Decimal)
+	return ret0
+}
+
+// Quantity indicates an expected call of Quantity.
+func (mr *MockClosedPositionMockRecorder) Quantity() *MockClosedPositionQuantityCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quantity", reflect.TypeOf((*MockClosedPosition)(nil).Quantity))
+	return &MockClosedPositionQuantityCall{Call: call}
+}
+
+// MockClosedPositionQuantityCall wrap *gomock.Call
+type MockClosedPositionQuantityCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionQuantityCall) Return(arg0 decimal.Decimal) *MockClosedPositionQuantityCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionQuantityCall) Do(f func() decimal.Decimal) *MockClosedPositionQuantityCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionQuantityCall) DoAndReturn(f func() decimal.Decimal) *MockClosedPositionQuantityCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Side mocks base method.
+func (m *MockClosedPosition) Side() internal.Side {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Side")
+	ret0, _ := ret[0].(internal.Side)
+	return ret0
+}
+
+// Side indicates an expected call of Side.
+func (mr *MockClosedPositionMockRecorder) Side() *MockClosedPositionSideCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Side", reflect.TypeOf((*MockClosedPosition)(nil).Side))
+	return &MockClosedPositionSideCall{Call: call}
+}
+
+// MockClosedPositionSideCall wrap *gomock.Call
+type MockClosedPositionSideCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionSideCall) Return(arg0 internal.Side) *MockClosedPositionSideCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionSideCall) Do(f func() internal.Side) *MockClosedPositionSideCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionSideCall) DoAndReturn(f func() internal.Side) *MockClosedPositionSideCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Symbol mocks base method.
+func (m *MockClosedPosition) Symbol() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Symbol")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Symbol indicates an expected call of Symbol.
+func (mr *MockClosedPositionMockRecorder) Symbol() *MockClosedPositionSymbolCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Symbol", reflect.TypeOf((*MockClosedPosition)(nil).Symbol))
+	return &MockClosedPositionSymbolCall{Call: call}
+}
+
+// MockClosedPositionSymbolCall wrap *gomock.Call
+type MockClosedPositionSymbolCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionSymbolCall) Return(arg0 string) *MockClosedPositionSymbolCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionSymbolCall) Do(f func() string) *MockClosedPositionSymbolCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionSymbolCall) DoAndReturn(f func() string) *MockClosedPositionSymbolCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Taxes mocks base method.
+func (m *MockClosedPosition) Taxes() decimal.Decimal {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Taxes")
+	ret0, _ := ret[0].(decimal.Decimal)
+	return ret0
+}
+
+// Taxes indicates an expected call of Taxes.
+func (mr *MockClosedPositionMockRecorder) Taxes() *MockClosedPositionTaxesCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Taxes", reflect.TypeOf((*MockClosedPosition)(nil).Taxes))
+	return &MockClosedPositionTaxesCall{Call: call}
+}
+
+// MockClosedPositionTaxesCall wrap *gomock.Call
+type MockClosedPositionTaxesCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionTaxesCall) Return(arg0 decimal.Decimal) *MockClosedPositionTaxesCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionTaxesCall) Do(f func() decimal.Decimal) *MockClosedPositionTaxesCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionTaxesCall) DoAndReturn(f func() decimal.Decimal) *MockClosedPositionTaxesCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Timestamp mocks base method.
+func (m *MockClosedPosition) Timestamp() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Timestamp")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Timestamp indicates an expected call of Timestamp.
+func (mr *MockClosedPositionMockRecorder) Timestamp() *MockClosedPositionTimestampCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Timestamp", reflect.TypeOf((*MockClosedPosition)(nil).Timestamp))
+	return &MockClosedPositionTimestampCall{Call: call}
+}
+
+// MockClosedPositionTimestampCall wrap *gomock.Call
+type MockClosedPositionTimestampCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockClosedPositionTimestampCall) Return(arg0 time.Time) *MockClosedPositionTimestampCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockClosedPositionTimestampCall) Do(f func() time.Time) *MockClosedPositionTimestampCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockClosedPositionTimestampCall) DoAndReturn(f func() time.Time) *MockClosedPositionTimestampCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // MockReportWriter is a mock of ReportWriter interface.
 type MockReportWriter struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// MonetaryCorrectionMonths is how long an asset must be held for its acquisition value to be
+// corrected.
+const MonetaryCorrectionMonths = 24
+
+// MonetaryCorrectionCoefficients are the currency devaluation coefficients by year of the sale,
+// as published in the Portaria of that year, and then by year of acquisition.
+//
+// The sales of years without a table fail to be corrected unless the coefficients are given in
+// MonetaryCorrection.Coefficients.
+//
+// TODO: add the coefficients of the Portarias of 2023 to 2025, checked against the published text.
+var MonetaryCorrectionCoefficients = map[int]map[int]decimal.Decimal{}
+
+// MonetaryCorrection holds the currency devaluation coefficients, published yearly in a Portaria,
+// that update the acquisition value of assets held for more than MonetaryCorrectionMonths.
+type MonetaryCorrection struct {
+	// Coefficients by year of acquisition, which override the ones of
+	// MonetaryCorrectionCoefficients for the year of the sale.
+	Coefficients map[int]decimal.Decimal
+	// Natures are the natures of the assets to correct.
+	Natures []Nature
+}
+
+// Coefficient returns the coefficient to apply to the acquisition value of ri or false on the 2nd
+// return value if ri is not corrected.
+func (mc MonetaryCorrection) Coefficient(ri ReportItem) (decimal.Decimal, bool) {
+	if !mc.applies(ri) {
+		return decimal.Decimal{}, false
+	}
+
+	coefficient, ok := mc.Coefficients[ri.BuyTimestamp.Year()]
+	if ok {
+		return coefficient, true
+	}
+
+	coefficient, ok = MonetaryCorrectionCoefficients[ri.SellTimestamp.Year()][ri.BuyTimestamp.Year()]
+	if !ok {
+		return decimal.Decimal{}, false
+	}
+
+	return coefficient, true
+}
+
+// applies returns true if ri is of the natures of mc and was held for more than
+// MonetaryCorrectionMonths.
+func (mc MonetaryCorrection) applies(ri ReportItem) bool {
+	if ri.Short || !slices.Contains(mc.Natures, ri.Nature) {
+		return false
+	}
+
+	return ri.SellTimestamp.After(ri.BuyTimestamp.AddDate(0, MonetaryCorrectionMonths, 0))
+}
+
+// WithMonetaryCorrection sets the CorrectedBuyValue of the report items of the natures of mc held
+// for more than MonetaryCorrectionMonths.
+func WithMonetaryCorrection(mc MonetaryCorrection) ReportOption {
+	return func(o *reportOptions) {
+		o.correction = &mc
+	}
+}
+
+// correct sets the CorrectedBuyValue of ri, if applicable. Returns ErrUnknownMonetaryCorrection if
+// ri should be corrected but there's no coefficient for its year of acquisition.
+func (mc MonetaryCorrection) correct(ri ReportItem) (ReportItem, error) {
+	if !mc.applies(ri) {
+		return ri, nil
+	}
+
+	coefficient, ok := mc.Coefficient(ri)
+	if !ok {
+		return ri, fmt.Errorf("%w of %d for %s sold in %d", ErrUnknownMonetaryCorrection, ri.BuyTimestamp.Year(), ri.Symbol, ri.SellTimestamp.Year())
+	}
+
+	ri.CorrectedBuyValue = ri.BuyValue.Mul(coefficient)
+	return ri, nil
+}
+
+type monetaryCorrectionFile struct {
+	Natures      []string                   `json:"natures"`
+	Coefficients map[string]decimal.Decimal `json:"coefficients"`
+}
+
+// LoadMonetaryCorrection reads the coefficients from the JSON file in path.
+func LoadMonetaryCorrection(path string) (MonetaryCorrection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MonetaryCorrection{}, fmt.Errorf("open monetary correction: %w", err)
+	}
+	defer f.Close()
+
+	return ReadMonetaryCorrection(f)
+}
+
+// ReadMonetaryCorrection reads the coefficients as JSON with the natures to correct and the
+// coefficients by year (i.e.: {"natures": ["G01"], "coefficients": {"2020": "1.05"}}). The
+// coefficients are optional and override the ones of MonetaryCorrectionCoefficients.
+func ReadMonetaryCorrection(r io.Reader) (MonetaryCorrection, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var raw monetaryCorrectionFile
+	err := dec.Decode(&raw)
+	if err != nil {
+		return MonetaryCorrection{}, fmt.Errorf("decode monetary correction: %w", err)
+	}
+
+	if len(raw.Natures) == 0 {
+		return MonetaryCorrection{}, fmt.Errorf("monetary correction without natures")
+	}
+
+	mc := MonetaryCorrection{
+		Coefficients: make(map[int]decimal.Decimal, len(raw.Coefficients)),
+	}
+
+	for _, s := range raw.Natures {
+		n, err := ParseNature(s)
+		if err != nil {
+			return MonetaryCorrection{}, fmt.Errorf("parse monetary correction nature: %w", err)
+		}
+		mc.Natures = append(mc.Natures, n)
+	}
+
+	for s, coefficient := range raw.Coefficients {
+		year, err := strconv.Atoi(s)
+		if err != nil {
+			return MonetaryCorrection{}, fmt.Errorf("parse monetary correction year: %w", err)
+		}
+
+		if coefficient.LessThan(decimal.NewFromInt(1)) {
+			return MonetaryCorrection{}, fmt.Errorf("monetary correction coefficient of %d below 1: %v", year, coefficient)
+		}
+
+		mc.Coefficients[year] = coefficient
+	}
+
+	return mc, nil
+}
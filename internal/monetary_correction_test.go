@@ -0,0 +1,151 @@
+package internal_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBuildReport_MonetaryCorrection(t *testing.T) {
+	bought := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mc := internal.MonetaryCorrection{
+		Coefficients: map[int]decimal.Decimal{2020: decimal.RequireFromString("1.1")},
+		Natures:      []internal.Nature{internal.NatureG01},
+	}
+
+	tests := []struct {
+		name          string
+		sold          time.Time
+		mc            internal.MonetaryCorrection
+		wantCorrected float64
+		wantErr       error
+	}{
+		{"held for more than 24 months", bought.AddDate(2, 0, 1), mc, 220.0, nil},
+		{"held for exactly 24 months", bought.AddDate(2, 0, 0), mc, 0, nil},
+		{"other natures", bought.AddDate(3, 0, 0), internal.MonetaryCorrection{Coefficients: mc.Coefficients, Natures: []internal.Nature{internal.NatureG20}}, 0, nil},
+		{"year without coefficient", bought.AddDate(3, 0, 0), internal.MonetaryCorrection{Natures: mc.Natures}, 0, internal.ErrUnknownMonetaryCorrection},
+		{"year without coefficient held for 24 months", bought.AddDate(2, 0, 0), internal.MonetaryCorrection{Natures: mc.Natures}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			records := []internal.Record{
+				mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, bought),
+				mockRecord(ctrl, 30.0, 10.0, internal.SideSell, tt.sold),
+			}
+
+			writer := internal.NewAggregatorWriter()
+
+			err := internal.BuildReport(t.Context(), newSliceReader(records), writer, internal.WithMonetaryCorrection(tt.mc))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want err %v but got %v", tt.wantErr, err)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			for ri := range writer.Iter() {
+				assertDecimalEqual(t, "buy value", decimal.NewFromInt(200), ri.BuyValue)
+				assertDecimalEqual(t, "corrected buy value", decimal.NewFromFloat(tt.wantCorrected), ri.CorrectedBuyValue)
+			}
+
+			wantSpent := decimal.NewFromInt(200)
+			if tt.wantCorrected != 0 {
+				wantSpent = decimal.NewFromFloat(tt.wantCorrected)
+			}
+			assertDecimalEqual(t, "total corrected spent", wantSpent, writer.TotalCorrectedSpent())
+		})
+	}
+}
+
+func TestMonetaryCorrection_Coefficient(t *testing.T) {
+	defer func(coefficients map[int]map[int]decimal.Decimal) {
+		internal.MonetaryCorrectionCoefficients = coefficients
+	}(internal.MonetaryCorrectionCoefficients)
+
+	internal.MonetaryCorrectionCoefficients = map[int]map[int]decimal.Decimal{
+		2024: {2019: decimal.RequireFromString("1.12"), 2020: decimal.RequireFromString("1.11")},
+		2025: {2019: decimal.RequireFromString("1.15")},
+	}
+
+	bought := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		sold         time.Time
+		coefficients map[int]decimal.Decimal
+		want         string
+		wantOk       bool
+	}{
+		{"table of the year of the sale", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), nil, "1.12", true},
+		{"table of another year of the sale", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), nil, "1.15", true},
+		{"overridden", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), map[int]decimal.Decimal{2019: decimal.RequireFromString("1.2")}, "1.2", true},
+		{"override of another year", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), map[int]decimal.Decimal{2020: decimal.RequireFromString("1.2")}, "1.12", true},
+		{"year of the sale without table", time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := internal.MonetaryCorrection{Coefficients: tt.coefficients, Natures: []internal.Nature{internal.NatureG01}}
+
+			got, ok := mc.Coefficient(internal.ReportItem{Nature: internal.NatureG01, BuyTimestamp: bought, SellTimestamp: tt.sold})
+			if ok != tt.wantOk {
+				t.Fatalf("want ok %v but got %v", tt.wantOk, ok)
+			}
+
+			if ok && got.String() != tt.want {
+				t.Fatalf("want coefficient %s but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMonetaryCorrectionCoefficients(t *testing.T) {
+	for sold, coefficients := range internal.MonetaryCorrectionCoefficients {
+		for bought, coefficient := range coefficients {
+			if bought > sold || coefficient.LessThan(decimal.NewFromInt(1)) {
+				t.Errorf("invalid coefficient of %d in the table of %d: %v", bought, sold, coefficient)
+			}
+		}
+	}
+}
+
+func TestReadMonetaryCorrection(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{"valid", `{"natures": ["g01", "G20"], "coefficients": {"2019": "1.02", "2020": 1.01}}`, false},
+		{"without natures", `{"coefficients": {"2020": "1.01"}}`, true},
+		{"unknown nature", `{"natures": ["X99"], "coefficients": {}}`, true},
+		{"invalid year", `{"natures": ["G01"], "coefficients": {"last": "1.01"}}`, true},
+		{"coefficient below 1", `{"natures": ["G01"], "coefficients": {"2020": "0.99"}}`, true},
+		{"unknown field", `{"natures": ["G01"], "coefficient": {}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := internal.ReadMonetaryCorrection(strings.NewReader(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got.Natures) != 2 || got.Natures[0] != internal.NatureG01 {
+				t.Fatalf("want natures G01 and G20 but got %v", got.Natures)
+			}
+
+			assertDecimalEqual(t, "2019", decimal.RequireFromString("1.02"), got.Coefficients[2019])
+		})
+	}
+}
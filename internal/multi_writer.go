@@ -0,0 +1,49 @@
+package internal
+
+import "context"
+
+// MultiWriter writes each ReportItem to all of its writers, in order.
+type MultiWriter struct {
+	writers []ReportWriter
+}
+
+func NewMultiWriter(writers ...ReportWriter) *MultiWriter {
+	return &MultiWriter{
+		writers: writers,
+	}
+}
+
+// Write stops at the first writer that fails and returns its error.
+func (mw *MultiWriter) Write(ctx context.Context, ri ReportItem) error {
+	for _, w := range mw.writers {
+		err := w.Write(ctx, ri)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MultiAuditWriter writes each AuditEntry to all of its writers, in order.
+type MultiAuditWriter struct {
+	writers []AuditWriter
+}
+
+func NewMultiAuditWriter(writers ...AuditWriter) *MultiAuditWriter {
+	return &MultiAuditWriter{
+		writers: writers,
+	}
+}
+
+// WriteAudit stops at the first writer that fails and returns its error.
+func (mw *MultiAuditWriter) WriteAudit(ctx context.Context, e AuditEntry) error {
+	for _, w := range mw.writers {
+		err := w.WriteAudit(ctx, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nmoniz/any2anexoj/internal"
+)
+
+func TestMultiWriter_Write(t *testing.T) {
+	first := internal.NewAggregatorWriter()
+	second := internal.NewAggregatorWriter()
+
+	mw := internal.NewMultiWriter(first, second)
+
+	err := mw.Write(t.Context(), internal.ReportItem{Symbol: "TEST"})
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	for _, aw := range []*internal.AggregatorWriter{first, second} {
+		count := 0
+		for range aw.Iter() {
+			count++
+		}
+
+		if count != 1 {
+			t.Fatalf("want 1 item in every writer but got %d", count)
+		}
+	}
+}
+
+func TestMultiWriter_WriteError(t *testing.T) {
+	wantErr := errors.New("write failed")
+
+	after := internal.NewAggregatorWriter()
+	mw := internal.NewMultiWriter(failingWriter{wantErr}, after)
+
+	err := mw.Write(t.Context(), internal.ReportItem{Symbol: "TEST"})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("want error %v but got %v", wantErr, err)
+	}
+
+	for range after.Iter() {
+		t.Fatalf("want no items written after the failing writer")
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (fw failingWriter) Write(context.Context, internal.ReportItem) error {
+	return fw.err
+}
+
+func TestMultiAuditWriter_WriteAudit(t *testing.T) {
+	first := &auditTestWriter{}
+	second := &auditTestWriter{}
+
+	mw := internal.NewMultiAuditWriter(first, second)
+
+	err := mw.WriteAudit(t.Context(), internal.AuditEntry{Item: internal.ReportItem{Symbol: "TEST"}})
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	for _, w := range []*auditTestWriter{first, second} {
+		if len(w.entries) != 1 {
+			t.Fatalf("want 1 entry in every writer but got %d", len(w.entries))
+		}
+	}
+}
+
+func TestMultiAuditWriter_WriteAuditError(t *testing.T) {
+	wantErr := errors.New("write failed")
+
+	after := &auditTestWriter{}
+	mw := internal.NewMultiAuditWriter(failingWriter{wantErr}, after)
+
+	err := mw.WriteAudit(t.Context(), internal.AuditEntry{})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("want error %v but got %v", wantErr, err)
+	}
+
+	if len(after.entries) != 0 {
+		t.Fatalf("want no entries written after the failing writer")
+	}
+}
+
+func (fw failingWriter) WriteAudit(context.Context, internal.AuditEntry) error {
+	return fw.err
+}
@@ -1,5 +1,10 @@
 package internal
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Nature string
 
 const (
@@ -12,6 +17,14 @@ const (
 	// NatureG20 describes selling units in investment funds (including ETFs) as per table VII:
 	// Resgates ou alienação de unidades de participação ou liquidação de fundos de investimento
 	NatureG20 Nature = "G20"
+
+	// NatureG10 describes selling of other securities, such as bonds, as per table VII: Alienação
+	// onerosa de outros valores mobiliários
+	NatureG10 Nature = "G10"
+
+	// NatureG40 describes the results of derivatives (options, futures) as per table VII: Operações
+	// relativas a instrumentos financeiros derivados
+	NatureG40 Nature = "G40"
 )
 
 func (n Nature) String() string {
@@ -20,3 +33,57 @@ func (n Nature) String() string {
 	}
 	return string(n)
 }
+
+// NatureSource is where the Nature of a Record came from.
+type NatureSource string
+
+const (
+	// NatureSourceUnknown is the zero value of NatureSource type
+	NatureSourceUnknown NatureSource = ""
+
+	// NatureSourceStatement is a Nature given by the statement, either explicitly or derived from the
+	// type of instrument, or by the user.
+	NatureSourceStatement NatureSource = "statement"
+
+	// NatureSourceOpenFIGI is a Nature derived from the security type returned by OpenFIGI.
+	NatureSourceOpenFIGI NatureSource = "openfigi"
+)
+
+func (ns NatureSource) String() string {
+	if ns == "" {
+		return "unknown"
+	}
+	return string(ns)
+}
+
+// NatureSourceRecord is implemented by Records that know where their Nature came from.
+type NatureSourceRecord interface {
+	NatureSource() NatureSource
+}
+
+// NatureSourceOf returns where the Nature of r came from or NatureSourceUnknown if unknown.
+func NatureSourceOf(r Record) NatureSource {
+	nr, ok := RecordAs[NatureSourceRecord](r)
+	if !ok {
+		return NatureSourceUnknown
+	}
+	return nr.NatureSource()
+}
+
+// natures lists every known Nature except NatureUnknown.
+var natures = []Nature{
+	NatureG01,
+	NatureG10,
+	NatureG20,
+	NatureG40,
+}
+
+// ParseNature returns the Nature with the given code (case insensitive).
+func ParseNature(s string) (Nature, error) {
+	for _, n := range natures {
+		if strings.EqualFold(s, string(n)) {
+			return n, nil
+		}
+	}
+	return NatureUnknown, fmt.Errorf("unknown nature: %q", s)
+}
@@ -36,3 +36,31 @@ func TestNature_String(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNature(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    internal.Nature
+		wantErr bool
+	}{
+		{"G01", "G01", internal.NatureG01, false},
+		{"lower case g20", "g20", internal.NatureG20, false},
+		{"other securities", "G10", internal.NatureG10, false},
+		{"derivatives", "G40", internal.NatureG40, false},
+		{"not in table VII", "G02", internal.NatureUnknown, true},
+		{"empty", "", internal.NatureUnknown, true},
+		{"unknown code", "G99", internal.NatureUnknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := internal.ParseNature(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error to be %v but got %v", tt.wantErr, err)
+			}
+			if tt.want != got {
+				t.Fatalf("want %q but got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -35,8 +36,34 @@ func NewOpenFIGI(c *http.Client) *OpenFIGI {
 }
 
 func (of *OpenFIGI) SecurityTypeByISIN(ctx context.Context, isin string) (string, error) {
+	if len(isin) != 12 || countries.ByName(isin[:2]) == countries.Unknown {
+		return "", fmt.Errorf("invalid ISIN: %s", isin)
+	}
+
+	return of.securityType(ctx, isin, mappingRequestBody{
+		IDType:  "ID_ISIN",
+		IDValue: isin,
+	})
+}
+
+// SecurityTypeByTicker is similar to SecurityTypeByISIN but for brokers that only provide the
+// ticker. The exchCode narrows down the search as the same ticker is often reused in different
+// exchanges for unrelated securities (see https://www.openfigi.com/api/enumValues/v3/exchCode).
+func (of *OpenFIGI) SecurityTypeByTicker(ctx context.Context, ticker, exchCode string) (string, error) {
+	if len(ticker) == 0 {
+		return "", fmt.Errorf("empty ticker")
+	}
+
+	return of.securityType(ctx, exchCode+":"+ticker, mappingRequestBody{
+		IDType:   "TICKER",
+		IDValue:  ticker,
+		ExchCode: exchCode,
+	})
+}
+
+func (of *OpenFIGI) securityType(ctx context.Context, cacheKey string, mapping mappingRequestBody) (string, error) {
 	of.mu.RLock()
-	if secType, ok := of.securityTypeCache[isin]; ok {
+	if secType, ok := of.securityTypeCache[cacheKey]; ok {
 		of.mu.RUnlock()
 		return secType, nil
 	}
@@ -48,18 +75,11 @@ func (of *OpenFIGI) SecurityTypeByISIN(ctx context.Context, isin string) (string
 	// we check again because there could be more than one concurrent cache miss and we want only one
 	// of them to result in an actual request. When the first one releases the lock the following
 	// reads will hit the cache.
-	if secType, ok := of.securityTypeCache[isin]; ok {
+	if secType, ok := of.securityTypeCache[cacheKey]; ok {
 		return secType, nil
 	}
 
-	if len(isin) != 12 || countries.ByName(isin[:2]) == countries.Unknown {
-		return "", fmt.Errorf("invalid ISIN: %s", isin)
-	}
-
-	rawBody, err := json.Marshal([]mappingRequestBody{{
-		IDType:  "ID_ISIN",
-		IDValue: isin,
-	}})
+	rawBody, err := json.Marshal([]mappingRequestBody{mapping})
 	if err != nil {
 		return "", fmt.Errorf("marshal mapping request body: %w", err)
 	}
@@ -104,17 +124,53 @@ func (of *OpenFIGI) SecurityTypeByISIN(ctx context.Context, isin string) (string
 	// all entries have the same securityType value.
 	secType := resBody[0].Data[0].SecurityType
 	if secType == "" {
-		return "", fmt.Errorf("empty security type returned for ISIN: %s", isin)
+		return "", fmt.Errorf("empty security type returned for %s: %s", mapping.IDType, mapping.IDValue)
 	}
 
-	of.securityTypeCache[isin] = secType
+	of.securityTypeCache[cacheKey] = secType
 
 	return secType, nil
 }
 
+// NatureGetter returns a function that lazily resolves the Nature of the security identified by
+// isin. This allows readers to defer the (rate limited) api request to only when/if needed.
+func (of *OpenFIGI) NatureGetter(ctx context.Context, isin string) func() Nature {
+	return natureGetter(isin, func() (string, error) {
+		return of.SecurityTypeByISIN(ctx, isin)
+	})
+}
+
+// TickerNatureGetter is similar to NatureGetter but for brokers that only provide the ticker.
+func (of *OpenFIGI) TickerNatureGetter(ctx context.Context, ticker, exchCode string) func() Nature {
+	return natureGetter(exchCode+":"+ticker, func() (string, error) {
+		return of.SecurityTypeByTicker(ctx, ticker, exchCode)
+	})
+}
+
+func natureGetter(id string, securityType func() (string, error)) func() Nature {
+	return sync.OnceValue(func() Nature {
+		secType, err := securityType()
+		if err != nil {
+			slog.Error("failed to get security type", slog.Any("err", err), slog.String("id", id))
+			return NatureUnknown
+		}
+
+		switch secType {
+		case "Common Stock":
+			return NatureG01
+		case "ETP":
+			return NatureG20
+		default:
+			slog.Error("got unsupported security type", slog.String("id", id), slog.String("securityType", secType))
+			return NatureUnknown
+		}
+	})
+}
+
 type mappingRequestBody struct {
-	IDType  string `json:"idType"`
-	IDValue string `json:"idValue"`
+	IDType   string `json:"idType"`
+	IDValue  string `json:"idValue"`
+	ExchCode string `json:"exchCode,omitempty"`
 }
 
 type mappingResponseBody struct {
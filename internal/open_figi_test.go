@@ -166,6 +166,82 @@ func TestOpenFIGI_SecurityTypeByISIN_Cache(t *testing.T) {
 	}
 }
 
+func TestOpenFIGI_SecurityTypeByTicker(t *testing.T) {
+	c := NewTestClient(t, func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("read request body: %v", err)
+		}
+
+		want := `[{"idType":"TICKER","idValue":"VWCE","exchCode":"GY"}]`
+		if string(body) != want {
+			t.Fatalf("want request body %s but got %s", want, body)
+		}
+
+		return &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`[{"data":[{"securityType":"ETP"}]}]`)),
+		}, nil
+	})
+
+	of := internal.NewOpenFIGI(c)
+
+	got, err := of.SecurityTypeByTicker(t.Context(), "VWCE", "GY")
+	if err != nil {
+		t.Fatalf("want success but failed: %v", err)
+	}
+
+	if got != "ETP" {
+		t.Fatalf("want security type to be %q but got %q", "ETP", got)
+	}
+
+	_, err = of.SecurityTypeByTicker(t.Context(), "", "GY")
+	if err == nil {
+		t.Fatalf("want error for empty ticker")
+	}
+}
+
+func TestOpenFIGI_NatureGetter(t *testing.T) {
+	tests := []struct {
+		name   string // description of this test case
+		client *http.Client
+		want   internal.Nature
+	}{
+		{
+			name:   "Common Stock translates to G01",
+			client: NewSecurityTypeTestClient(t, "Common Stock"),
+			want:   internal.NatureG01,
+		},
+		{
+			name:   "ETP translates to G20",
+			client: NewSecurityTypeTestClient(t, "ETP"),
+			want:   internal.NatureG20,
+		},
+		{
+			name:   "Other translates to Unknown",
+			client: NewSecurityTypeTestClient(t, "Other"),
+			want:   internal.NatureUnknown,
+		},
+		{
+			name: "Request fails",
+			client: NewTestClient(t, func(req *http.Request) (*http.Response, error) {
+				return nil, fmt.Errorf("boom")
+			}),
+			want: internal.NatureUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getter := internal.NewOpenFIGI(tt.client).NatureGetter(t.Context(), "IE1234567890")
+			got := getter()
+			if tt.want != got {
+				t.Errorf("want %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
 type RoundTripFunc func(req *http.Request) (*http.Response, error)
 
 func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -180,3 +256,16 @@ func NewTestClient(t testing.TB, fn RoundTripFunc) *http.Client {
 		Transport: fn,
 	}
 }
+
+func NewSecurityTypeTestClient(t testing.TB, securityType string) *http.Client {
+	t.Helper()
+
+	return NewTestClient(t, func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Status:     http.StatusText(http.StatusOK),
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"data":[{"securityType":%q}]}]`, securityType))),
+			Request:    req,
+		}, nil
+	})
+}
@@ -0,0 +1,204 @@
+// Package pdf implements just enough of the PDF format to write plain text documents, such as
+// reports to be archived. Text is written in the standard Courier fonts, which every reader has, so
+// nothing is embedded and columns can be aligned with spaces. Only characters of the Windows-1252
+// charset, which covers Portuguese, are supported; others are replaced by a question mark.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Pages are A4 in landscape so that wide tables fit. Sizes are in points (1/72 of an inch).
+const (
+	pageWidth  = 842
+	pageHeight = 595
+	margin     = 36
+
+	fontSize       = 8
+	leading        = 10
+	headingSize    = 11
+	headingLeading = 18
+)
+
+// MaxLineLength is the number of characters that fit in a line. Longer lines are wrapped. Every
+// character of Courier is 600/1000 of the font size wide.
+const MaxLineLength = (pageWidth - 2*margin) * 1000 / (fontSize * 600)
+
+// Metadata is written to the document information dictionary.
+type Metadata struct {
+	Title    string
+	Producer string
+	Created  time.Time
+}
+
+type line struct {
+	text    string
+	heading bool
+}
+
+// Writer collects lines of text and writes them as a PDF document, with a page number at the bottom
+// of each page, once closed.
+type Writer struct {
+	w     io.Writer
+	md    Metadata
+	lines []line
+}
+
+func NewWriter(w io.Writer, md Metadata) *Writer {
+	return &Writer{
+		w:  w,
+		md: md,
+	}
+}
+
+// Heading adds a line in bold and bigger than the others.
+func (pw *Writer) Heading(s string) {
+	pw.lines = append(pw.lines, line{text: s, heading: true})
+}
+
+// Line adds a line, or several if s has line breaks or is longer than MaxLineLength.
+func (pw *Writer) Line(s string) {
+	for l := range strings.SplitSeq(s, "\n") {
+		for utf8.RuneCountInString(l) > MaxLineLength {
+			runes := []rune(l)
+			pw.lines = append(pw.lines, line{text: string(runes[:MaxLineLength])})
+			l = string(runes[MaxLineLength:])
+		}
+		pw.lines = append(pw.lines, line{text: l})
+	}
+}
+
+// Close writes the document. The Writer must not be used afterwards.
+func (pw *Writer) Close() error {
+	pages := pw.paginate()
+
+	var buf bytes.Buffer
+	var offsets []int
+
+	// Objects are numbered from 1 in the order they are written: the catalog, the page tree, the
+	// fonts, the information dictionary and then each page followed by its contents.
+	obj := func(format string, a ...any) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, a...)
+		buf.WriteString("\nendobj\n")
+	}
+
+	const firstPage = 6
+
+	// The comment with binary characters tells transfer tools that the file is not plain text.
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	obj("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	obj("<< /Title %s /Producer %s /CreationDate %s >>",
+		literal(pw.md.Title), literal(pw.md.Producer), literal(pw.md.Created.UTC().Format("D:20060102150405Z")))
+
+	for i, page := range pages {
+		content := pw.content(page, i+1, len(pages))
+
+		obj("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1)
+		obj("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := pw.w.Write(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("write document: %w", err)
+	}
+
+	return nil
+}
+
+// paginate splits the lines into pages. A heading is never the last line of a page.
+func (pw *Writer) paginate() [][]line {
+	pages := [][]line{nil}
+
+	// The bottom margin leaves room for the page number.
+	y := pageHeight - margin
+	for i, l := range pw.lines {
+		height := leading
+		if l.heading {
+			height = headingLeading
+			if i+1 < len(pw.lines) {
+				height += leading
+			}
+		}
+
+		if y-height < margin+leading && len(pages[len(pages)-1]) > 0 {
+			pages = append(pages, nil)
+			y = pageHeight - margin
+		}
+
+		pages[len(pages)-1] = append(pages[len(pages)-1], l)
+		if l.heading {
+			y -= headingLeading
+		} else {
+			y -= leading
+		}
+	}
+
+	return pages
+}
+
+func (pw *Writer) content(page []line, num, total int) string {
+	var sb strings.Builder
+
+	y := pageHeight - margin
+	for _, l := range page {
+		font, size, height := "F1", fontSize, leading
+		if l.heading {
+			font, size, height = "F2", headingSize, headingLeading
+		}
+
+		y -= height
+		fmt.Fprintf(&sb, "BT /%s %d Tf %d %d Td %s Tj ET\n", font, size, margin, y, literal(l.text))
+	}
+
+	fmt.Fprintf(&sb, "BT /F1 %d Tf %d %d Td %s Tj ET", fontSize, pageWidth-margin-4*fontSize, margin-leading, literal(fmt.Sprintf("%d/%d", num, total)))
+
+	return sb.String()
+}
+
+// literal returns s as a PDF string in the Windows-1252 charset.
+func literal(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, r := range s {
+		b, ok := charmap.Windows1252.EncodeRune(r)
+		if !ok {
+			b = '?'
+		}
+
+		switch b {
+		case '(', ')', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(b)
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	pw := NewWriter(&buf, Metadata{
+		Title:    "Relatório (2024)",
+		Producer: "any2anexoj v1.2.3",
+		Created:  time.Date(2025, 3, 4, 10, 30, 0, 0, time.UTC),
+	})
+
+	pw.Heading("Anexo J")
+	pw.Line("Valor de realização: 100,00 €\nback\\slash")
+	for i := range 100 {
+		pw.Line(fmt.Sprintf("line %d", i))
+	}
+	pw.Line(strings.Repeat("x", MaxLineLength+1) + " 日本")
+
+	err := pw.Close()
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	doc := buf.String()
+
+	if !strings.HasPrefix(doc, "%PDF-1.4\n") || !strings.HasSuffix(doc, "%%EOF\n") {
+		t.Fatalf("want a PDF header and trailer but got:\n%s", doc)
+	}
+
+	// Every entry of the cross-reference table must point to its object.
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(doc)
+	if startxref == nil {
+		t.Fatalf("want startxref but got none")
+	}
+	xref, _ := strconv.Atoi(startxref[1])
+	if !strings.HasPrefix(doc[xref:], "xref\n") {
+		t.Fatalf("want startxref to point to the xref table")
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllStringSubmatch(doc[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(doc[off:], want) {
+			t.Errorf("want object %d at offset %d but got %q", i+1, off, doc[off:min(off+10, len(doc))])
+		}
+	}
+
+	// 5 objects and 2 per page
+	if want := 5 + 2*3; len(entries) != want {
+		t.Errorf("want %d objects but got %d", want, len(entries))
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"page count", "/Count 3"},
+		{"title", "/Title (Relat\xf3rio \\(2024\\))"},
+		{"producer", "/Producer (any2anexoj v1.2.3)"},
+		{"creation date", "/CreationDate (D:20250304103000Z)"},
+		{"heading", "/F2 11 Tf 36 541 Td (Anexo J) Tj"},
+		{"encoded text", "(Valor de realiza\xe7\xe3o: 100,00 \x80) Tj"},
+		{"escaped backslash", "(back\\\\slash) Tj"},
+		{"wrapped line", "(x ??) Tj"},
+		{"page number", "(3/3) Tj"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(doc, tt.want) {
+				t.Errorf("want document to contain %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewWriter(&buf, Metadata{}).Close()
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "/Count 1") {
+		t.Errorf("want a single blank page")
+	}
+}
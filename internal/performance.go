@@ -0,0 +1,439 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PerformanceRecorder keeps the trades of the records read through its readers to compute the
+// performance of the portfolio once the report is built. Derivatives are left out.
+type PerformanceRecorder struct {
+	mu     sync.Mutex
+	events []performanceEvent
+}
+
+// performanceEvent is a change to the holdings of the portfolio. The values are in EUR.
+type performanceEvent struct {
+	timestamp time.Time
+	symbol    string
+	side      Side
+	quantity  decimal.Decimal
+	price     decimal.Decimal
+	fees      decimal.Decimal
+	taxes     decimal.Decimal
+	action    CorporateAction
+}
+
+func NewPerformanceRecorder() *PerformanceRecorder {
+	return &PerformanceRecorder{}
+}
+
+// Reader returns a RecordReader that reads from r and keeps the records read.
+func (pr *PerformanceRecorder) Reader(r RecordReader) RecordReader {
+	return performanceReader{
+		reader:   r,
+		recorder: pr,
+	}
+}
+
+type performanceReader struct {
+	reader   RecordReader
+	recorder *PerformanceRecorder
+}
+
+func (pr performanceReader) ReadRecord(ctx context.Context) (Record, error) {
+	rec, err := pr.reader.ReadRecord(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pr.recorder.record(rec)
+
+	return rec, nil
+}
+
+func (pr *PerformanceRecorder) record(rec Record) {
+	if _, ok := RecordAs[DerivativeRecord](rec); ok {
+		return
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	e := performanceEvent{
+		timestamp: rec.Timestamp(),
+		symbol:    rec.Symbol(),
+		side:      rec.Side(),
+		quantity:  rec.Quantity(),
+		price:     rec.Price(),
+		fees:      rec.Fees(),
+		taxes:     rec.Taxes(),
+	}
+
+	switch rec.Side() {
+	case SideBuy, SideTransferIn, SideTransferOut:
+		pr.events = append(pr.events, e)
+
+	case SideSell:
+		// The broker already matched the sell so its acquisition is not in the statement
+		if cp, ok := RecordAs[ClosedPosition](rec); ok {
+			pr.events = append(pr.events, performanceEvent{
+				timestamp: cp.OpenTimestamp(),
+				symbol:    rec.Symbol(),
+				side:      SideBuy,
+				quantity:  rec.Quantity(),
+				price:     cp.OpenPrice(),
+			})
+		}
+		pr.events = append(pr.events, e)
+
+	case SideCorporateAction:
+		if car, ok := RecordAs[CorporateActionRecord](rec); ok {
+			e.action = car.CorporateAction()
+			pr.events = append(pr.events, e)
+		}
+	}
+}
+
+// Performance is how the portfolio did from the first record until End.
+type Performance struct {
+	Start time.Time
+	End   time.Time
+	// TWR is the time-weighted return of the whole period, which leaves out the effect of the amounts
+	// invested and withdrawn. It's not annualised.
+	TWR decimal.Decimal
+	// MWR is the money-weighted return per year: the internal rate of return of the amounts invested
+	// and withdrawn and the value at End. It's not valid when no rate solves it.
+	MWR decimal.NullDecimal
+	// NetInvested is what was paid for the buys, fees and taxes included, minus what was received
+	// from the sells.
+	NetInvested decimal.Decimal
+	// Value is the market value of the positions held at End.
+	Value decimal.Decimal
+	// Realised is the sum of the RealisedPnL of the report.
+	Realised decimal.Decimal
+	// Unrealised is the difference between the market value and the cost of the positions held at
+	// End.
+	Unrealised decimal.Decimal
+	// Unpriced are the symbols held at End without a price, which are valued at the last price they
+	// were traded at.
+	Unpriced []string
+	Years    []YearPerformance
+}
+
+// YearPerformance is how the portfolio did in a calendar year.
+type YearPerformance struct {
+	Year   int
+	Bought decimal.Decimal
+	Sold   decimal.Decimal
+	// Turnover is the lesser of Bought and Sold over the average market value of the year.
+	Turnover decimal.Decimal
+	Fees     decimal.Decimal
+	Taxes    decimal.Decimal
+	Realised decimal.Decimal
+	// TWR is the time-weighted return of the year, or of the part of it with records.
+	TWR decimal.Decimal
+}
+
+type yearState struct {
+	YearPerformance
+	growth decimal.Decimal
+	// area is the market value multiplied by the hours it was held, to compute the average.
+	area  decimal.Decimal
+	hours decimal.Decimal
+}
+
+type cashFlow struct {
+	timestamp time.Time
+	amount    decimal.Decimal
+}
+
+// Performance computes the performance of the records read until at, or the last record if later,
+// with the report items and positions in aw. The positions held at the end are valued with prices,
+// in EUR by symbol, or the last price they were traded at if missing.
+func (pr *PerformanceRecorder) Performance(aw *AggregatorWriter, prices map[string]decimal.Decimal, at time.Time) Performance {
+	pr.mu.Lock()
+	events := slices.Clone(pr.events)
+	pr.mu.Unlock()
+
+	// The acquisitions of the closed positions come after later records
+	slices.SortStableFunc(events, func(a, b performanceEvent) int {
+		return a.timestamp.Compare(b.timestamp)
+	})
+
+	var perf Performance
+	if len(events) > 0 {
+		perf.Start = events[0].timestamp
+		perf.End = events[len(events)-1].timestamp
+	}
+	if at.After(perf.End) {
+		perf.End = at
+	}
+
+	years := make(map[int]*yearState)
+	year := func(y int) *yearState {
+		ys, ok := years[y]
+		if !ok {
+			ys = &yearState{YearPerformance: YearPerformance{Year: y}, growth: decimal.NewFromInt(1)}
+			years[y] = ys
+		}
+		return ys
+	}
+
+	holdings := make(map[string]decimal.Decimal)
+	last := make(map[string]decimal.Decimal)
+	value := func() decimal.Decimal {
+		var v decimal.Decimal
+		for symbol, qty := range holdings {
+			v = v.Add(qty.Mul(last[symbol]))
+		}
+		return v
+	}
+
+	growth := decimal.NewFromInt(1)
+	link := func(t time.Time, factor decimal.Decimal) {
+		growth = growth.Mul(factor)
+		ys := year(t.Year())
+		ys.growth = ys.growth.Mul(factor)
+	}
+
+	// hold adds the value held between from and to to the average of each year
+	hold := func(from, to time.Time, v decimal.Decimal) {
+		for from.Before(to) {
+			end := time.Date(from.Year()+1, 1, 1, 0, 0, 0, 0, from.Location())
+			if end.After(to) {
+				end = to
+			}
+
+			hours := decimal.NewFromFloat(end.Sub(from).Hours())
+			ys := year(from.Year())
+			ys.area = ys.area.Add(v.Mul(hours))
+			ys.hours = ys.hours.Add(hours)
+
+			from = end
+		}
+	}
+
+	var flows []cashFlow
+	var start decimal.Decimal
+	lastTimestamp := perf.Start
+
+	for _, e := range events {
+		hold(lastTimestamp, e.timestamp, start)
+		lastTimestamp = e.timestamp
+
+		ys := year(e.timestamp.Year())
+
+		// The flow is what the investor puts in the portfolio, or takes out of it if negative
+		var flow decimal.Decimal
+		switch e.side {
+		case SideBuy:
+			last[e.symbol] = e.price
+			holdings[e.symbol] = holdings[e.symbol].Add(e.quantity)
+			flow = e.quantity.Mul(e.price)
+			ys.Bought = ys.Bought.Add(flow)
+
+		case SideSell:
+			last[e.symbol] = e.price
+			holdings[e.symbol] = holdings[e.symbol].Sub(e.quantity)
+			flow = e.quantity.Mul(e.price).Neg()
+			ys.Sold = ys.Sold.Sub(flow)
+
+		case SideTransferIn:
+			// Transfers in keep the original price, which is not the market price if it's known
+			if _, ok := last[e.symbol]; !ok {
+				last[e.symbol] = e.price
+			}
+			holdings[e.symbol] = holdings[e.symbol].Add(e.quantity)
+			flow = e.quantity.Mul(last[e.symbol])
+
+		case SideTransferOut:
+			holdings[e.symbol] = holdings[e.symbol].Sub(e.quantity)
+			flow = e.quantity.Mul(last[e.symbol]).Neg()
+
+		case SideCorporateAction:
+			flow = applyPerformanceAction(e.symbol, e.action, holdings, last)
+		}
+
+		flow = flow.Add(e.fees).Add(e.taxes)
+		ys.Fees = ys.Fees.Add(e.fees)
+		ys.Taxes = ys.Taxes.Add(e.taxes)
+
+		end := value()
+		switch {
+		case start.IsPositive():
+			link(e.timestamp, end.Sub(flow).Div(start))
+		case flow.IsPositive():
+			// Without anything held before, the return is only the fees and taxes paid
+			link(e.timestamp, end.Div(flow))
+		}
+		start = end
+
+		if !flow.IsZero() {
+			flows = append(flows, cashFlow{timestamp: e.timestamp, amount: flow})
+			perf.NetInvested = perf.NetInvested.Add(flow)
+		}
+	}
+
+	for symbol, qty := range holdings {
+		if qty.IsZero() {
+			continue
+		}
+
+		price, ok := prices[symbol]
+		if ok {
+			last[symbol] = price
+		} else {
+			perf.Unpriced = append(perf.Unpriced, symbol)
+		}
+	}
+	slices.Sort(perf.Unpriced)
+
+	hold(lastTimestamp, perf.End, start)
+	perf.Value = value()
+	if start.IsPositive() {
+		link(perf.End, perf.Value.Div(start))
+	}
+
+	perf.TWR = growth.Sub(decimal.NewFromInt(1))
+	perf.MWR = moneyWeightedReturn(flows, perf.Value, perf.End)
+
+	for ri := range aw.Iter() {
+		perf.Realised = perf.Realised.Add(ri.RealisedPnL())
+		ys := year(ri.SellTimestamp.Year())
+		ys.Realised = ys.Realised.Add(ri.RealisedPnL())
+	}
+
+	for p := range aw.Positions() {
+		perf.Unrealised = perf.Unrealised.Add(p.UnrealisedPnL(last[p.Symbol]))
+	}
+
+	for _, ys := range years {
+		ys.TWR = ys.growth.Sub(decimal.NewFromInt(1))
+
+		if ys.hours.IsPositive() {
+			average := ys.area.Div(ys.hours)
+			if average.IsPositive() {
+				ys.Turnover = decimal.Min(ys.Bought, ys.Sold).Div(average)
+			}
+		}
+
+		perf.Years = append(perf.Years, ys.YearPerformance)
+	}
+	slices.SortFunc(perf.Years, func(a, b YearPerformance) int {
+		return cmp.Compare(a.Year, b.Year)
+	})
+
+	return perf
+}
+
+// applyPerformanceAction changes the holdings of symbol as done by the action. The value of the
+// shares received is the fraction of the value held given by the CostFraction. Returns the cash
+// received as a negative flow.
+func applyPerformanceAction(symbol string, action CorporateAction, holdings, last map[string]decimal.Decimal) decimal.Decimal {
+	held := holdings[symbol]
+	if !held.IsPositive() {
+		return decimal.Decimal{}
+	}
+
+	price := last[symbol]
+	one := decimal.NewFromInt(1)
+
+	switch action.Kind {
+	case CorporateActionRename:
+		if action.Ratio.IsPositive() {
+			delete(holdings, symbol)
+			holdings[action.NewSymbol] = holdings[action.NewSymbol].Add(held.Mul(action.Ratio))
+			last[action.NewSymbol] = price.Div(action.Ratio)
+		}
+
+	case CorporateActionSpinOff:
+		if action.Ratio.IsPositive() {
+			holdings[action.NewSymbol] = holdings[action.NewSymbol].Add(held.Mul(action.Ratio))
+			last[action.NewSymbol] = price.Mul(action.CostFraction).Div(action.Ratio)
+			last[symbol] = price.Mul(one.Sub(action.CostFraction))
+		}
+
+	case CorporateActionMerger:
+		delete(holdings, symbol)
+		if action.Ratio.IsPositive() {
+			holdings[action.NewSymbol] = holdings[action.NewSymbol].Add(held.Mul(action.Ratio))
+			last[action.NewSymbol] = price.Mul(one.Sub(action.CostFraction)).Div(action.Ratio)
+		}
+		return held.Mul(action.Cash).Neg()
+	}
+
+	return decimal.Decimal{}
+}
+
+// moneyWeightedReturn finds, by bisection, the yearly rate at which the flows grow to value at end.
+func moneyWeightedReturn(flows []cashFlow, value decimal.Decimal, end time.Time) decimal.NullDecimal {
+	if len(flows) == 0 {
+		return decimal.NullDecimal{}
+	}
+
+	final := value.InexactFloat64()
+	balance := func(rate float64) float64 {
+		sum := -final
+		for _, f := range flows {
+			years := end.Sub(f.timestamp).Hours() / 24 / 365
+			sum += f.amount.InexactFloat64() * math.Pow(1+rate, years)
+		}
+		return sum
+	}
+
+	lo, hi := -0.9999, 1000.0
+	if math.Signbit(balance(lo)) == math.Signbit(balance(hi)) {
+		return decimal.NullDecimal{}
+	}
+
+	for range 200 {
+		mid := (lo + hi) / 2
+		if math.Signbit(balance(mid)) == math.Signbit(balance(lo)) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return decimal.NewNullDecimal(decimal.NewFromFloat((lo + hi) / 2).Round(6))
+}
+
+// LoadPrices reads the prices from the JSON file in path.
+func LoadPrices(path string) (map[string]decimal.Decimal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open prices: %w", err)
+	}
+	defer f.Close()
+
+	return ReadPrices(f)
+}
+
+// ReadPrices reads the prices in EUR by symbol as JSON (i.e.: {"US0378331005": "180.25"}).
+func ReadPrices(r io.Reader) (map[string]decimal.Decimal, error) {
+	var prices map[string]decimal.Decimal
+
+	err := json.NewDecoder(r).Decode(&prices)
+	if err != nil {
+		return nil, fmt.Errorf("decode prices: %w", err)
+	}
+
+	for symbol, price := range prices {
+		if price.IsNegative() {
+			return nil, fmt.Errorf("negative price of %s: %v", symbol, price)
+		}
+	}
+
+	return prices, nil
+}
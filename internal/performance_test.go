@@ -0,0 +1,183 @@
+package internal_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPerformanceRecorder_Performance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		costsRecord(mockRecord(ctrl, 10.0, 10.0, internal.SideBuy, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)), 1, 0),
+		mockRecord(ctrl, 12.0, 10.0, internal.SideBuy, time.Date(2023, 7, 2, 0, 0, 0, 0, time.UTC)),
+		costsRecord(mockRecord(ctrl, 15.0, 10.0, internal.SideSell, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), 0, 0.5),
+	}
+
+	recorder := internal.NewPerformanceRecorder()
+	aw := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), recorder.Reader(newSliceReader(records)), aw, internal.WithPositions(aw))
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	got := recorder.Performance(aw, map[string]decimal.Decimal{"TEST": decimal.NewFromInt(16)}, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name string
+		got  decimal.Decimal
+		want string
+	}{
+		// 100/101 * 240-120/100 * 150+149.5/240 * 160/150
+		{"twr", got.TWR.Round(6), "0.581518"},
+		{"mwr", got.MWR.Decimal, "0.317841"},
+		{"net invested", got.NetInvested, "71.5"},
+		{"value", got.Value, "160"},
+		{"realised", got.Realised, "50"},
+		{"unrealised", got.Unrealised, "40"},
+		{"2023 twr", got.Years[0].TWR.Round(6), "0.188119"},
+		{"2023 bought", got.Years[0].Bought, "220"},
+		{"2023 fees", got.Years[0].Fees, "1"},
+		{"2024 twr", got.Years[1].TWR.Round(6), "0.331111"},
+		{"2024 sold", got.Years[1].Sold, "150"},
+		{"2024 taxes", got.Years[1].Taxes, "0.5"},
+		{"2024 realised", got.Years[1].Realised, "50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.String() != tt.want {
+				t.Errorf("want %s but got %s", tt.want, tt.got)
+			}
+		})
+	}
+
+	if !got.MWR.Valid {
+		t.Errorf("want a valid money-weighted return")
+	}
+
+	if len(got.Unpriced) != 0 {
+		t.Errorf("want every position priced but got %v", got.Unpriced)
+	}
+}
+
+func TestPerformanceRecorder_Turnover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		mockRecord(ctrl, 10.0, 10.0, internal.SideBuy, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+		mockRecord(ctrl, 10.0, 5.0, internal.SideSell, time.Date(2023, 7, 2, 0, 0, 0, 0, time.UTC)),
+	}
+
+	recorder := internal.NewPerformanceRecorder()
+	aw := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), recorder.Reader(newSliceReader(records)), aw, internal.WithPositions(aw))
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	got := recorder.Performance(aw, nil, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// 50 over the average of 100 for 182 days and 50 for 183 days
+	if want := "0.667276"; got.Years[0].Turnover.Round(6).String() != want {
+		t.Errorf("want turnover %s but got %s", want, got.Years[0].Turnover)
+	}
+
+	if !got.TWR.IsZero() || !got.MWR.Valid || !got.MWR.Decimal.IsZero() {
+		t.Errorf("want no returns at a constant price but got %v and %v", got.TWR, got.MWR)
+	}
+
+	// Without prices the position is valued at the last trade
+	if !slices.Equal(got.Unpriced, []string{"TEST"}) || !got.Value.Equal(decimal.NewFromInt(50)) {
+		t.Errorf("want TEST valued at the last price but got %v unpriced and a value of %v", got.Unpriced, got.Value)
+	}
+}
+
+func TestPerformanceRecorder_CorporateActions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+		actionRecord(ctrl, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), internal.CorporateAction{Kind: internal.CorporateActionRename, NewSymbol: "TEST", Ratio: decimal.NewFromInt(2)}),
+		actionRecord(ctrl, time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), internal.CorporateAction{Kind: internal.CorporateActionMerger, NewSymbol: "NEW", Ratio: decimal.NewFromInt(1), CostFraction: decimal.NewFromFloat(0.5), Cash: decimal.NewFromInt(5)}),
+	}
+
+	recorder := internal.NewPerformanceRecorder()
+	aw := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), recorder.Reader(newSliceReader(records)), aw, internal.WithPositions(aw))
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	got := recorder.Performance(aw, nil, time.Time{})
+
+	// 20 shares at 10 after the split are merged into 20 shares at 5 and 100 in cash
+	if !got.Value.Equal(decimal.NewFromInt(100)) || !got.NetInvested.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("want a value of 100 and 100 invested but got %v and %v", got.Value, got.NetInvested)
+	}
+
+	if !got.TWR.IsZero() {
+		t.Errorf("want no return but got %v", got.TWR)
+	}
+
+	if !got.End.Equal(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("want the period to end at the last record but got %v", got.End)
+	}
+}
+
+func TestReadPrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"prices", `{"US0378331005": "180.25", "IE00B4L5Y983": 95}`, map[string]string{"US0378331005": "180.25", "IE00B4L5Y983": "95"}, false},
+		{"negative", `{"US0378331005": "-1"}`, nil, true},
+		{"invalid", `["180.25"]`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := internal.ReadPrices(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %v but got %v", tt.want, got)
+			}
+			for symbol, price := range tt.want {
+				if got[symbol].String() != price {
+					t.Errorf("want %s to be %s but got %v", symbol, price, got[symbol])
+				}
+			}
+		})
+	}
+}
+
+func costsRecord(rec internal.Record, fees, taxes float64) internal.Record {
+	return costsTestRecord{Record: rec, fees: decimal.NewFromFloat(fees), taxes: decimal.NewFromFloat(taxes)}
+}
+
+type costsTestRecord struct {
+	internal.Record
+
+	fees  decimal.Decimal
+	taxes decimal.Decimal
+}
+
+func (r costsTestRecord) Fees() decimal.Decimal {
+	return r.fees
+}
+
+func (r costsTestRecord) Taxes() decimal.Decimal {
+	return r.taxes
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Position is an open lot, or what is left of it, after processing every Record.
+type Position struct {
+	Symbol        string
+	Account       string
+	BrokerCountry int64
+	AssetCountry  int64
+	Quantity      decimal.Decimal
+	// Cost is the acquisition value of the Quantity in EUR or, for short positions, the proceeds.
+	Cost decimal.Decimal
+	// Timestamp is when the lot was acquired or, for short positions, sold.
+	Timestamp time.Time
+	Short     bool
+}
+
+// DaysHeld returns the number of calendar days from the date the position was opened to the date
+// of at, like ReportItem.HoldingDays.
+func (p Position) DaysHeld(at time.Time) int {
+	days := int(calendarDate(at).Sub(calendarDate(p.Timestamp)) / (24 * time.Hour))
+	return max(days, 0)
+}
+
+// Value returns the market value of the position at price.
+func (p Position) Value(price decimal.Decimal) decimal.Decimal {
+	return p.Quantity.Mul(price)
+}
+
+// UnrealisedPnL returns the gain, or loss if negative, of closing the position at price.
+func (p Position) UnrealisedPnL(price decimal.Decimal) decimal.Decimal {
+	if p.Short {
+		return p.Cost.Sub(p.Value(price))
+	}
+	return p.Value(price).Sub(p.Cost)
+}
+
+// HeldFor returns when the position completes the given number of days held.
+func (p Position) HeldFor(days int) time.Time {
+	return p.Timestamp.AddDate(0, 0, days)
+}
+
+type PositionWriter interface {
+	// WritePosition writes the open positions
+	WritePosition(context.Context, Position) error
+}
+
+// WithPositions writes the positions still open after processing every Record to w.
+func WithPositions(w PositionWriter) ReportOption {
+	return func(o *reportOptions) {
+		o.positions = w
+	}
+}
+
+// writePositions writes the open lots and short positions sorted by symbol and account and, for
+// each of these, in FIFO order.
+func (l *ledger) writePositions(ctx context.Context, w PositionWriter) error {
+	err := writeQueues(ctx, l.buys, false, w)
+	if err != nil {
+		return err
+	}
+
+	return writeQueues(ctx, l.shorts, true, w)
+}
+
+func writeQueues(ctx context.Context, queues map[lotKey]*FillerQueue, short bool, w PositionWriter) error {
+	keys := make([]lotKey, 0, len(queues))
+	for key := range queues {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b lotKey) int {
+		return cmp.Or(cmp.Compare(a.symbol, b.symbol), cmp.Compare(a.account, b.account))
+	})
+
+	for _, key := range keys {
+		for lot := range queues[key].All() {
+			quantity := lot.Unfilled()
+
+			err := w.WritePosition(ctx, Position{
+				Symbol:        lot.Symbol(),
+				Account:       key.account,
+				BrokerCountry: lot.BrokerCountry(),
+				AssetCountry:  lot.AssetCountry(),
+				Quantity:      quantity,
+				Cost:          quantity.Mul(lot.Price()),
+				Timestamp:     lot.Timestamp(),
+				Short:         short,
+			})
+			if err != nil {
+				return fmt.Errorf("write position: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
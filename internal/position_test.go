@@ -0,0 +1,111 @@
+package internal_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBuildReport_WithPositions(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		accountTestRecord{Record: mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now), account: "a"},
+		accountTestRecord{Record: symbolRecord(mockRecord(ctrl, 22.0, 5.0, internal.SideBuy, now.Add(1)), "OTHER"), account: "b"},
+		accountTestRecord{Record: mockRecord(ctrl, 21.0, 2.0, internal.SideBuy, now.Add(2)), account: "a"},
+		accountTestRecord{Record: mockRecord(ctrl, 25.0, 4.0, internal.SideSell, now.Add(3)), account: "a"},
+		accountTestRecord{Record: mockRecord(ctrl, 30.0, 3.0, internal.SideSell, now.Add(4)), account: "b"},
+	}
+
+	writer := internal.NewAggregatorWriter()
+
+	err := internal.BuildReport(t.Context(), newSliceReader(records), writer, internal.WithPositions(writer), internal.WithShortSelling())
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	type position struct {
+		symbol    string
+		account   string
+		quantity  float64
+		cost      float64
+		timestamp time.Time
+		short     bool
+	}
+
+	want := []position{
+		{"OTHER", "b", 5.0, 110.0, now.Add(1), false},
+		{"TEST", "a", 6.0, 120.0, now, false},
+		{"TEST", "a", 2.0, 42.0, now.Add(2), false},
+		{"TEST", "b", 3.0, 90.0, now.Add(4), true},
+	}
+
+	var got []position
+	for p := range writer.Positions() {
+		got = append(got, position{p.Symbol, p.Account, p.Quantity.InexactFloat64(), p.Cost.InexactFloat64(), p.Timestamp, p.Short})
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("want positions %+v but got %+v", want, got)
+	}
+}
+
+func TestPosition_DaysHeld(t *testing.T) {
+	p := internal.Position{
+		Quantity:  decimal.NewFromInt(1),
+		Timestamp: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"before acquisition", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"same day", time.Date(2023, 3, 1, 23, 0, 0, 0, time.UTC), 0},
+		{"next day", time.Date(2023, 3, 2, 10, 0, 0, 0, time.UTC), 1},
+		{"next day at midnight", time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC), 1},
+		{"one year with a leap day", time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), 366},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.DaysHeld(tt.at); got != tt.want {
+				t.Fatalf("want %d days held but got %d", tt.want, got)
+			}
+		})
+	}
+
+	if got := p.HeldFor(365); !got.Equal(time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("want 365 days held on 2024-02-29 but got %v", got)
+	}
+}
+
+func TestPosition_UnrealisedPnL(t *testing.T) {
+	tests := []struct {
+		name  string
+		short bool
+		want  string
+	}{
+		{"long", false, "30"},
+		{"short", true, "-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := internal.Position{Quantity: decimal.NewFromInt(3), Cost: decimal.NewFromInt(60), Short: tt.short}
+
+			got := p.UnrealisedPnL(decimal.NewFromInt(30))
+			if got.String() != tt.want {
+				t.Errorf("want %s but got %v", tt.want, got)
+			}
+
+			if value := p.Value(decimal.NewFromInt(30)); value.String() != "90" {
+				t.Errorf("want a value of 90 but got %v", value)
+			}
+		})
+	}
+}
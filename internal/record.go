@@ -0,0 +1,27 @@
+package internal
+
+// RecordWrapper is implemented by Records that decorate another Record, usually to override some
+// of its values. Use RecordAs to look for optional interfaces through a chain of wrappers.
+type RecordWrapper interface {
+	Unwrap() Record
+}
+
+// RecordAs finds the first Record in the chain of wrappers of r that implements T, similar to
+// errors.As. Returns false on the 2nd return value if there's none.
+func RecordAs[T any](r Record) (T, bool) {
+	for r != nil {
+		if t, ok := r.(T); ok {
+			return t, true
+		}
+
+		w, ok := r.(RecordWrapper)
+		if !ok {
+			break
+		}
+
+		r = w.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
@@ -23,6 +23,17 @@ type Record interface {
 	Taxes() decimal.Decimal
 }
 
+// ClosedPosition is a sell Record that the broker already matched against its acquisition. Instead
+// of going through FIFO, BuildReport reports it as is.
+type ClosedPosition interface {
+	Record
+
+	// OpenPrice is the unit price paid when the position was opened.
+	OpenPrice() decimal.Decimal
+	// OpenTimestamp is when the position was opened.
+	OpenTimestamp() time.Time
+}
+
 type RecordReader interface {
 	// ReadRecord should return Records until an error is found.
 	ReadRecord(context.Context) (Record, error)
@@ -30,28 +41,99 @@ type RecordReader interface {
 
 type ReportItem struct {
 	Symbol        string
+	Account       string
 	Nature        Nature
 	BrokerCountry int64
 	AssetCountry  int64
+	// Quantity is how much was sold, or bought back when Short.
+	Quantity decimal.Decimal
+	// BuyValue is the nominal acquisition value.
 	BuyValue      decimal.Decimal
 	BuyTimestamp  time.Time
 	SellValue     decimal.Decimal
 	SellTimestamp time.Time
 	Fees          decimal.Decimal
 	Taxes         decimal.Decimal
+	// AccruedInterest is the interest received on the sell minus the interest paid on the buy of
+	// bonds. It's reported as income, in quadro 8, instead of being part of the buy and sell values.
+	AccruedInterest decimal.Decimal
+	// CorrectedBuyValue is the BuyValue updated by the monetary correction coefficient of the year of
+	// acquisition. It's zero when the correction doesn't apply.
+	CorrectedBuyValue decimal.Decimal
+	// Short is true when the position was opened by the sell and closed by a later buy. In that case
+	// BuyTimestamp is when the position was opened and SellTimestamp is the closing buy, when the
+	// result is realised.
+	Short bool
 }
 
 func (ri ReportItem) RealisedPnL() decimal.Decimal {
 	return ri.SellValue.Sub(ri.BuyValue)
 }
 
+// AcquisitionValue returns the CorrectedBuyValue, if any, or the BuyValue otherwise. It's the value
+// used to compute the taxable gain.
+func (ri ReportItem) AcquisitionValue() decimal.Decimal {
+	if ri.CorrectedBuyValue.IsZero() {
+		return ri.BuyValue
+	}
+	return ri.CorrectedBuyValue
+}
+
+// ShortTermDays is the holding period under which gains are short-term.
+const ShortTermDays = 365
+
+// HoldingDays returns the number of calendar days between the dates of the acquisition and of the
+// realisation, regardless of the time of day and of daylight saving time changes.
+func (ri ReportItem) HoldingDays() int {
+	days := int(calendarDate(ri.SellTimestamp).Sub(calendarDate(ri.BuyTimestamp)) / (24 * time.Hour))
+	return max(days, 0)
+}
+
+// calendarDate returns midnight UTC of the date of t in its own location.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsShortTerm returns true if the item was held for less than ShortTermDays. Since 2023, the
+// short-term gains must be aggregated (englobamento) by taxpayers in the top bracket.
+func (ri ReportItem) IsShortTerm() bool {
+	return ri.HoldingDays() < ShortTermDays
+}
+
 type ReportWriter interface {
 	// ReportWriter writes report items
 	Write(context.Context, ReportItem) error
 }
 
-func BuildReport(ctx context.Context, reader RecordReader, writer ReportWriter) error {
-	buys := make(map[string]*FillerQueue)
+// ReportOption customizes how BuildReport matches the records.
+type ReportOption func(*reportOptions)
+
+type reportOptions struct {
+	globalFIFO   bool
+	shortSelling bool
+	positions    PositionWriter
+	audit        AuditWriter
+	correction   *MonetaryCorrection
+}
+
+// WithGlobalFIFO matches sells against the lots bought in any account instead of only the lots of
+// the account where the sell happened.
+func WithGlobalFIFO() ReportOption {
+	return func(o *reportOptions) {
+		o.globalFIFO = true
+	}
+}
+
+// WithShortSelling opens a short position when selling more than what is held instead of failing
+// with ErrInsufficientBoughtVolume. Short positions are closed by the following buys, in FIFO order.
+func WithShortSelling() ReportOption {
+	return func(o *reportOptions) {
+		o.shortSelling = true
+	}
+}
+
+func BuildReport(ctx context.Context, reader RecordReader, writer ReportWriter, opts ...ReportOption) error {
+	l := newLedger(opts...)
 
 	for {
 		select {
@@ -61,18 +143,22 @@ func BuildReport(ctx context.Context, reader RecordReader, writer ReportWriter)
 			rec, err := reader.ReadRecord(ctx)
 			if err != nil {
 				if errors.Is(err, io.EOF) {
+					if len(l.pending) > 0 {
+						return fmt.Errorf("processing record: %w", ErrInsufficientTransferredVolume)
+					}
+					if l.options.positions != nil {
+						return l.writePositions(ctx, l.options.positions)
+					}
 					return nil
 				}
 				return err
 			}
 
-			buyQueue, ok := buys[rec.Symbol()]
-			if !ok {
-				buyQueue = new(FillerQueue)
-				buys[rec.Symbol()] = buyQueue
+			if len(l.pending) > 0 && rec.Timestamp().After(l.pending[0].Timestamp()) {
+				return fmt.Errorf("processing record: %w", ErrInsufficientTransferredVolume)
 			}
 
-			err = processRecord(ctx, buyQueue, rec, writer)
+			err = l.process(ctx, rec, writer)
 			if err != nil {
 				return fmt.Errorf("processing record: %w", err)
 			}
@@ -80,10 +166,109 @@ func BuildReport(ctx context.Context, reader RecordReader, writer ReportWriter)
 	}
 }
 
-func processRecord(ctx context.Context, q *FillerQueue, rec Record, writer ReportWriter) error {
+// ledger keeps the open lots of every security while the records are processed.
+type ledger struct {
+	options reportOptions
+
+	// buys holds the open lots per account and symbol, or only per symbol when using global FIFO.
+	buys map[lotKey]*FillerQueue
+	// shorts holds the open short positions using the same keys as buys.
+	shorts map[lotKey]*FillerQueue
+	// transit holds the lots transferred out of a broker until they are transferred in to another.
+	transit map[string]*FillerQueue
+	// pending holds the transfers in read before their transfer out. Records with the same timestamp
+	// come in no particular order so these wait until a record with a later timestamp is read.
+	pending []Record
+}
+
+func newLedger(opts ...ReportOption) *ledger {
+	var options reportOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &ledger{
+		options: options,
+		buys:    make(map[lotKey]*FillerQueue),
+		shorts:  make(map[lotKey]*FillerQueue),
+		transit: make(map[string]*FillerQueue),
+	}
+}
+
+type lotKey struct {
+	account string
+	symbol  string
+}
+
+func (l *ledger) key(rec Record) lotKey {
+	key := lotKey{symbol: rec.Symbol()}
+	if !l.options.globalFIFO {
+		key.account = AccountOf(rec)
+	}
+	return key
+}
+
+// queues returns the open lots, the open short positions and the lots in transit of the security
+// of rec. The short positions are nil unless rec can be sold short.
+func (l *ledger) queues(rec Record, short bool) (q, shorts, transit *FillerQueue) {
+	key := l.key(rec)
+
+	q, ok := l.buys[key]
+	if !ok {
+		q = new(FillerQueue)
+		l.buys[key] = q
+	}
+
+	if short {
+		shorts, ok = l.shorts[key]
+		if !ok {
+			shorts = new(FillerQueue)
+			l.shorts[key] = shorts
+		}
+	}
+
+	transit, ok = l.transit[rec.Symbol()]
+	if !ok {
+		transit = new(FillerQueue)
+		l.transit[rec.Symbol()] = transit
+	}
+
+	return q, shorts, transit
+}
+
+func (l *ledger) process(ctx context.Context, rec Record, writer ReportWriter) error {
+	if ca, ok := RecordAs[CorporateActionRecord](rec); ok {
+		return l.processCorporateAction(ctx, rec, ca.CorporateAction(), writer)
+	}
+
+	if d, ok := RecordAs[DerivativeRecord](rec); ok {
+		return l.processDerivative(ctx, rec, d.Derivative(), writer)
+	}
+
+	q, shorts, transit := l.queues(rec, l.options.shortSelling)
+
+	return l.processRecord(ctx, q, shorts, transit, rec, writer)
+}
+
+// processRecord matches rec against the open lots in q. The shorts queue holds the open short
+// positions and must be nil unless short selling is enabled.
+func (l *ledger) processRecord(ctx context.Context, q, shorts, transit *FillerQueue, rec Record, writer ReportWriter) error {
+	if cp, ok := RecordAs[ClosedPosition](rec); ok {
+		return l.processClosedPosition(ctx, rec, cp, writer)
+	}
+
 	switch rec.Side() {
 	case SideBuy:
-		q.Push(NewFiller(rec))
+		unmatchedQty, err := l.closeShorts(ctx, shorts, rec, writer)
+		if err != nil {
+			return err
+		}
+
+		if unmatchedQty.Equal(rec.Quantity()) {
+			q.Push(NewFiller(rec))
+		} else if unmatchedQty.IsPositive() {
+			q.Push(NewFiller(partialRecord{Record: rec, quantity: unmatchedQty}))
+		}
 
 	case SideSell:
 		unmatchedQty := rec.Quantity()
@@ -91,9 +276,20 @@ func processRecord(ctx context.Context, q *FillerQueue, rec Record, writer Repor
 		for unmatchedQty.IsPositive() {
 			buy, ok := q.Peek()
 			if !ok {
-				return ErrInsufficientBoughtVolume
+				if shorts == nil {
+					return ErrInsufficientBoughtVolume
+				}
+
+				if unmatchedQty.Equal(rec.Quantity()) {
+					shorts.Push(NewFiller(rec))
+				} else {
+					shorts.Push(NewFiller(partialRecord{Record: rec, quantity: unmatchedQty}))
+				}
+
+				break
 			}
 
+			filledBefore := buy.Filled()
 			matchedQty, filled := buy.Fill(unmatchedQty)
 
 			if filled {
@@ -108,21 +304,49 @@ func processRecord(ctx context.Context, q *FillerQueue, rec Record, writer Repor
 			buyValue := matchedQty.Mul(buy.Price())
 			sellValue := matchedQty.Mul(rec.Price())
 
-			err := writer.Write(ctx, ReportItem{
-				Symbol:        rec.Symbol(),
-				BrokerCountry: rec.BrokerCountry(),
-				AssetCountry:  rec.AssetCountry(),
-				BuyValue:      buyValue,
-				BuyTimestamp:  buy.Timestamp(),
-				SellValue:     sellValue,
-				SellTimestamp: rec.Timestamp(),
-				Fees:          buy.Fees().Add(rec.Fees()),
-				Taxes:         buy.Taxes().Add(rec.Taxes()),
-				Nature:        buy.Nature(),
-			})
+			err := l.write(ctx, writer, ReportItem{
+				Symbol:          rec.Symbol(),
+				Account:         AccountOf(rec),
+				BrokerCountry:   rec.BrokerCountry(),
+				AssetCountry:    rec.AssetCountry(),
+				Quantity:        matchedQty,
+				BuyValue:        buyValue,
+				BuyTimestamp:    buy.Timestamp(),
+				SellValue:       sellValue,
+				SellTimestamp:   rec.Timestamp(),
+				Fees:            matchedCosts(buy.Fees(), rec.Fees(), buy.Record, rec, matchedQty),
+				Taxes:           matchedCosts(buy.Taxes(), rec.Taxes(), buy.Record, rec, matchedQty),
+				Nature:          buy.Nature(),
+				AccruedInterest: netAccruedInterest(rec, buy.Record, matchedQty),
+			}, lotMatch(rec, buy, matchedQty, filledBefore))
 			if err != nil {
-				return fmt.Errorf("write report item: %w", err)
+				return err
+			}
+		}
+
+	case SideTransferOut:
+		err := moveLots(q, transit, rec.Quantity(), nil)
+		if err != nil {
+			if errors.Is(err, errEmptyQueue) {
+				return ErrInsufficientBoughtVolume
 			}
+			return err
+		}
+
+		return l.processPending(ctx, writer)
+
+	case SideTransferIn:
+		if transit.Unfilled().LessThan(rec.Quantity()) {
+			l.pending = append(l.pending, rec)
+			return nil
+		}
+
+		err := moveLots(transit, q, rec.Quantity(), rec)
+		if err != nil {
+			if errors.Is(err, errEmptyQueue) {
+				return ErrInsufficientTransferredVolume
+			}
+			return err
 		}
 
 	default:
@@ -131,3 +355,222 @@ func processRecord(ctx context.Context, q *FillerQueue, rec Record, writer Repor
 
 	return nil
 }
+
+// processPending retries the transfers in that were waiting for a transfer out. The ones still
+// missing lots in transit keep waiting.
+func (l *ledger) processPending(ctx context.Context, writer ReportWriter) error {
+	pending := l.pending
+	l.pending = nil
+
+	for _, rec := range pending {
+		err := l.process(ctx, rec, writer)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// closeShorts matches the buy against the open short positions and returns the quantity left to
+// open a long position.
+func (l *ledger) closeShorts(ctx context.Context, shorts *FillerQueue, buy Record, writer ReportWriter) (decimal.Decimal, error) {
+	unmatchedQty := buy.Quantity()
+
+	for unmatchedQty.IsPositive() {
+		short, ok := shorts.Peek()
+		if !ok {
+			break
+		}
+
+		filledBefore := short.Filled()
+		matchedQty, filled := short.Fill(unmatchedQty)
+
+		if filled {
+			_, ok := shorts.Pop()
+			if !ok {
+				return decimal.Decimal{}, fmt.Errorf("pop empty filler queue")
+			}
+		}
+
+		unmatchedQty = unmatchedQty.Sub(matchedQty)
+
+		err := l.write(ctx, writer, ReportItem{
+			Symbol:          buy.Symbol(),
+			Account:         AccountOf(buy),
+			BrokerCountry:   buy.BrokerCountry(),
+			AssetCountry:    buy.AssetCountry(),
+			Quantity:        matchedQty,
+			BuyValue:        matchedQty.Mul(buy.Price()),
+			BuyTimestamp:    short.Timestamp(),
+			SellValue:       matchedQty.Mul(short.Price()),
+			SellTimestamp:   buy.Timestamp(),
+			Fees:            matchedCosts(short.Fees(), buy.Fees(), short.Record, buy, matchedQty),
+			Taxes:           matchedCosts(short.Taxes(), buy.Taxes(), short.Record, buy, matchedQty),
+			Nature:          short.Nature(),
+			AccruedInterest: netAccruedInterest(short.Record, buy, matchedQty),
+			Short:           true,
+		}, lotMatch(buy, short, matchedQty, filledBefore))
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+	}
+
+	return unmatchedQty, nil
+}
+
+var errEmptyQueue = fmt.Errorf("empty queue")
+
+// moveLots moves quantity from the front of src into dst, splitting the last lot if needed. The lots
+// keep their acquisition details and are inserted in chronological order into dst. When the move
+// happens because of a transfer in, the lots take the broker context of that record.
+func moveLots(src, dst *FillerQueue, quantity decimal.Decimal, transferIn Record) error {
+	for quantity.IsPositive() {
+		lot, ok := src.Peek()
+		if !ok {
+			return errEmptyQueue
+		}
+
+		movedQty, filled := lot.Fill(quantity)
+
+		if filled {
+			_, ok := src.Pop()
+			if !ok {
+				return fmt.Errorf("pop empty filler queue")
+			}
+		}
+
+		quantity = quantity.Sub(movedQty)
+
+		moved := transferredRecord{
+			Record:   lot.Record,
+			quantity: movedQty,
+		}
+		if transferIn != nil {
+			moved.brokerCountry = transferIn.BrokerCountry()
+			moved.account = AccountOf(transferIn)
+		}
+
+		dst.Insert(NewFiller(moved))
+	}
+
+	return nil
+}
+
+// transferredRecord is a lot, or part of it, that was moved between brokers. Other than the
+// quantity, broker and account it is indistinguishable from the original acquisition.
+type transferredRecord struct {
+	Record
+
+	quantity decimal.Decimal
+	// brokerCountry and account are zero values while the lot is in transit.
+	brokerCountry int64
+	account       string
+}
+
+func (tr transferredRecord) Unwrap() Record {
+	return tr.Record
+}
+
+func (tr transferredRecord) Account() string {
+	if tr.account == "" {
+		return AccountOf(tr.Record)
+	}
+	return tr.account
+}
+
+func (tr transferredRecord) Quantity() decimal.Decimal {
+	return tr.quantity
+}
+
+func (tr transferredRecord) Fees() decimal.Decimal {
+	return prorate(tr.Record.Fees(), tr.quantity, tr.Record.Quantity())
+}
+
+func (tr transferredRecord) Taxes() decimal.Decimal {
+	return prorate(tr.Record.Taxes(), tr.quantity, tr.Record.Quantity())
+}
+
+func (tr transferredRecord) BrokerCountry() int64 {
+	if tr.brokerCountry == 0 {
+		return tr.Record.BrokerCountry()
+	}
+	return tr.brokerCountry
+}
+
+// prorate returns the part of value that corresponds to quantity out of total.
+func prorate(value, quantity, total decimal.Decimal) decimal.Decimal {
+	if total.IsZero() || quantity.Equal(total) {
+		return value
+	}
+	return value.Mul(quantity).Div(total)
+}
+
+// matchedCosts returns the part of the costs of the opening and closing records that corresponds to
+// the matched quantity, so records matched in more than one report item are not counted twice.
+func matchedCosts(openingCosts, closingCosts decimal.Decimal, opening, closing Record, matchedQty decimal.Decimal) decimal.Decimal {
+	return prorate(openingCosts, matchedQty, opening.Quantity()).Add(prorate(closingCosts, matchedQty, closing.Quantity()))
+}
+
+// partialRecord is the part of a Record that was left after matching some of its quantity.
+type partialRecord struct {
+	Record
+
+	quantity decimal.Decimal
+}
+
+func (pr partialRecord) Unwrap() Record {
+	return pr.Record
+}
+
+func (pr partialRecord) Quantity() decimal.Decimal {
+	return pr.quantity
+}
+
+func (pr partialRecord) Fees() decimal.Decimal {
+	return prorate(pr.Record.Fees(), pr.quantity, pr.Record.Quantity())
+}
+
+func (pr partialRecord) Taxes() decimal.Decimal {
+	return prorate(pr.Record.Taxes(), pr.quantity, pr.Record.Quantity())
+}
+
+// processClosedPosition reports the position as matched by the broker. The rec and cp are the same
+// record but rec might be wrapped, overriding some of the values of cp.
+func (l *ledger) processClosedPosition(ctx context.Context, rec Record, cp ClosedPosition, writer ReportWriter) error {
+	if !rec.Side().IsSell() {
+		return fmt.Errorf("closed position with side: %v", rec.Side())
+	}
+
+	// The broker already matched the position so the acquisition is in the same line of the statement.
+	acquisition := newAuditRecord(rec)
+	acquisition.Side = SideBuy
+	acquisition.Timestamp = cp.OpenTimestamp()
+	acquisition.Price = cp.OpenPrice()
+
+	err := l.write(ctx, writer, ReportItem{
+		Symbol:          rec.Symbol(),
+		Account:         AccountOf(rec),
+		BrokerCountry:   rec.BrokerCountry(),
+		AssetCountry:    rec.AssetCountry(),
+		Quantity:        rec.Quantity(),
+		BuyValue:        rec.Quantity().Mul(cp.OpenPrice()),
+		BuyTimestamp:    cp.OpenTimestamp(),
+		SellValue:       rec.Quantity().Mul(rec.Price()),
+		SellTimestamp:   rec.Timestamp(),
+		Fees:            rec.Fees(),
+		Taxes:           rec.Taxes(),
+		Nature:          rec.Nature(),
+		AccruedInterest: netAccruedInterest(rec, nil, rec.Quantity()),
+	}, match{
+		realisation: rec,
+		acquisition: acquisition,
+		quantity:    rec.Quantity(),
+		filledAfter: rec.Quantity(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
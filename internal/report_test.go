@@ -2,6 +2,7 @@ package internal_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"testing"
@@ -35,6 +36,7 @@ func TestBuildReport(t *testing.T) {
 
 	writer := mocks.NewMockReportWriter(ctrl)
 	writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+		Quantity:      decimal.NewFromFloat(10),
 		BuyValue:      decimal.NewFromFloat(200.0),
 		BuyTimestamp:  now,
 		SellValue:     decimal.NewFromFloat(250.0),
@@ -49,6 +51,349 @@ func TestBuildReport(t *testing.T) {
 	}
 }
 
+func TestBuildReport_Transfer(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	reader := mocks.NewMockRecordReader(ctrl)
+	records := []internal.Record{
+		mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+		mockRecord(ctrl, 22.0, 5.0, internal.SideBuy, now.Add(1)),
+		mockRecord(ctrl, 0, 12.0, internal.SideTransferOut, now.Add(2)),
+		mockRecord(ctrl, 0, 12.0, internal.SideTransferIn, now.Add(3)),
+		mockRecord(ctrl, 25.0, 11.0, internal.SideSell, now.Add(4)),
+	}
+	reader.EXPECT().ReadRecord(gomock.Any()).DoAndReturn(func(ctx context.Context) (internal.Record, error) {
+		if len(records) > 0 {
+			r := records[0]
+			records = records[1:]
+			return r, nil
+		}
+		return nil, io.EOF
+	}).Times(6)
+
+	// The transfer must not realise any gain and the sale must still consume the oldest lot first
+	writer := mocks.NewMockReportWriter(ctrl)
+	gomock.InOrder(
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(10),
+			BuyValue:      decimal.NewFromFloat(200.0),
+			BuyTimestamp:  now,
+			SellValue:     decimal.NewFromFloat(250.0),
+			SellTimestamp: now.Add(4),
+		})),
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(1),
+			BuyValue:      decimal.NewFromFloat(22.0),
+			BuyTimestamp:  now.Add(1),
+			SellValue:     decimal.NewFromFloat(25.0),
+			SellTimestamp: now.Add(4),
+		})),
+	)
+
+	gotErr := internal.BuildReport(t.Context(), reader, writer)
+	if gotErr != nil {
+		t.Fatalf("got unexpected err: %v", gotErr)
+	}
+}
+
+func TestBuildReport_TransferErrors(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		records func(ctrl *gomock.Controller) []internal.Record
+		want    error
+	}{
+		{"transfer out without buys", func(ctrl *gomock.Controller) []internal.Record {
+			return []internal.Record{mockRecord(ctrl, 0, 1.0, internal.SideTransferOut, now)}
+		}, internal.ErrInsufficientBoughtVolume},
+		{"transfer in without transfer out", func(ctrl *gomock.Controller) []internal.Record {
+			return []internal.Record{mockRecord(ctrl, 0, 1.0, internal.SideTransferIn, now)}
+		}, internal.ErrInsufficientTransferredVolume},
+		{"transfer out after the transfer in", func(ctrl *gomock.Controller) []internal.Record {
+			return []internal.Record{
+				mockRecord(ctrl, 20.0, 1.0, internal.SideBuy, now),
+				mockRecord(ctrl, 0, 1.0, internal.SideTransferIn, now.Add(1)),
+				mockRecord(ctrl, 0, 1.0, internal.SideTransferOut, now.Add(2)),
+			}
+		}, internal.ErrInsufficientTransferredVolume},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			reader := newSliceReader(tt.records(ctrl))
+
+			writer := mocks.NewMockReportWriter(ctrl)
+
+			gotErr := internal.BuildReport(t.Context(), reader, writer)
+			if !errors.Is(gotErr, tt.want) {
+				t.Fatalf("want error %v but got %v", tt.want, gotErr)
+			}
+		})
+	}
+}
+
+func TestBuildReport_ClosedPosition(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	cp := mocks.NewMockClosedPosition(ctrl)
+	cp.EXPECT().Symbol().Return("TEST").AnyTimes()
+	cp.EXPECT().BrokerCountry().Return(int64(countries.PL)).AnyTimes()
+	cp.EXPECT().AssetCountry().Return(int64(countries.USA)).AnyTimes()
+	cp.EXPECT().Side().Return(internal.SideSell).AnyTimes()
+	cp.EXPECT().Quantity().Return(decimal.NewFromFloat(10.0)).AnyTimes()
+	cp.EXPECT().Price().Return(decimal.NewFromFloat(25.0)).AnyTimes()
+	cp.EXPECT().Timestamp().Return(now.Add(1)).AnyTimes()
+	cp.EXPECT().OpenPrice().Return(decimal.NewFromFloat(20.0)).AnyTimes()
+	cp.EXPECT().OpenTimestamp().Return(now).AnyTimes()
+	cp.EXPECT().Fees().Return(decimal.NewFromFloat(1.0)).AnyTimes()
+	cp.EXPECT().Taxes().Return(decimal.Decimal{}).AnyTimes()
+	cp.EXPECT().Nature().Return(internal.NatureG01).AnyTimes()
+
+	records := []internal.Record{cp}
+	reader := mocks.NewMockRecordReader(ctrl)
+	reader.EXPECT().ReadRecord(gomock.Any()).DoAndReturn(func(ctx context.Context) (internal.Record, error) {
+		if len(records) > 0 {
+			r := records[0]
+			records = records[1:]
+			return r, nil
+		}
+		return nil, io.EOF
+	}).Times(2)
+
+	// Without a previous buy, going through FIFO would fail with ErrInsufficientBoughtVolume
+	writer := mocks.NewMockReportWriter(ctrl)
+	writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+		Quantity:      decimal.NewFromFloat(10),
+		BuyValue:      decimal.NewFromFloat(200.0),
+		BuyTimestamp:  now,
+		SellValue:     decimal.NewFromFloat(250.0),
+		SellTimestamp: now.Add(1),
+		Fees:          decimal.NewFromFloat(1.0),
+		Taxes:         decimal.Decimal{},
+	})).Times(1)
+
+	gotErr := internal.BuildReport(t.Context(), reader, writer)
+	if gotErr != nil {
+		t.Fatalf("got unexpected err: %v", gotErr)
+	}
+}
+
+func TestBuildReport_Accounts(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		opts    []internal.ReportOption
+		want    []internal.ReportItem
+		wantErr error
+	}{
+		{
+			name: "lots are isolated per account",
+			want: []internal.ReportItem{
+				{Account: "b", Quantity: decimal.NewFromFloat(5), BuyValue: decimal.NewFromFloat(110.0), BuyTimestamp: now.Add(1), SellValue: decimal.NewFromFloat(125.0), SellTimestamp: now.Add(2)},
+			},
+			wantErr: internal.ErrInsufficientBoughtVolume,
+		},
+		{
+			name: "global fifo",
+			opts: []internal.ReportOption{internal.WithGlobalFIFO()},
+			want: []internal.ReportItem{
+				{Account: "b", Quantity: decimal.NewFromFloat(5), BuyValue: decimal.NewFromFloat(100.0), BuyTimestamp: now, SellValue: decimal.NewFromFloat(125.0), SellTimestamp: now.Add(2)},
+				{Account: "b", Quantity: decimal.NewFromFloat(5), BuyValue: decimal.NewFromFloat(110.0), BuyTimestamp: now.Add(1), SellValue: decimal.NewFromFloat(125.0), SellTimestamp: now.Add(2)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			// Account b only bought 5 so selling 10 requires the lot bought at account a
+			reader := internal.NewMergeReader(
+				internal.NewAccountReader(newSliceReader([]internal.Record{
+					mockRecord(ctrl, 20.0, 5.0, internal.SideBuy, now),
+				}), "a"),
+				internal.NewAccountReader(newSliceReader([]internal.Record{
+					mockRecord(ctrl, 22.0, 5.0, internal.SideBuy, now.Add(1)),
+					mockRecord(ctrl, 25.0, 10.0, internal.SideSell, now.Add(2)),
+				}), "b"),
+			)
+
+			writer := mocks.NewMockReportWriter(ctrl)
+			var calls []any
+			for _, ri := range tt.want {
+				calls = append(calls, writer.EXPECT().Write(gomock.Any(), eqReportItem(ri)).Times(1))
+			}
+			gomock.InOrder(calls...)
+
+			gotErr := internal.BuildReport(t.Context(), reader, writer, tt.opts...)
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("want error %v but got %v", tt.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestBuildReport_TransferBetweenAccounts(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	reader := internal.NewMergeReader(
+		internal.NewAccountReader(newSliceReader([]internal.Record{
+			mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now),
+			mockRecord(ctrl, 0, 10.0, internal.SideTransferOut, now.Add(1)),
+		}), "a"),
+		internal.NewAccountReader(newSliceReader([]internal.Record{
+			mockRecord(ctrl, 0, 10.0, internal.SideTransferIn, now.Add(2)),
+			mockRecord(ctrl, 25.0, 10.0, internal.SideSell, now.Add(3)),
+		}), "b"),
+	)
+
+	writer := mocks.NewMockReportWriter(ctrl)
+	writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+		Account:       "b",
+		Quantity:      decimal.NewFromFloat(10),
+		BuyValue:      decimal.NewFromFloat(200.0),
+		BuyTimestamp:  now,
+		SellValue:     decimal.NewFromFloat(250.0),
+		SellTimestamp: now.Add(3),
+	})).Times(1)
+
+	gotErr := internal.BuildReport(t.Context(), reader, writer)
+	if gotErr != nil {
+		t.Fatalf("got unexpected err: %v", gotErr)
+	}
+}
+
+func TestBuildReport_TransferSameTimestamp(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	// The transfer in comes first because its statement was given first
+	reader := internal.NewMergeReader(
+		internal.NewAccountReader(newSliceReader([]internal.Record{
+			mockRecord(ctrl, 0, 4.0, internal.SideTransferIn, now.Add(1)),
+			mockRecord(ctrl, 25.0, 4.0, internal.SideSell, now.Add(2)),
+		}), "b"),
+		internal.NewAccountReader(newSliceReader([]internal.Record{
+			costsRecord(mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now), 1, 0.5),
+			mockRecord(ctrl, 0, 4.0, internal.SideTransferOut, now.Add(1)),
+		}), "a"),
+	)
+
+	// Only the fees and taxes of the transferred part of the lot go with it
+	writer := mocks.NewMockReportWriter(ctrl)
+	writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+		Account:       "b",
+		Quantity:      decimal.NewFromFloat(4),
+		BuyValue:      decimal.NewFromFloat(80.0),
+		BuyTimestamp:  now,
+		SellValue:     decimal.NewFromFloat(100.0),
+		SellTimestamp: now.Add(2),
+		Fees:          decimal.NewFromFloat(0.4),
+		Taxes:         decimal.NewFromFloat(0.2),
+	})).Times(1)
+
+	gotErr := internal.BuildReport(t.Context(), reader, writer)
+	if gotErr != nil {
+		t.Fatalf("got unexpected err: %v", gotErr)
+	}
+}
+
+func TestBuildReport_ShortSelling(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		mockRecord(ctrl, 25.0, 10.0, internal.SideSell, now),
+		mockRecord(ctrl, 20.0, 6.0, internal.SideBuy, now.Add(1)),
+		mockRecord(ctrl, 22.0, 6.0, internal.SideBuy, now.Add(2)),
+		mockRecord(ctrl, 30.0, 3.0, internal.SideSell, now.Add(3)),
+	}
+
+	writer := mocks.NewMockReportWriter(ctrl)
+	gomock.InOrder(
+		// The buys close the short position and realise its result
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(6),
+			BuyValue:      decimal.NewFromFloat(120.0),
+			BuyTimestamp:  now,
+			SellValue:     decimal.NewFromFloat(150.0),
+			SellTimestamp: now.Add(1),
+			Short:         true,
+		})),
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(4),
+			BuyValue:      decimal.NewFromFloat(88.0),
+			BuyTimestamp:  now,
+			SellValue:     decimal.NewFromFloat(100.0),
+			SellTimestamp: now.Add(2),
+			Short:         true,
+		})),
+		// What's left of the last buy is a regular lot and the remaining sale opens a new short
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(2),
+			BuyValue:      decimal.NewFromFloat(44.0),
+			BuyTimestamp:  now.Add(2),
+			SellValue:     decimal.NewFromFloat(60.0),
+			SellTimestamp: now.Add(3),
+		})),
+	)
+
+	gotErr := internal.BuildReport(t.Context(), newSliceReader(records), writer, internal.WithShortSelling())
+	if gotErr != nil {
+		t.Fatalf("got unexpected err: %v", gotErr)
+	}
+
+	gotErr = internal.BuildReport(t.Context(), newSliceReader(records[:1]), writer)
+	if !errors.Is(gotErr, internal.ErrInsufficientBoughtVolume) {
+		t.Fatalf("want error %v without short selling but got %v", internal.ErrInsufficientBoughtVolume, gotErr)
+	}
+}
+
+func TestBuildReport_PartialCosts(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+
+	records := []internal.Record{
+		costsRecord(mockRecord(ctrl, 25.0, 4.0, internal.SideSell, now), 1, 0),
+		costsRecord(mockRecord(ctrl, 20.0, 10.0, internal.SideBuy, now.Add(1)), 2, 1),
+		mockRecord(ctrl, 30.0, 6.0, internal.SideSell, now.Add(2)),
+	}
+
+	// The costs of the buy are split between the short it closes and the lot left open
+	writer := mocks.NewMockReportWriter(ctrl)
+	gomock.InOrder(
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(4),
+			BuyValue:      decimal.NewFromFloat(80.0),
+			BuyTimestamp:  now,
+			SellValue:     decimal.NewFromFloat(100.0),
+			SellTimestamp: now.Add(1),
+			Fees:          decimal.NewFromFloat(1.8),
+			Taxes:         decimal.NewFromFloat(0.4),
+			Short:         true,
+		})),
+		writer.EXPECT().Write(gomock.Any(), eqReportItem(internal.ReportItem{
+			Quantity:      decimal.NewFromFloat(6),
+			BuyValue:      decimal.NewFromFloat(120.0),
+			BuyTimestamp:  now.Add(1),
+			SellValue:     decimal.NewFromFloat(180.0),
+			SellTimestamp: now.Add(2),
+			Fees:          decimal.NewFromFloat(1.2),
+			Taxes:         decimal.NewFromFloat(0.6),
+		})),
+	)
+
+	gotErr := internal.BuildReport(t.Context(), newSliceReader(records), writer, internal.WithShortSelling())
+	if gotErr != nil {
+		t.Fatalf("got unexpected err: %v", gotErr)
+	}
+}
+
 func mockRecord(ctrl *gomock.Controller, price, quantity float64, side internal.Side, ts time.Time) *mocks.MockRecord {
 	rec := mocks.NewMockRecord(ctrl)
 	rec.EXPECT().Symbol().Return("TEST").AnyTimes()
@@ -82,7 +427,10 @@ func (m ReportItemMatcher) Matches(x any) bool {
 
 	switch other := x.(type) {
 	case internal.ReportItem:
-		return m.BuyValue.Equal(other.BuyValue) &&
+		return m.Account == other.Account &&
+			m.Short == other.Short &&
+			m.Quantity.Equal(other.Quantity) &&
+			m.BuyValue.Equal(other.BuyValue) &&
 			m.BuyTimestamp.Equal(other.BuyTimestamp) &&
 			m.SellValue.Equal(other.SellValue) &&
 			m.SellTimestamp.Equal(other.SellTimestamp) &&
@@ -98,3 +446,62 @@ func (m ReportItemMatcher) String() string {
 }
 
 var _ gomock.Matcher = (*ReportItemMatcher)(nil)
+
+func TestReportItem_IsShortTerm(t *testing.T) {
+	bought := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		sold     time.Time
+		wantDays int
+		want     bool
+	}{
+		{"same day", bought.Add(time.Hour), 0, true},
+		{"one day short of a year", bought.AddDate(0, 0, 364), 364, true},
+		{"a year", bought.AddDate(0, 0, 365), 365, false},
+		{"a year earlier in the day", time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC), 365, false},
+		{"one day short of a year later in the day", time.Date(2025, 2, 28, 23, 0, 0, 0, time.UTC), 364, true},
+		{"sold before bought", bought.Add(-time.Hour), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := internal.ReportItem{BuyTimestamp: bought, SellTimestamp: tt.sold}
+
+			if ri.HoldingDays() != tt.wantDays {
+				t.Fatalf("want %d days held but got %d", tt.wantDays, ri.HoldingDays())
+			}
+
+			if ri.IsShortTerm() != tt.want {
+				t.Fatalf("want short-term %v but got %v", tt.want, ri.IsShortTerm())
+			}
+		})
+	}
+}
+
+func TestReportItem_HoldingDaysDST(t *testing.T) {
+	lisbon, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		t.Skipf("missing time zone data: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		bought   time.Time
+		sold     time.Time
+		wantDays int
+	}{
+		// From the winter to the summer time the year is an hour short of 365 * 24h
+		{"a year into the summer time", time.Date(2024, 3, 30, 10, 0, 0, 0, lisbon), time.Date(2025, 3, 30, 10, 0, 0, 0, lisbon), 365},
+		{"across the spring change", time.Date(2024, 3, 30, 0, 30, 0, 0, lisbon), time.Date(2024, 3, 31, 0, 30, 0, 0, lisbon), 1},
+		{"across the autumn change", time.Date(2024, 10, 26, 23, 30, 0, 0, lisbon), time.Date(2024, 10, 27, 23, 30, 0, 0, lisbon), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := internal.ReportItem{BuyTimestamp: tt.bought, SellTimestamp: tt.sold}
+
+			if ri.HoldingDays() != tt.wantDays {
+				t.Fatalf("want %d days held but got %d", tt.wantDays, ri.HoldingDays())
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package scalable
+
+import (
+	"github.com/biter777/countries"
+)
+
+const Country = countries.Germany
@@ -0,0 +1,244 @@
+package scalable
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+type Record struct {
+	symbol    string
+	timestamp time.Time
+	side      internal.Side
+	quantity  decimal.Decimal
+	price     decimal.Decimal
+	fees      decimal.Decimal
+	taxes     decimal.Decimal
+
+	// natureGetter allows us to defer the operation of figuring out the nature to only when/if needed.
+	natureGetter func() internal.Nature
+	// line is where the record is in the statement, starting at 1.
+	line int
+}
+
+func (r Record) Symbol() string {
+	return r.symbol
+}
+
+func (r Record) Timestamp() time.Time {
+	return r.timestamp
+}
+
+func (r Record) BrokerCountry() int64 {
+	return int64(Country)
+}
+
+func (r Record) AssetCountry() int64 {
+	return int64(countries.ByName(r.Symbol()[:2]).Info().Code)
+}
+
+func (r Record) Side() internal.Side {
+	return r.side
+}
+
+func (r Record) Quantity() decimal.Decimal {
+	return r.quantity
+}
+
+func (r Record) Price() decimal.Decimal {
+	return r.price
+}
+
+func (r Record) Fees() decimal.Decimal {
+	return r.fees
+}
+
+func (r Record) Taxes() decimal.Decimal {
+	return r.taxes
+}
+
+func (r Record) Nature() internal.Nature {
+	return r.natureGetter()
+}
+
+// NatureSource is always OpenFIGI since the statement has no type of instrument.
+func (r Record) NatureSource() internal.NatureSource {
+	return internal.NatureSourceOpenFIGI
+}
+
+func (r Record) Line() int {
+	return r.line
+}
+
+// RecordReader reads the transactions CSV exported from the Scalable Capital broker. Columns are
+// looked up by name from the header row so reordering or adding columns does not break parsing.
+type RecordReader struct {
+	reader  *csv.Reader
+	figi    *internal.OpenFIGI
+	columns map[string]int
+}
+
+func NewRecordReader(r io.Reader, f *internal.OpenFIGI) *RecordReader {
+	reader := csv.NewReader(r)
+	reader.Comma = ';'
+
+	return &RecordReader{
+		reader: reader,
+		figi:   f,
+	}
+}
+
+const (
+	Buy         = "buy"
+	Sell        = "sell"
+	SavingsPlan = "savings plan"
+
+	StatusExecuted = "executed"
+)
+
+// Columns required to build a Record.
+const (
+	colDate   = "date"
+	colTime   = "time"
+	colStatus = "status"
+	colType   = "type"
+	colISIN   = "isin"
+	colShares = "shares"
+	colPrice  = "price"
+	colFee    = "fee"
+	colTax    = "tax"
+)
+
+// timestampLayout is the layout of the date and time columns once joined by a space.
+const timestampLayout = time.DateOnly + " " + time.TimeOnly
+
+func (rr *RecordReader) ReadRecord(ctx context.Context) (internal.Record, error) {
+	if rr.columns == nil {
+		err := rr.readHeader()
+		if err != nil {
+			return Record{}, err
+		}
+	}
+
+	for {
+		raw, err := rr.reader.Read()
+		if err != nil {
+			return Record{}, fmt.Errorf("read record: %w", err)
+		}
+
+		// Cancelled and rejected orders are also part of the export.
+		if !strings.EqualFold(rr.field(raw, colStatus), StatusExecuted) {
+			continue
+		}
+
+		var side internal.Side
+		switch strings.ToLower(rr.field(raw, colType)) {
+		case Buy, SavingsPlan:
+			// Savings plan executions are regular buys that happen to be free of order fees.
+			side = internal.SideBuy
+		case Sell:
+			side = internal.SideSell
+		default:
+			// distributions, interest, deposits, withdrawals, etc.
+			continue
+		}
+
+		qant, err := parseDecimal(rr.field(raw, colShares))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record quantity: %w", err)
+		}
+
+		price, err := parseDecimal(rr.field(raw, colPrice))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record price: %w", err)
+		}
+
+		ts, err := time.Parse(timestampLayout, rr.field(raw, colDate)+" "+rr.field(raw, colTime))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record timestamp: %w", err)
+		}
+
+		fee, err := parseOptionalDecimal(rr.field(raw, colFee))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record fee: %w", err)
+		}
+
+		tax, err := parseOptionalDecimal(rr.field(raw, colTax))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record tax: %w", err)
+		}
+
+		isin := rr.field(raw, colISIN)
+
+		// Scalable Capital reports costs as negative cash movements but internally we always deal with
+		// absolute values.
+		return Record{
+			symbol:       isin,
+			side:         side,
+			quantity:     qant.Abs(),
+			price:        price.Abs(),
+			fees:         fee.Abs(),
+			taxes:        tax.Abs(),
+			timestamp:    ts,
+			natureGetter: rr.figi.NatureGetter(ctx, isin),
+			line:         rr.line(),
+		}, nil
+	}
+}
+
+// line returns the line of the last record read from the statement.
+func (rr *RecordReader) line() int {
+	line, _ := rr.reader.FieldPos(0)
+	return line
+}
+
+func (rr *RecordReader) readHeader() error {
+	header, err := rr.reader.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{colDate, colTime, colStatus, colType, colISIN, colShares, colPrice, colFee, colTax} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing column in header: %s", name)
+		}
+	}
+
+	rr.columns = columns
+
+	return nil
+}
+
+func (rr *RecordReader) field(raw []string, name string) string {
+	return raw[rr.columns[name]]
+}
+
+// parseDecimal parses numbers as formatted by the export which uses a comma as the decimal
+// separator and a dot as the thousands separator (i.e.: 1.234,56).
+func parseDecimal(s string) (decimal.Decimal, error) {
+	s = strings.ReplaceAll(s, ".", "")
+	s = strings.ReplaceAll(s, ",", ".")
+	return decimal.NewFromString(s)
+}
+
+// parseOptionalDecimal behaves the same as parseDecimal but returns 0 when len(s) is 0 instead of
+// error.
+func parseOptionalDecimal(s string) (decimal.Decimal, error) {
+	if len(s) == 0 {
+		return decimal.Decimal{}, nil
+	}
+
+	return parseDecimal(s)
+}
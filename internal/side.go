@@ -6,6 +6,12 @@ const (
 	SideUnknown Side = iota
 	SideBuy
 	SideSell
+	SideTransferIn
+	SideTransferOut
+	SideExpire
+	SideExercise
+	SideAssign
+	SideCorporateAction
 )
 
 func (d Side) String() string {
@@ -14,6 +20,18 @@ func (d Side) String() string {
 		return "buy"
 	case SideSell:
 		return "sell"
+	case SideTransferIn:
+		return "transfer in"
+	case SideTransferOut:
+		return "transfer out"
+	case SideExpire:
+		return "expire"
+	case SideExercise:
+		return "exercise"
+	case SideAssign:
+		return "assign"
+	case SideCorporateAction:
+		return "corporate action"
 	default:
 		return "unknown"
 	}
@@ -28,3 +46,33 @@ func (d Side) IsBuy() bool {
 func (d Side) IsSell() bool {
 	return d == SideSell
 }
+
+// IsTransferIn returns true if the s == SideTransferIn
+func (d Side) IsTransferIn() bool {
+	return d == SideTransferIn
+}
+
+// IsTransferOut returns true if the s == SideTransferOut
+func (d Side) IsTransferOut() bool {
+	return d == SideTransferOut
+}
+
+// IsExpire returns true if the s == SideExpire
+func (d Side) IsExpire() bool {
+	return d == SideExpire
+}
+
+// IsExercise returns true if the s == SideExercise
+func (d Side) IsExercise() bool {
+	return d == SideExercise
+}
+
+// IsAssign returns true if the s == SideAssign
+func (d Side) IsAssign() bool {
+	return d == SideAssign
+}
+
+// IsCorporateAction returns true if the s == SideCorporateAction
+func (d Side) IsCorporateAction() bool {
+	return d == SideCorporateAction
+}
@@ -10,6 +10,12 @@ func TestSide_String(t *testing.T) {
 	}{
 		{"buy", SideBuy, "buy"},
 		{"sell", SideSell, "sell"},
+		{"transfer in", SideTransferIn, "transfer in"},
+		{"transfer out", SideTransferOut, "transfer out"},
+		{"expire", SideExpire, "expire"},
+		{"exercise", SideExercise, "exercise"},
+		{"assign", SideAssign, "assign"},
+		{"corporate action", SideCorporateAction, "corporate action"},
 		{"unknown", SideUnknown, "unknown"},
 	}
 	for _, tt := range tests {
@@ -58,3 +64,41 @@ func TestSide_IsSell(t *testing.T) {
 		})
 	}
 }
+
+func TestSide_IsTransferIn(t *testing.T) {
+	tests := []struct {
+		name string
+		side Side
+		want bool
+	}{
+		{"buy", SideBuy, false},
+		{"transfer in", SideTransferIn, true},
+		{"transfer out", SideTransferOut, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.side.IsTransferIn(); got != tt.want {
+				t.Errorf("want Side.IsTransferIn() to be %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSide_IsTransferOut(t *testing.T) {
+	tests := []struct {
+		name string
+		side Side
+		want bool
+	}{
+		{"sell", SideSell, false},
+		{"transfer in", SideTransferIn, false},
+		{"transfer out", SideTransferOut, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.side.IsTransferOut(); got != tt.want {
+				t.Errorf("want Side.IsTransferOut() to be %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+
+	"github.com/shopspring/decimal"
+)
+
+// SummaryValues are the sums of a group of ReportItems. Like the totals, the values of each item
+// are rounded before being added so they match the declaration.
+type SummaryValues struct {
+	Disposals        int
+	Quantity         decimal.Decimal
+	AcquisitionValue decimal.Decimal
+	RealisationValue decimal.Decimal
+	Fees             decimal.Decimal
+	Taxes            decimal.Decimal
+	// NetPnL is the sum of the RealisedPnL minus the fees, each rounded on its own so it may be off
+	// by a cent from the other values. The taxes paid abroad are left out since they're credited
+	// against the tax due in Portugal.
+	NetPnL decimal.Decimal
+}
+
+func (sv *SummaryValues) add(ri ReportItem) {
+	sv.Disposals++
+	sv.Quantity = sv.Quantity.Add(ri.Quantity)
+	sv.AcquisitionValue = sv.AcquisitionValue.Add(ri.BuyValue.Round(2))
+	sv.RealisationValue = sv.RealisationValue.Add(ri.SellValue.Round(2))
+	sv.Fees = sv.Fees.Add(ri.Fees.Round(2))
+	sv.Taxes = sv.Taxes.Add(ri.Taxes.Round(2))
+	sv.NetPnL = sv.NetPnL.Add(ri.RealisedPnL().Round(2)).Sub(ri.Fees.Round(2))
+}
+
+// SymbolSummary is the result of the disposals of a symbol with a given nature.
+type SymbolSummary struct {
+	Symbol string
+	Nature Nature
+	SummaryValues
+}
+
+// CountrySummary is the result of the disposals of the assets of a source country.
+type CountrySummary struct {
+	Country int64
+	SummaryValues
+}
+
+// Summary has the results of a report grouped by symbol and nature, sorted by symbol, and by the
+// source country of the assets, sorted by the country code.
+type Summary struct {
+	Symbols   []SymbolSummary
+	Countries []CountrySummary
+}
+
+// Summarize groups the items by symbol and nature and by the source country of the assets.
+func Summarize(items iter.Seq[ReportItem]) Summary {
+	type symbolKey struct {
+		symbol string
+		nature Nature
+	}
+
+	symbols := make(map[symbolKey]*SymbolSummary)
+	countries := make(map[int64]*CountrySummary)
+
+	for ri := range items {
+		sk := symbolKey{symbol: ri.Symbol, nature: ri.Nature}
+		ss, ok := symbols[sk]
+		if !ok {
+			ss = &SymbolSummary{Symbol: ri.Symbol, Nature: ri.Nature}
+			symbols[sk] = ss
+		}
+		ss.add(ri)
+
+		cs, ok := countries[ri.AssetCountry]
+		if !ok {
+			cs = &CountrySummary{Country: ri.AssetCountry}
+			countries[ri.AssetCountry] = cs
+		}
+		cs.add(ri)
+	}
+
+	var s Summary
+
+	for _, ss := range symbols {
+		s.Symbols = append(s.Symbols, *ss)
+	}
+	slices.SortFunc(s.Symbols, func(a, b SymbolSummary) int {
+		return cmp.Or(cmp.Compare(a.Symbol, b.Symbol), cmp.Compare(a.Nature, b.Nature))
+	})
+
+	for _, cs := range countries {
+		s.Countries = append(s.Countries, *cs)
+	}
+	slices.SortFunc(s.Countries, func(a, b CountrySummary) int {
+		return cmp.Compare(a.Country, b.Country)
+	})
+
+	return s
+}
@@ -0,0 +1,89 @@
+package internal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestSummarize(t *testing.T) {
+	items := []internal.ReportItem{
+		{
+			Symbol: "US0378331005", Nature: internal.NatureG01, AssetCountry: 840,
+			Quantity: decimal.NewFromInt(2), BuyValue: decimal.NewFromFloat(100.005), SellValue: decimal.NewFromFloat(150),
+			Fees: decimal.NewFromFloat(1.5), Taxes: decimal.NewFromFloat(3),
+		},
+		{
+			Symbol: "IE00B4L5Y983", Nature: internal.NatureG20, AssetCountry: 372,
+			Quantity: decimal.NewFromInt(10), BuyValue: decimal.NewFromFloat(60), SellValue: decimal.NewFromFloat(50),
+			Fees: decimal.NewFromFloat(0.5),
+		},
+		{
+			Symbol: "US0378331005", Nature: internal.NatureG01, AssetCountry: 840,
+			Quantity: decimal.NewFromInt(1), BuyValue: decimal.NewFromFloat(50.005), SellValue: decimal.NewFromFloat(70),
+			Fees: decimal.NewFromFloat(1),
+		},
+		{
+			Symbol: "US5949181045", Nature: internal.NatureG01, AssetCountry: 840,
+			Quantity: decimal.NewFromInt(1), BuyValue: decimal.NewFromFloat(300), SellValue: decimal.NewFromFloat(290),
+		},
+	}
+
+	got := internal.Summarize(slices.Values(items))
+
+	wantSymbols := []struct {
+		symbol    string
+		nature    internal.Nature
+		disposals int
+		quantity  string
+		bought    string
+		sold      string
+		fees      string
+		taxes     string
+		netPnL    string
+	}{
+		{"IE00B4L5Y983", internal.NatureG20, 1, "10", "60", "50", "0.5", "0", "-10.5"},
+		// Each value is rounded before being added: 100.01 + 50.01 and 49.995 -> 50 + 19.995 -> 20 - 2.5
+		{"US0378331005", internal.NatureG01, 2, "3", "150.02", "220", "2.5", "3", "67.5"},
+		{"US5949181045", internal.NatureG01, 1, "1", "300", "290", "0", "0", "-10"},
+	}
+	if len(got.Symbols) != len(wantSymbols) {
+		t.Fatalf("want %d symbols but got %d: %+v", len(wantSymbols), len(got.Symbols), got.Symbols)
+	}
+	for i, want := range wantSymbols {
+		s := got.Symbols[i]
+		if s.Symbol != want.symbol || s.Nature != want.nature || s.Disposals != want.disposals ||
+			s.Quantity.String() != want.quantity || s.AcquisitionValue.String() != want.bought ||
+			s.RealisationValue.String() != want.sold || s.Fees.String() != want.fees ||
+			s.Taxes.String() != want.taxes || s.NetPnL.String() != want.netPnL {
+			t.Errorf("want symbol %d to be %+v but got %+v", i, want, s)
+		}
+	}
+
+	wantCountries := []struct {
+		country   int64
+		disposals int
+		netPnL    string
+	}{
+		{372, 1, "-10.5"},
+		{840, 3, "57.5"},
+	}
+	if len(got.Countries) != len(wantCountries) {
+		t.Fatalf("want %d countries but got %d: %+v", len(wantCountries), len(got.Countries), got.Countries)
+	}
+	for i, want := range wantCountries {
+		c := got.Countries[i]
+		if c.Country != want.country || c.Disposals != want.disposals || c.NetPnL.String() != want.netPnL {
+			t.Errorf("want country %d to be %+v but got %+v", i, want, c)
+		}
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	got := internal.Summarize(slices.Values([]internal.ReportItem(nil)))
+	if len(got.Symbols) != 0 || len(got.Countries) != 0 {
+		t.Errorf("want an empty summary but got %+v", got)
+	}
+}
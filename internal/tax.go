@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// AutonomousRate is the special rate of capital gains (article 72 of the CIRS).
+var AutonomousRate = decimal.RequireFromString("0.28")
+
+// TaxBracket is a bracket of the general IRS rates (article 68 of the CIRS). The rate applies to the
+// part of the taxable income up to UpTo, which is zero for the last bracket.
+type TaxBracket struct {
+	UpTo decimal.Decimal
+	Rate decimal.Decimal
+}
+
+// TaxBrackets are the general IRS rates used with englobamento by tax year, as amended during the
+// year when that was the case.
+var TaxBrackets = map[int][]TaxBracket{
+	2023: {
+		{decimal.NewFromInt(7479), decimal.RequireFromString("0.145")},
+		{decimal.NewFromInt(11284), decimal.RequireFromString("0.21")},
+		{decimal.NewFromInt(15992), decimal.RequireFromString("0.265")},
+		{decimal.NewFromInt(20700), decimal.RequireFromString("0.285")},
+		{decimal.NewFromInt(26355), decimal.RequireFromString("0.35")},
+		{decimal.NewFromInt(38632), decimal.RequireFromString("0.37")},
+		{decimal.NewFromInt(50483), decimal.RequireFromString("0.435")},
+		{decimal.NewFromInt(78834), decimal.RequireFromString("0.45")},
+		{decimal.Decimal{}, decimal.RequireFromString("0.48")},
+	},
+	2024: {
+		{decimal.NewFromInt(7703), decimal.RequireFromString("0.13")},
+		{decimal.NewFromInt(11623), decimal.RequireFromString("0.165")},
+		{decimal.NewFromInt(16472), decimal.RequireFromString("0.22")},
+		{decimal.NewFromInt(21321), decimal.RequireFromString("0.25")},
+		{decimal.NewFromInt(27146), decimal.RequireFromString("0.32")},
+		{decimal.NewFromInt(39791), decimal.RequireFromString("0.355")},
+		{decimal.NewFromInt(43000), decimal.RequireFromString("0.435")},
+		{decimal.NewFromInt(80000), decimal.RequireFromString("0.45")},
+		{decimal.Decimal{}, decimal.RequireFromString("0.48")},
+	},
+	2025: {
+		{decimal.NewFromInt(8059), decimal.RequireFromString("0.125")},
+		{decimal.NewFromInt(12160), decimal.RequireFromString("0.16")},
+		{decimal.NewFromInt(17233), decimal.RequireFromString("0.215")},
+		{decimal.NewFromInt(22306), decimal.RequireFromString("0.244")},
+		{decimal.NewFromInt(28400), decimal.RequireFromString("0.314")},
+		{decimal.NewFromInt(41629), decimal.RequireFromString("0.349")},
+		{decimal.NewFromInt(44987), decimal.RequireFromString("0.431")},
+		{decimal.NewFromInt(83696), decimal.RequireFromString("0.446")},
+		{decimal.Decimal{}, decimal.RequireFromString("0.48")},
+	},
+}
+
+// TaxBracketsOf returns the general IRS rates of the tax year or ErrUnknownTaxYear if they're not
+// in TaxBrackets.
+func TaxBracketsOf(year int) ([]TaxBracket, error) {
+	brackets, ok := TaxBrackets[year]
+	if !ok {
+		return nil, fmt.Errorf("tax brackets of %d: %w", year, ErrUnknownTaxYear)
+	}
+	return brackets, nil
+}
+
+// SolidarityBrackets are the rates of the additional solidarity tax (article 68-A of the CIRS).
+var SolidarityBrackets = []TaxBracket{
+	{decimal.NewFromInt(80000), decimal.Decimal{}},
+	{decimal.NewFromInt(250000), decimal.RequireFromString("0.025")},
+	{decimal.Decimal{}, decimal.RequireFromString("0.05")},
+}
+
+// IncomeTax returns the tax of the taxable income with the general rates in brackets, including the
+// solidarity tax, before any deduction.
+func IncomeTax(income decimal.Decimal, brackets []TaxBracket) decimal.Decimal {
+	return progressiveTax(income, brackets).Add(progressiveTax(income, SolidarityBrackets))
+}
+
+func progressiveTax(income decimal.Decimal, brackets []TaxBracket) decimal.Decimal {
+	var tax, from decimal.Decimal
+
+	for _, b := range brackets {
+		if !income.GreaterThan(from) {
+			break
+		}
+
+		to := income
+		if !b.UpTo.IsZero() && b.UpTo.LessThan(income) {
+			to = b.UpTo
+		}
+
+		tax = tax.Add(to.Sub(from).Mul(b.Rate))
+		from = b.UpTo
+
+		if b.UpTo.IsZero() {
+			break
+		}
+	}
+
+	return tax
+}
+
+// TaxBracketOf returns the bracket in brackets, starting at 1, of the taxable income.
+func TaxBracketOf(income decimal.Decimal, brackets []TaxBracket) int {
+	for i, b := range brackets {
+		if b.UpTo.IsZero() || income.LessThan(b.UpTo) {
+			return i + 1
+		}
+	}
+	return len(brackets)
+}
+
+// IsTopTaxBracket returns true if bracket is the last one of brackets, where the short-term gains
+// must be aggregated.
+func IsTopTaxBracket(bracket int, brackets []TaxBracket) bool {
+	return bracket >= len(brackets)
+}
+
+// TaxOption is the tax due on the capital gains with one of the options of the declaration.
+type TaxOption struct {
+	// Rate is the average rate applied to the net gain.
+	Rate decimal.Decimal
+	// Tax is the tax on the net gain before the foreign tax credit.
+	Tax decimal.Decimal
+	// ForeignTaxCredit is the part of the tax paid abroad that can be deducted. It's limited, for
+	// each item, to the Portuguese tax on the gain of that item.
+	ForeignTaxCredit decimal.Decimal
+	// Due is the Tax minus the ForeignTaxCredit.
+	Due decimal.Decimal
+}
+
+// TaxEstimate compares the tax on the capital gains at the autonomous rate with the tax when
+// opting for englobamento. It's an estimate: deductions, losses of previous years and the special
+// regimes are not considered.
+type TaxEstimate struct {
+	// NetGain is the sum of the sell values minus the acquisition values, with the monetary
+	// correction, and the fees.
+	NetGain decimal.Decimal
+	// ShortTermGain is the part of NetGain of the items held for less than a year.
+	ShortTermGain decimal.Decimal
+	// ForeignTaxPaid is the total tax paid abroad.
+	ForeignTaxPaid decimal.Decimal
+	// TaxableIncome is the other income taxed with the general rates, used by englobamento.
+	TaxableIncome decimal.Decimal
+	// Year is the tax year of the items and of the general rates.
+	Year int
+	// Bracket is the bracket of the taxpayer, starting at 1.
+	Bracket int
+	// MandatoryAggregation is true when the short-term gains are aggregated even without opting for
+	// englobamento, because the taxpayer is in the top bracket.
+	MandatoryAggregation bool
+
+	Autonomous   TaxOption
+	Englobamento TaxOption
+}
+
+// PreferEnglobamento returns true when opting for englobamento results in less tax due.
+func (te TaxEstimate) PreferEnglobamento() bool {
+	return te.Englobamento.Due.LessThan(te.Autonomous.Due)
+}
+
+// EstimateTax estimates the tax of the tax year on the items written to aw realised in that year,
+// given the other taxable income and the bracket of the taxpayer. When the bracket is 0, it's the
+// bracket of the taxable income plus the short-term gains. Returns ErrUnknownTaxYear if the rates of
+// the year are not known.
+func EstimateTax(aw *AggregatorWriter, taxableIncome decimal.Decimal, bracket, year int) (TaxEstimate, error) {
+	brackets, err := TaxBracketsOf(year)
+	if err != nil {
+		return TaxEstimate{}, err
+	}
+
+	// The statements usually have the sales of the previous years too
+	aw = aw.OfYear(year)
+
+	est := TaxEstimate{
+		NetGain:        aw.TotalEarned().Sub(aw.TotalCorrectedSpent()).Sub(aw.TotalFees()),
+		ShortTermGain:  aw.ShortTermEarned().Sub(aw.ShortTermSpent()).Sub(aw.ShortTermFees()),
+		ForeignTaxPaid: aw.TotalTaxes(),
+		TaxableIncome:  taxableIncome,
+		Year:           year,
+		Bracket:        bracket,
+	}
+
+	zero := decimal.Decimal{}
+	gain := decimal.Max(est.NetGain, zero)
+	shortTermGain := decimal.Max(est.ShortTermGain, zero)
+
+	if est.Bracket == 0 {
+		est.Bracket = TaxBracketOf(taxableIncome.Add(shortTermGain), brackets)
+	}
+	est.MandatoryAggregation = IsTopTaxBracket(est.Bracket, brackets)
+
+	est.Englobamento.Tax = aggregatedTax(taxableIncome, gain, brackets)
+	if gain.IsPositive() {
+		est.Englobamento.Rate = est.Englobamento.Tax.Div(gain)
+	}
+
+	englobamentoRate := func(ReportItem) decimal.Decimal { return est.Englobamento.Rate }
+
+	autonomousRate := func(ReportItem) decimal.Decimal { return AutonomousRate }
+
+	est.Autonomous.Rate = AutonomousRate
+	est.Autonomous.Tax = gain.Mul(AutonomousRate).Round(2)
+
+	if est.MandatoryAggregation {
+		// The short-term gains are aggregated and only the others are taxed at the autonomous rate
+		longTermGain := decimal.Max(est.NetGain.Sub(est.ShortTermGain), zero)
+		shortTermTax := aggregatedTax(taxableIncome, shortTermGain, brackets)
+
+		var shortTermRate decimal.Decimal
+		if shortTermGain.IsPositive() {
+			shortTermRate = shortTermTax.Div(shortTermGain)
+		}
+
+		est.Autonomous.Tax = longTermGain.Mul(AutonomousRate).Round(2).Add(shortTermTax)
+		if total := longTermGain.Add(shortTermGain); total.IsPositive() {
+			est.Autonomous.Rate = est.Autonomous.Tax.Div(total)
+		}
+
+		autonomousRate = func(ri ReportItem) decimal.Decimal {
+			if ri.IsShortTerm() {
+				return shortTermRate
+			}
+			return AutonomousRate
+		}
+	}
+
+	est.Autonomous.ForeignTaxCredit = foreignTaxCredit(aw, autonomousRate, est.Autonomous.Tax)
+	est.Autonomous.Due = est.Autonomous.Tax.Sub(est.Autonomous.ForeignTaxCredit)
+
+	est.Englobamento.ForeignTaxCredit = foreignTaxCredit(aw, englobamentoRate, est.Englobamento.Tax)
+	est.Englobamento.Due = est.Englobamento.Tax.Sub(est.Englobamento.ForeignTaxCredit)
+
+	return est, nil
+}
+
+// aggregatedTax returns the increase of the tax of the taxable income when gain is aggregated.
+func aggregatedTax(taxableIncome, gain decimal.Decimal, brackets []TaxBracket) decimal.Decimal {
+	return IncomeTax(taxableIncome.Add(gain), brackets).Sub(IncomeTax(taxableIncome, brackets)).Round(2)
+}
+
+// foreignTaxCredit returns the tax paid abroad that can be deducted. For each item, it's limited to
+// the tax at the rate of the item on its gain, and in total it's limited to the tax.
+func foreignTaxCredit(aw *AggregatorWriter, rate func(ReportItem) decimal.Decimal, tax decimal.Decimal) decimal.Decimal {
+	var credit decimal.Decimal
+
+	for ri := range aw.Iter() {
+		gain := ri.SellValue.Sub(ri.AcquisitionValue()).Sub(ri.Fees)
+		if !gain.IsPositive() || !ri.Taxes.IsPositive() {
+			continue
+		}
+
+		credit = credit.Add(decimal.Min(ri.Taxes, gain.Mul(rate(ri))))
+	}
+
+	return decimal.Min(credit, tax).Round(2)
+}
@@ -0,0 +1,281 @@
+package internal_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestIncomeTax(t *testing.T) {
+	tests := []struct {
+		name   string
+		year   int
+		income float64
+		want   float64
+	}{
+		{"no income", 2025, 0, 0},
+		{"first bracket", 2025, 8000, 1000},
+		{"second bracket", 2025, 10000, 1317.935},
+		{"with solidarity tax", 2025, 100000, 37559.911},
+		// 7703 * 13% + 297 * 16.5%
+		{"2024 second bracket", 2024, 8000, 1050.395},
+		// 7479 * 14.5% + 521 * 21%
+		{"2023 second bracket", 2023, 8000, 1193.865},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brackets, err := internal.TaxBracketsOf(tt.year)
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+
+			got := internal.IncomeTax(decimal.NewFromFloat(tt.income), brackets)
+			if !got.Equal(decimal.NewFromFloat(tt.want)) {
+				t.Fatalf("want tax %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEstimateTax(t *testing.T) {
+	bought := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	sold := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	boughtLate := time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC)
+	soldSoon := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		items            []internal.ReportItem
+		income           float64
+		bracket          int
+		wantNetGain      float64
+		wantAutonomous   internal.TaxOption
+		wantEnglobamento internal.TaxOption
+		wantPrefer       bool
+		wantMandatory    bool
+	}{
+		{
+			name: "low income prefers englobamento",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(2010), Fees: decimal.NewFromInt(10), Taxes: decimal.NewFromInt(50)},
+			},
+			income:      10000,
+			wantNetGain: 1000,
+			wantAutonomous: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.28"),
+				Tax:              decimal.NewFromInt(280),
+				ForeignTaxCredit: decimal.NewFromInt(50),
+				Due:              decimal.NewFromInt(230),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.16"),
+				Tax:              decimal.NewFromInt(160),
+				ForeignTaxCredit: decimal.NewFromInt(50),
+				Due:              decimal.NewFromInt(110),
+			},
+			wantPrefer: true,
+		},
+		{
+			name: "foreign tax credit is limited to the portuguese tax",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(1100), Taxes: decimal.NewFromInt(40)},
+			},
+			income:      10000,
+			wantNetGain: 100,
+			wantAutonomous: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.28"),
+				Tax:              decimal.NewFromInt(28),
+				ForeignTaxCredit: decimal.NewFromInt(28),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.16"),
+				Tax:              decimal.NewFromInt(16),
+				ForeignTaxCredit: decimal.NewFromInt(16),
+			},
+		},
+		{
+			name: "losses are not taxed",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(1100), Taxes: decimal.NewFromInt(10)},
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(800)},
+			},
+			income:      10000,
+			wantNetGain: -100,
+			wantAutonomous: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.28"),
+			},
+		},
+		{
+			name: "sales of other years are ignored",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(5000), Taxes: decimal.NewFromInt(100)},
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(2010), Fees: decimal.NewFromInt(10), Taxes: decimal.NewFromInt(50)},
+			},
+			income:      10000,
+			wantNetGain: 1000,
+			wantAutonomous: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.28"),
+				Tax:              decimal.NewFromInt(280),
+				ForeignTaxCredit: decimal.NewFromInt(50),
+				Due:              decimal.NewFromInt(230),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.16"),
+				Tax:              decimal.NewFromInt(160),
+				ForeignTaxCredit: decimal.NewFromInt(50),
+				Due:              decimal.NewFromInt(110),
+			},
+			wantPrefer: true,
+		},
+		{
+			name: "short-term gains of other years don't change the bracket",
+			items: []internal.ReportItem{
+				{BuyTimestamp: boughtLate.AddDate(-1, 0, 0), SellTimestamp: soldSoon.AddDate(-1, 0, 0), BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(200000)},
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(2010), Fees: decimal.NewFromInt(10), Taxes: decimal.NewFromInt(50)},
+			},
+			income:      10000,
+			wantNetGain: 1000,
+			wantAutonomous: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.28"),
+				Tax:              decimal.NewFromInt(280),
+				ForeignTaxCredit: decimal.NewFromInt(50),
+				Due:              decimal.NewFromInt(230),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate:             decimal.RequireFromString("0.16"),
+				Tax:              decimal.NewFromInt(160),
+				ForeignTaxCredit: decimal.NewFromInt(50),
+				Due:              decimal.NewFromInt(110),
+			},
+			wantPrefer: true,
+		},
+		{
+			name: "high income prefers the autonomous rate",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(2000)},
+			},
+			income:      100000,
+			wantNetGain: 1000,
+			wantAutonomous: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.28"),
+				Tax:  decimal.NewFromInt(280),
+				Due:  decimal.NewFromInt(280),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.505"),
+				Tax:  decimal.NewFromInt(505),
+				Due:  decimal.NewFromInt(505),
+			},
+			wantMandatory: true,
+		},
+		{
+			name: "short-term gains of the top bracket are aggregated",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(2000)},
+				{BuyTimestamp: boughtLate, SellTimestamp: soldSoon, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(1500)},
+			},
+			income:      100000,
+			wantNetGain: 1500,
+			wantAutonomous: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.355"),
+				Tax:  decimal.RequireFromString("532.5"),
+				Due:  decimal.RequireFromString("532.5"),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.505"),
+				Tax:  decimal.RequireFromString("757.5"),
+				Due:  decimal.RequireFromString("757.5"),
+			},
+			wantMandatory: true,
+		},
+		{
+			name: "declared top bracket",
+			items: []internal.ReportItem{
+				{BuyTimestamp: bought, SellTimestamp: sold, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(2000)},
+				{BuyTimestamp: boughtLate, SellTimestamp: soldSoon, BuyValue: decimal.NewFromInt(1000), SellValue: decimal.NewFromInt(1500)},
+			},
+			income:      10000,
+			bracket:     9,
+			wantNetGain: 1500,
+			wantAutonomous: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.24"),
+				Tax:  decimal.NewFromInt(360),
+				Due:  decimal.NewFromInt(360),
+			},
+			wantEnglobamento: internal.TaxOption{
+				Rate: decimal.RequireFromString("0.16"),
+				Tax:  decimal.NewFromInt(240),
+				Due:  decimal.NewFromInt(240),
+			},
+			wantPrefer:    true,
+			wantMandatory: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aw := internal.NewAggregatorWriter()
+			for _, ri := range tt.items {
+				err := aw.Write(t.Context(), ri)
+				if err != nil {
+					t.Fatalf("got unexpected err: %v", err)
+				}
+			}
+
+			got, err := internal.EstimateTax(aw, decimal.NewFromFloat(tt.income), tt.bracket, 2025)
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+
+			assertDecimalEqual(t, "net gain", decimal.NewFromFloat(tt.wantNetGain), got.NetGain)
+			assertTaxOption(t, "autonomous", tt.wantAutonomous, got.Autonomous)
+			assertTaxOption(t, "englobamento", tt.wantEnglobamento, got.Englobamento)
+
+			if got.MandatoryAggregation != tt.wantMandatory {
+				t.Fatalf("want mandatory aggregation %v but got %v", tt.wantMandatory, got.MandatoryAggregation)
+			}
+
+			if got.PreferEnglobamento() != tt.wantPrefer {
+				t.Fatalf("want prefer englobamento %v but got %v", tt.wantPrefer, got.PreferEnglobamento())
+			}
+		})
+	}
+}
+
+func TestEstimateTax_UnknownYear(t *testing.T) {
+	_, err := internal.EstimateTax(internal.NewAggregatorWriter(), decimal.NewFromInt(10000), 0, 2019)
+	if !errors.Is(err, internal.ErrUnknownTaxYear) {
+		t.Fatalf("want error %v but got %v", internal.ErrUnknownTaxYear, err)
+	}
+}
+
+func assertTaxOption(t *testing.T, name string, want, got internal.TaxOption) {
+	t.Helper()
+
+	assertDecimalEqual(t, name+" rate", want.Rate, got.Rate)
+	assertDecimalEqual(t, name+" tax", want.Tax, got.Tax)
+	assertDecimalEqual(t, name+" foreign tax credit", want.ForeignTaxCredit, got.ForeignTaxCredit)
+	assertDecimalEqual(t, name+" due", want.Due, got.Due)
+}
+
+func TestTaxBracketOf(t *testing.T) {
+	tests := []struct {
+		income float64
+		want   int
+	}{
+		{0, 1},
+		{8058.99, 1},
+		{8059, 2},
+		{30000, 6},
+		{83696, 9},
+		{1000000, 9},
+	}
+	for _, tt := range tests {
+		got := internal.TaxBracketOf(decimal.NewFromFloat(tt.income), internal.TaxBrackets[2025])
+		if got != tt.want {
+			t.Fatalf("want bracket %d of %v but got %d", tt.want, tt.income, got)
+		}
+	}
+}
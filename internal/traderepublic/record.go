@@ -0,0 +1,230 @@
+package traderepublic
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+type Record struct {
+	symbol    string
+	timestamp time.Time
+	side      internal.Side
+	quantity  decimal.Decimal
+	price     decimal.Decimal
+	fees      decimal.Decimal
+	taxes     decimal.Decimal
+
+	// natureGetter allows us to defer the operation of figuring out the nature to only when/if needed.
+	natureGetter func() internal.Nature
+	// line is where the record is in the statement, starting at 1.
+	line int
+}
+
+func (r Record) Symbol() string {
+	return r.symbol
+}
+
+func (r Record) Timestamp() time.Time {
+	return r.timestamp
+}
+
+func (r Record) BrokerCountry() int64 {
+	return int64(Country)
+}
+
+func (r Record) AssetCountry() int64 {
+	return int64(countries.ByName(r.Symbol()[:2]).Info().Code)
+}
+
+func (r Record) Side() internal.Side {
+	return r.side
+}
+
+func (r Record) Quantity() decimal.Decimal {
+	return r.quantity
+}
+
+func (r Record) Price() decimal.Decimal {
+	return r.price
+}
+
+func (r Record) Fees() decimal.Decimal {
+	return r.fees
+}
+
+func (r Record) Taxes() decimal.Decimal {
+	return r.taxes
+}
+
+func (r Record) Nature() internal.Nature {
+	return r.natureGetter()
+}
+
+// NatureSource is always OpenFIGI since the statement has no type of instrument.
+func (r Record) NatureSource() internal.NatureSource {
+	return internal.NatureSourceOpenFIGI
+}
+
+func (r Record) Line() int {
+	return r.line
+}
+
+// RecordReader reads the transactions CSV exported from the Trade Republic web app. Columns are
+// looked up by name from the header row so reordering or adding columns does not break parsing.
+type RecordReader struct {
+	reader  *csv.Reader
+	figi    *internal.OpenFIGI
+	columns map[string]int
+}
+
+func NewRecordReader(r io.Reader, f *internal.OpenFIGI) *RecordReader {
+	return &RecordReader{
+		reader: csv.NewReader(r),
+		figi:   f,
+	}
+}
+
+const (
+	Buy         = "buy"
+	Sell        = "sell"
+	SavingsPlan = "savings_plan"
+	RoundUp     = "round_up"
+	Saveback    = "saveback"
+)
+
+// Columns required to build a Record.
+const (
+	colTimestamp = "datetime"
+	colType      = "type"
+	colSymbol    = "symbol"
+	colShares    = "shares"
+	colPrice     = "price"
+	colFee       = "fee"
+	colTax       = "tax"
+)
+
+func (rr *RecordReader) ReadRecord(ctx context.Context) (internal.Record, error) {
+	if rr.columns == nil {
+		err := rr.readHeader()
+		if err != nil {
+			return Record{}, err
+		}
+	}
+
+	for {
+		raw, err := rr.reader.Read()
+		if err != nil {
+			return Record{}, fmt.Errorf("read record: %w", err)
+		}
+
+		var side internal.Side
+		switch strings.ToLower(rr.field(raw, colType)) {
+		case Buy, SavingsPlan, RoundUp, Saveback:
+			// Savings plans, round ups and saveback are all executed as regular buy orders and the
+			// acquired shares are indistinguishable from the ones bought manually.
+			side = internal.SideBuy
+		case Sell:
+			side = internal.SideSell
+		default:
+			// dividends, interest, deposits, card transactions, etc.
+			continue
+		}
+
+		qant, err := parseDecimal(rr.field(raw, colShares))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record quantity: %w", err)
+		}
+
+		price, err := parseDecimal(rr.field(raw, colPrice))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record price: %w", err)
+		}
+
+		ts, err := time.Parse(time.RFC3339, rr.field(raw, colTimestamp))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record timestamp: %w", err)
+		}
+
+		fee, err := parseOptionalDecimal(rr.field(raw, colFee))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record fee: %w", err)
+		}
+
+		tax, err := parseOptionalDecimal(rr.field(raw, colTax))
+		if err != nil {
+			return Record{}, fmt.Errorf("parse record tax: %w", err)
+		}
+
+		symbol := rr.field(raw, colSymbol)
+
+		// Trade Republic reports the quantity of sells and the costs as negative cash movements but
+		// internally we always deal with absolute values.
+		return Record{
+			symbol:       symbol,
+			side:         side,
+			quantity:     qant.Abs(),
+			price:        price.Abs(),
+			fees:         fee.Abs(),
+			taxes:        tax.Abs(),
+			timestamp:    ts,
+			natureGetter: rr.figi.NatureGetter(ctx, symbol),
+			line:         rr.line(),
+		}, nil
+	}
+}
+
+// line returns the line of the last record read from the statement.
+func (rr *RecordReader) line() int {
+	line, _ := rr.reader.FieldPos(0)
+	return line
+}
+
+func (rr *RecordReader) readHeader() error {
+	header, err := rr.reader.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{colTimestamp, colType, colSymbol, colShares, colPrice, colFee, colTax} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing column in header: %s", name)
+		}
+	}
+
+	rr.columns = columns
+
+	return nil
+}
+
+func (rr *RecordReader) field(raw []string, name string) string {
+	return raw[rr.columns[name]]
+}
+
+// parseDecimal attempts to parse a string using a standard precision and rounding mode.
+// Using this function helps avoid issues around converting values due to minor parameter changes.
+func parseDecimal(s string) (decimal.Decimal, error) {
+	return decimal.NewFromString(s)
+}
+
+// parseOptionalDecimal behaves the same as parseDecimal but returns 0 when len(s) is 0 instead of
+// error.
+func parseOptionalDecimal(s string) (decimal.Decimal, error) {
+	if len(s) == 0 {
+		return decimal.Decimal{}, nil
+	}
+
+	return parseDecimal(s)
+}
@@ -0,0 +1,190 @@
+package traderepublic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+const header = "datetime,date,account_type,category,type,asset_class,name,symbol,shares,price,amount,fee,tax,currency\n"
+
+func TestRecordReader_ReadRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       io.Reader
+		want    Record
+		wantErr bool
+	}{
+		{
+			name:    "empty reader",
+			r:       bytes.NewBufferString(""),
+			wantErr: true,
+		},
+		{
+			name:    "missing columns",
+			r:       bytes.NewBufferString("datetime,type,symbol\n2025-07-03T10:44:29Z,BUY,XX1234567890\n"),
+			wantErr: true,
+		},
+		{
+			name: "well-formed buy",
+			r:    bytes.NewBufferString(header + `2025-07-03T10:44:29Z,2025-07-03,DEFAULT,TRADING,BUY,STOCK,Aspargus Broccoli,XX1234567890,2.4387014200,7.369,-17.97,-1.00,,EUR`),
+			want: Record{
+				symbol:       "XX1234567890",
+				side:         internal.SideBuy,
+				quantity:     ShouldParseDecimal(t, "2.4387014200"),
+				price:        ShouldParseDecimal(t, "7.369"),
+				timestamp:    time.Date(2025, 7, 3, 10, 44, 29, 0, time.UTC),
+				fees:         ShouldParseDecimal(t, "1.00"),
+				taxes:        decimal.Decimal{},
+				natureGetter: func() internal.Nature { return internal.NatureG01 },
+			},
+		},
+		{
+			name: "savings plan is a buy",
+			r:    bytes.NewBufferString(header + `2025-07-03T10:44:29Z,2025-07-03,DEFAULT,TRADING,SAVINGS_PLAN,FUND,Aspargus Broccoli,XX1234567890,0.5,50,-25,,,EUR`),
+			want: Record{
+				symbol:       "XX1234567890",
+				side:         internal.SideBuy,
+				quantity:     ShouldParseDecimal(t, "0.5"),
+				price:        ShouldParseDecimal(t, "50"),
+				timestamp:    time.Date(2025, 7, 3, 10, 44, 29, 0, time.UTC),
+				fees:         decimal.Decimal{},
+				taxes:        decimal.Decimal{},
+				natureGetter: func() internal.Nature { return internal.NatureG01 },
+			},
+		},
+		{
+			name: "well-formed sell skips other transactions",
+			r: bytes.NewBufferString(header +
+				"2025-08-01T09:00:00Z,2025-08-01,DEFAULT,CASH,DEPOSIT,,,,,,100,,,EUR\n" +
+				"2025-08-04T11:45:30Z,2025-08-04,DEFAULT,TRADING,SELL,STOCK,Aspargus Broccoli,XX1234567890,-2.4387014200,7.9999999999,18.51,-1.00,-0.1,EUR\n"),
+			want: Record{
+				symbol:       "XX1234567890",
+				side:         internal.SideSell,
+				quantity:     ShouldParseDecimal(t, "2.4387014200"),
+				price:        ShouldParseDecimal(t, "7.9999999999"),
+				timestamp:    time.Date(2025, 8, 4, 11, 45, 30, 0, time.UTC),
+				fees:         ShouldParseDecimal(t, "1.00"),
+				taxes:        ShouldParseDecimal(t, "0.1"),
+				natureGetter: func() internal.Nature { return internal.NatureG01 },
+			},
+		},
+		{
+			name:    "malformed quantity",
+			r:       bytes.NewBufferString(header + `2025-07-03T10:44:29Z,2025-07-03,DEFAULT,TRADING,BUY,STOCK,Aspargus Broccoli,XX1234567890,0x1234,7.369,-17.97,-1.00,,EUR`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed price",
+			r:       bytes.NewBufferString(header + `2025-07-03T10:44:29Z,2025-07-03,DEFAULT,TRADING,BUY,STOCK,Aspargus Broccoli,XX1234567890,2.4387014200,,-17.97,-1.00,,EUR`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed fee",
+			r:       bytes.NewBufferString(header + `2025-07-03T10:44:29Z,2025-07-03,DEFAULT,TRADING,BUY,STOCK,Aspargus Broccoli,XX1234567890,2.4387014200,7.369,-17.97,BAD,,EUR`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed tax",
+			r:       bytes.NewBufferString(header + `2025-07-03T10:44:29Z,2025-07-03,DEFAULT,TRADING,BUY,STOCK,Aspargus Broccoli,XX1234567890,2.4387014200,7.369,-17.97,-1.00,BAD,EUR`),
+			wantErr: true,
+		},
+		{
+			name:    "malformed timestamp",
+			r:       bytes.NewBufferString(header + `2025-07-03 10:44:29,2025-07-03,DEFAULT,TRADING,BUY,STOCK,Aspargus Broccoli,XX1234567890,2.4387014200,7.369,-17.97,-1.00,,EUR`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := NewRecordReader(tt.r, NewFigiClientSecurityTypeStub(t, "Common Stock"))
+			got, gotErr := rr.ReadRecord(t.Context())
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Fatalf("ReadRecord() failed: %v", gotErr)
+				}
+				return
+			}
+
+			if tt.wantErr {
+				t.Fatalf("ReadRecord() expected an error")
+			}
+
+			if got.Symbol() != tt.want.symbol {
+				t.Fatalf("want symbol %v but got %v", tt.want.symbol, got.Symbol())
+			}
+
+			if got.Side() != tt.want.side {
+				t.Fatalf("want side %v but got %v", tt.want.side, got.Side())
+			}
+
+			if got.Price().Cmp(tt.want.price) != 0 {
+				t.Fatalf("want price %v but got %v", tt.want.price, got.Price())
+			}
+
+			if got.Quantity().Cmp(tt.want.quantity) != 0 {
+				t.Fatalf("want quantity %v but got %v", tt.want.quantity, got.Quantity())
+			}
+
+			if !got.Timestamp().Equal(tt.want.timestamp) {
+				t.Fatalf("want timestamp %v but got %v", tt.want.timestamp, got.Timestamp())
+			}
+
+			if got.Fees().Cmp(tt.want.fees) != 0 {
+				t.Fatalf("want fees %v but got %v", tt.want.fees, got.Fees())
+			}
+
+			if got.Taxes().Cmp(tt.want.taxes) != 0 {
+				t.Fatalf("want taxes %v but got %v", tt.want.taxes, got.Taxes())
+			}
+
+			if got.BrokerCountry() != int64(Country) {
+				t.Fatalf("want broker country %v but got %v", int64(Country), got.BrokerCountry())
+			}
+
+			if tt.want.natureGetter != nil && tt.want.Nature() != got.Nature() {
+				t.Fatalf("want nature %v but got %v", tt.want.Nature(), got.Nature())
+			}
+		})
+	}
+}
+
+func ShouldParseDecimal(t testing.TB, sf string) decimal.Decimal {
+	t.Helper()
+
+	bf, err := parseDecimal(sf)
+	if err != nil {
+		t.Fatalf("parsing decimal: %s", sf)
+	}
+	return bf
+}
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func NewFigiClientSecurityTypeStub(t testing.TB, securityType string) *internal.OpenFIGI {
+	t.Helper()
+
+	c := &http.Client{
+		Timeout: time.Second,
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"data":[{"securityType":%q}]}]`, securityType))),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	return internal.NewOpenFIGI(c)
+}
@@ -5,9 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"log/slog"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/biter777/countries"
@@ -26,6 +24,8 @@ type Record struct {
 
 	// natureGetter allows us to defer the operation of figuring out the nature to only when/if needed.
 	natureGetter func() internal.Nature
+	// line is where the record is in the statement, starting at 1.
+	line int
 }
 
 func (r Record) Symbol() string {
@@ -68,6 +68,15 @@ func (r Record) Nature() internal.Nature {
 	return r.natureGetter()
 }
 
+// NatureSource is always OpenFIGI since the statement has no type of instrument.
+func (r Record) NatureSource() internal.NatureSource {
+	return internal.NatureSourceOpenFIGI
+}
+
+func (r Record) Line() int {
+	return r.line
+}
+
 type RecordReader struct {
 	reader *csv.Reader
 	figi   *internal.OpenFIGI
@@ -144,29 +153,16 @@ func (rr RecordReader) ReadRecord(ctx context.Context) (internal.Record, error)
 			fees:         conversionFee,
 			taxes:        stampDutyTax.Add(frenchTxTax),
 			timestamp:    ts,
-			natureGetter: figiNatureGetter(ctx, rr.figi, raw[2]),
+			natureGetter: rr.figi.NatureGetter(ctx, raw[2]),
+			line:         rr.line(),
 		}, nil
 	}
 }
 
-func figiNatureGetter(ctx context.Context, of *internal.OpenFIGI, isin string) func() internal.Nature {
-	return sync.OnceValue(func() internal.Nature {
-		secType, err := of.SecurityTypeByISIN(ctx, isin)
-		if err != nil {
-			slog.Error("failed to get security type by ISIN", slog.Any("err", err), slog.String("isin", isin))
-			return internal.NatureUnknown
-		}
-
-		switch secType {
-		case "Common Stock":
-			return internal.NatureG01
-		case "ETP":
-			return internal.NatureG20
-		default:
-			slog.Error("got unsupported security type for ISIN", slog.String("isin", isin), slog.String("securityType", secType))
-			return internal.NatureUnknown
-		}
-	})
+// line returns the line of the last record read from the statement.
+func (rr RecordReader) line() int {
+	line, _ := rr.reader.FieldPos(0)
+	return line
 }
 
 // parseFloat attempts to parse a string using a standard precision and rounding mode.
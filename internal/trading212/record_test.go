@@ -37,6 +37,7 @@ func TestRecordReader_ReadRecord(t *testing.T) {
 				fees:         ShouldParseDecimal(t, "0.02"),
 				taxes:        ShouldParseDecimal(t, "0.25"),
 				natureGetter: func() internal.Nature { return internal.NatureG01 },
+				line:         1,
 			},
 		},
 		{
@@ -51,6 +52,7 @@ func TestRecordReader_ReadRecord(t *testing.T) {
 				fees:         ShouldParseDecimal(t, "0.02"),
 				taxes:        ShouldParseDecimal(t, "0.1"),
 				natureGetter: func() internal.Nature { return internal.NatureG01 },
+				line:         1,
 			},
 		},
 		{
@@ -147,46 +149,12 @@ func TestRecordReader_ReadRecord(t *testing.T) {
 				t.Fatalf("want taxes %v but got %v", tt.want.taxes, got.Taxes())
 			}
 
-			if tt.want.natureGetter != nil && tt.want.Nature() != got.Nature() {
-				t.Fatalf("want nature %v but got %v", tt.want.Nature(), got.Nature())
+			if internal.LineOf(got) != tt.want.line {
+				t.Fatalf("want line %v but got %v", tt.want.line, internal.LineOf(got))
 			}
-		})
-	}
-}
 
-func Test_figiNatureGetter(t *testing.T) {
-	tests := []struct {
-		name string // description of this test case
-		of   *internal.OpenFIGI
-		want internal.Nature
-	}{
-		{
-			name: "Common Stock translates to G01",
-			of:   NewFigiClientSecurityTypeStub(t, "Common Stock"),
-			want: internal.NatureG01,
-		},
-		{
-			name: "ETP translates to G20",
-			of:   NewFigiClientSecurityTypeStub(t, "ETP"),
-			want: internal.NatureG20,
-		},
-		{
-			name: "Other translates to Unknown",
-			of:   NewFigiClientSecurityTypeStub(t, "Other"),
-			want: internal.NatureUnknown,
-		},
-		{
-			name: "Request fails",
-			of:   NewFigiClientErrorStub(t, fmt.Errorf("boom")),
-			want: internal.NatureUnknown,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			getter := figiNatureGetter(t.Context(), tt.of, "IR1234567890")
-			got := getter()
-			if tt.want != got {
-				t.Errorf("want %v but got %v", tt.want, got)
+			if tt.want.natureGetter != nil && tt.want.Nature() != got.Nature() {
+				t.Fatalf("want nature %v but got %v", tt.want.Nature(), got.Nature())
 			}
 		})
 	}
@@ -225,16 +193,3 @@ func NewFigiClientSecurityTypeStub(t testing.TB, securityType string) *internal.
 
 	return internal.NewOpenFIGI(c)
 }
-
-func NewFigiClientErrorStub(t testing.TB, err error) *internal.OpenFIGI {
-	t.Helper()
-
-	c := &http.Client{
-		Timeout: time.Second,
-		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
-			return nil, err
-		}),
-	}
-
-	return internal.NewOpenFIGI(c)
-}
@@ -0,0 +1,46 @@
+package internal
+
+import "fmt"
+
+// Warning is something in the report that should be reviewed before filling the declaration.
+type Warning struct {
+	// Row is the position of the item in the report, starting at 1, or 0 for the whole report.
+	Row     int
+	Code    string
+	Message string
+}
+
+const (
+	WarningUnknownNature         = "unknown_nature"
+	WarningUnknownSourceCountry  = "unknown_source_country"
+	WarningUnknownCounterCountry = "unknown_counter_country"
+	WarningAccruedInterest       = "accrued_interest"
+)
+
+// Warnings returns the warnings of the items in aw in the order of the rows.
+func Warnings(aw *AggregatorWriter) []Warning {
+	var warnings []Warning
+
+	row := 0
+	for ri := range aw.Iter() {
+		row++
+
+		if ri.Nature == NatureUnknown {
+			warnings = append(warnings, Warning{row, WarningUnknownNature, fmt.Sprintf("unknown nature of %s", ri.Symbol)})
+		}
+
+		if ri.AssetCountry == 0 {
+			warnings = append(warnings, Warning{row, WarningUnknownSourceCountry, fmt.Sprintf("unknown source country of %s", ri.Symbol)})
+		}
+
+		if ri.BrokerCountry == 0 {
+			warnings = append(warnings, Warning{row, WarningUnknownCounterCountry, fmt.Sprintf("unknown counter country of %s", ri.Symbol)})
+		}
+	}
+
+	if !aw.TotalAccruedInterest().IsZero() {
+		warnings = append(warnings, Warning{0, WarningAccruedInterest, fmt.Sprintf("%s € of accrued interest of bonds must be declared as income in quadro 8", aw.TotalAccruedInterest().StringFixed(2))})
+	}
+
+	return warnings
+}
@@ -0,0 +1,35 @@
+package internal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+func TestWarnings(t *testing.T) {
+	aw := internal.NewAggregatorWriter()
+
+	items := []internal.ReportItem{
+		{Symbol: "OK", Nature: internal.NatureG01, AssetCountry: 840, BrokerCountry: 826},
+		{Symbol: "UNKNOWN", AssetCountry: 840},
+		{Symbol: "BOND", Nature: internal.NatureG10, AssetCountry: 276, BrokerCountry: 826, AccruedInterest: decimal.NewFromInt(10)},
+	}
+	for _, ri := range items {
+		err := aw.Write(t.Context(), ri)
+		if err != nil {
+			t.Fatalf("got unexpected err: %v", err)
+		}
+	}
+
+	var got []string
+	for _, w := range internal.Warnings(aw) {
+		got = append(got, w.Code)
+	}
+
+	want := []string{internal.WarningUnknownNature, internal.WarningUnknownCounterCountry, internal.WarningAccruedInterest}
+	if !slices.Equal(got, want) {
+		t.Fatalf("want warnings %v but got %v", want, got)
+	}
+}
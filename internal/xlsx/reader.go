@@ -0,0 +1,309 @@
+// Package xlsx implements just enough of the Office Open XML spreadsheet format to read the
+// statements some brokers export as workbooks and to write reports. Only cell values are read;
+// styles, formulas and everything else are ignored. Only a few number formats are written.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Workbook gives access to the cell values of each sheet in a xlsx file.
+type Workbook struct {
+	zr            *zip.Reader
+	sheetNames    []string
+	sheetPaths    map[string]string
+	sharedStrings []string
+}
+
+// NewWorkbook reads the workbook metadata. Sheets are only parsed when Rows is called.
+func NewWorkbook(r io.ReaderAt, size int64) (*Workbook, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open zip archive: %w", err)
+	}
+
+	wb := &Workbook{
+		zr:         zr,
+		sheetPaths: make(map[string]string),
+	}
+
+	var rels relationships
+	err = wb.decode("xl/_rels/workbook.xml.rels", &rels)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	var wbXML workbook
+	err = wb.decode("xl/workbook.xml", &wbXML)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range wbXML.Sheets {
+		target, ok := targets[s.RelID]
+		if !ok {
+			return nil, fmt.Errorf("missing relationship %q for sheet %q", s.RelID, s.Name)
+		}
+		wb.sheetNames = append(wb.sheetNames, s.Name)
+		wb.sheetPaths[strings.ToLower(s.Name)] = target
+	}
+
+	// Workbooks with only numeric cells are allowed to omit the shared strings table.
+	var sst sharedStrings
+	err = wb.decode("xl/sharedStrings.xml", &sst)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, si := range sst.Items {
+		wb.sharedStrings = append(wb.sharedStrings, si.text())
+	}
+
+	return wb, nil
+}
+
+// SheetNames returns the names of all sheets in the order they appear in the workbook.
+func (wb *Workbook) SheetNames() []string {
+	return wb.sheetNames
+}
+
+// Rows returns the values of all cells in the sheet with the given name (case insensitive). Empty
+// cells are returned as empty strings so that the index of a value always matches its column.
+// Numbers, including dates, are returned in their raw form.
+func (wb *Workbook) Rows(sheet string) ([][]string, error) {
+	p, ok := wb.sheetPaths[strings.ToLower(sheet)]
+	if !ok {
+		return nil, fmt.Errorf("sheet not found: %s", sheet)
+	}
+
+	var ws worksheet
+	err := wb.decode(p, &ws)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range ws.Rows {
+		// Rows might be omitted if empty but we want to preserve the row numbers.
+		for row.Num > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.Ref != "" {
+				col, err = columnIndex(c.Ref)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			for col > len(cells) {
+				cells = append(cells, "")
+			}
+
+			val, err := wb.cellValue(c)
+			if err != nil {
+				return nil, fmt.Errorf("cell %s: %w", c.Ref, err)
+			}
+
+			cells = append(cells, val)
+		}
+
+		rows = append(rows, cells)
+	}
+
+	return rows, nil
+}
+
+func (wb *Workbook) cellValue(c cell) (string, error) {
+	switch c.Type {
+	case "s":
+		idx, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return "", fmt.Errorf("parse shared string index: %w", err)
+		}
+		if idx < 0 || idx >= len(wb.sharedStrings) {
+			return "", fmt.Errorf("shared string index out of range: %d", idx)
+		}
+		return wb.sharedStrings[idx], nil
+	case "inlineStr":
+		return c.Inline.text(), nil
+	default:
+		return c.Value, nil
+	}
+}
+
+func (wb *Workbook) decode(name string, v any) error {
+	f, err := wb.zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	err = xml.NewDecoder(f).Decode(v)
+	if err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// columnIndex converts a cell reference (i.e.: AB12) into a zero based column index (i.e.: 27).
+func columnIndex(ref string) (int, error) {
+	var idx int
+	var i int
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		idx = idx*26 + int(ref[i]-'A'+1)
+	}
+
+	if i == 0 {
+		return 0, fmt.Errorf("invalid cell reference: %s", ref)
+	}
+
+	return idx - 1, nil
+}
+
+// excelEpoch is the zero value for date serial numbers. It is not 1900-01-01 because Excel
+// incorrectly treats 1900 as a leap year.
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// ParseSerialTime converts a date serial number, the way spreadsheets store dates and times, into
+// a time in UTC rounded to the second.
+func ParseSerialTime(s string) (time.Time, error) {
+	serial, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse date serial number: %w", err)
+	}
+
+	seconds := math.Round(serial * 24 * 60 * 60)
+
+	return excelEpoch.Add(time.Duration(seconds) * time.Second), nil
+}
+
+type relationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type workbook struct {
+	Sheets []struct {
+		Name  string `xml:"name,attr"`
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type sharedStrings struct {
+	Items []richText `xml:"si"`
+}
+
+// richText is either a plain text element or a list of runs, each with its own formatting.
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt richText) text() string {
+	if len(rt.Runs) == 0 {
+		return rt.Text
+	}
+
+	var sb strings.Builder
+	for _, r := range rt.Runs {
+		sb.WriteString(r.Text)
+	}
+	return sb.String()
+}
+
+type worksheet struct {
+	Rows []struct {
+		Num   int    `xml:"r,attr"`
+		Cells []cell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type cell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline richText `xml:"is"`
+}
+
+// ReadWorkbook is a convenience wrapper around NewWorkbook for when the workbook comes from a
+// stream. The whole stream is buffered in memory since the zip format requires random access.
+func ReadWorkbook(r io.Reader) (*Workbook, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read workbook: %w", err)
+	}
+
+	return NewWorkbook(bytes.NewReader(data), int64(len(data)))
+}
+
+// Header maps each column name (lower case) to its index.
+type Header map[string]int
+
+// FindHeader returns the index of the first row that contains all the required column names (case
+// insensitive) alongside its Header. Statements often have a few rows of account details before
+// the actual table which is why the header is not assumed to be the first row.
+func FindHeader(rows [][]string, required ...string) (int, Header, error) {
+	for i, row := range rows {
+		header := make(Header, len(row))
+		for j, name := range row {
+			header[strings.ToLower(strings.TrimSpace(name))] = j
+		}
+
+		if header.Has(required...) {
+			return i, header, nil
+		}
+	}
+
+	return 0, nil, fmt.Errorf("header not found: %s", strings.Join(required, ", "))
+}
+
+// Has returns true if all names are columns of the header.
+func (h Header) Has(names ...string) bool {
+	for _, name := range names {
+		if _, ok := h[strings.ToLower(name)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the value of the named column in row. Returns an empty string if the column does not
+// exist or the row is too short.
+func (h Header) Get(row []string, name string) string {
+	idx, ok := h[strings.ToLower(name)]
+	if !ok || idx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[idx])
+}
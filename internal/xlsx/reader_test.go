@@ -0,0 +1,155 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWorkbook_Rows(t *testing.T) {
+	data := NewTestWorkbook(t, map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Closed Positions" sheetId="2" r:id="rId2"/></sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>Symbol</t></si><si><r><t>Open </t></r><r><t>time</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>AAPL.US</t></is></c><c r="B3"><v>1.5</v></c><c r="C3"><v>45292.5</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	wb, err := NewWorkbook(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("want success but failed: %v", err)
+	}
+
+	if got, want := wb.SheetNames(), []string{"Summary", "Closed Positions"}; !slices.Equal(got, want) {
+		t.Fatalf("want sheet names %v but got %v", want, got)
+	}
+
+	rows, err := wb.Rows("closed positions")
+	if err != nil {
+		t.Fatalf("want success but failed: %v", err)
+	}
+
+	want := [][]string{
+		{"Symbol", "", "Open time"},
+		nil,
+		{"AAPL.US", "1.5", "45292.5"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("want %d rows but got %d: %q", len(want), len(rows), rows)
+	}
+	for i := range want {
+		if !slices.Equal(want[i], rows[i]) {
+			t.Fatalf("want row %d to be %q but got %q", i+1, want[i], rows[i])
+		}
+	}
+
+	_, err = wb.Rows("missing")
+	if err == nil {
+		t.Fatalf("want error for missing sheet")
+	}
+}
+
+func TestNewWorkbook_NotZip(t *testing.T) {
+	data := []byte("not a zip file")
+	_, err := NewWorkbook(bytes.NewReader(data), int64(len(data)))
+	if err == nil {
+		t.Fatalf("want error but got nil")
+	}
+}
+
+func TestParseSerialTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		serial  string
+		want    time.Time
+		wantErr bool
+	}{
+		{"date only", "45292", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"date and time", "45292.5", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), false},
+		{"rounds to seconds", "45292.000011574", time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC), false},
+		{"not a number", "01/01/2024", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSerialTime(tt.serial)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error to be %v but got %v", tt.wantErr, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("want %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func NewTestWorkbook(t testing.TB, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		_, err = w.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("close zip writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFindHeader(t *testing.T) {
+	rows := [][]string{
+		{"Account", "12345"},
+		nil,
+		{"Position", " Symbol ", "Open Time"},
+		{"1", "AAPL.US"},
+	}
+
+	idx, header, err := FindHeader(rows, "symbol", "open time")
+	if err != nil {
+		t.Fatalf("want success but failed: %v", err)
+	}
+
+	if idx != 2 {
+		t.Fatalf("want header at row index 2 but got %d", idx)
+	}
+
+	if got := header.Get(rows[3], "Symbol"); got != "AAPL.US" {
+		t.Fatalf("want symbol %q but got %q", "AAPL.US", got)
+	}
+
+	if got := header.Get(rows[3], "open time"); got != "" {
+		t.Fatalf("want empty value for short row but got %q", got)
+	}
+
+	if got := header.Get(rows[3], "missing"); got != "" {
+		t.Fatalf("want empty value for missing column but got %q", got)
+	}
+
+	_, _, err = FindHeader(rows, "close time")
+	if err == nil {
+		t.Fatalf("want error for missing header")
+	}
+}
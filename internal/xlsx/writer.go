@@ -0,0 +1,249 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Style is the format of a cell. Only the handful of styles needed to produce readable reports are
+// supported and they map, in this order, to the cellXfs of the styles part.
+type Style int
+
+const (
+	StyleDefault Style = iota
+	StyleEuro
+	StyleDate
+	StyleBold
+	StyleBoldEuro
+)
+
+// Cell is a value to be written with Writer. Use the constructors to build one.
+type Cell struct {
+	value  string
+	number bool
+	style  Style
+}
+
+// Text returns a cell with a string value.
+func Text(s string) Cell {
+	return Cell{value: s}
+}
+
+// Integer returns a cell with a whole number.
+func Integer(n int64) Cell {
+	return Cell{value: strconv.FormatInt(n, 10), number: true}
+}
+
+// Euro returns a cell with the value, rounded to the cent, formatted as an amount in Euros.
+func Euro(d decimal.Decimal) Cell {
+	return Cell{value: d.StringFixed(2), number: true, style: StyleEuro}
+}
+
+// Date returns a cell with the date, without the time of day, of t in its own location.
+func Date(t time.Time) Cell {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	serial := int64(day.Sub(excelEpoch) / (24 * time.Hour))
+	return Cell{value: strconv.FormatInt(serial, 10), number: true, style: StyleDate}
+}
+
+// Bold returns a copy of the cell in bold. Only text and Euro cells have a bold style.
+func (c Cell) Bold() Cell {
+	switch c.style {
+	case StyleEuro:
+		c.style = StyleBoldEuro
+	case StyleDefault:
+		c.style = StyleBold
+	}
+	return c
+}
+
+// Sheet is a list of rows added to a Writer.
+type Sheet struct {
+	name string
+	rows [][]Cell
+}
+
+// AppendRow adds a row after the last one. A nil row is left empty.
+func (s *Sheet) AppendRow(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+// Writer builds a workbook in memory and writes it on Close since the zip format requires each part
+// to be complete before the next one starts.
+type Writer struct {
+	w      io.Writer
+	sheets []*Sheet
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: w,
+	}
+}
+
+// AddSheet adds a sheet after the last one. Names must be unique, up to 31 characters long and
+// can't have any of []:*?/\ as required by spreadsheet applications.
+func (wr *Writer) AddSheet(name string) *Sheet {
+	s := &Sheet{name: name}
+	wr.sheets = append(wr.sheets, s)
+	return s
+}
+
+// Close writes the workbook. The Writer must not be used afterwards.
+func (wr *Writer) Close() error {
+	if len(wr.sheets) == 0 {
+		return fmt.Errorf("workbook without sheets")
+	}
+
+	zw := zip.NewWriter(wr.w)
+
+	parts := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", wr.contentTypes()},
+		{"_rels/.rels", []byte(xml.Header + rootRels)},
+		{"xl/workbook.xml", wr.workbook()},
+		{"xl/_rels/workbook.xml.rels", wr.workbookRels()},
+		{"xl/styles.xml", []byte(xml.Header + styles)},
+	}
+
+	for i, s := range wr.sheets {
+		parts = append(parts, struct {
+			name    string
+			content []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), s.xml()})
+	}
+
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", p.name, err)
+		}
+
+		_, err = f.Write(p.content)
+		if err != nil {
+			return fmt.Errorf("write %s: %w", p.name, err)
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		return fmt.Errorf("close zip archive: %w", err)
+	}
+
+	return nil
+}
+
+func (wr *Writer) contentTypes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	buf.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	buf.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wr.sheets {
+		fmt.Fprintf(&buf, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	buf.WriteString(`</Types>`)
+	return buf.Bytes()
+}
+
+func (wr *Writer) workbook() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range wr.sheets {
+		fmt.Fprintf(&buf, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+	}
+	buf.WriteString(`</sheets></workbook>`)
+	return buf.Bytes()
+}
+
+func (wr *Writer) workbookRels() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wr.sheets {
+		fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	// The styles are the last relationship so that the sheets ids match their position.
+	fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wr.sheets)+1)
+	buf.WriteString(`</Relationships>`)
+	return buf.Bytes()
+}
+
+func (s *Sheet) xml() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetFormatPr defaultColWidth="18" defaultRowHeight="15"/><sheetData>`)
+	for i, row := range s.rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, i+1)
+		for j, c := range row {
+			ref := columnName(j) + strconv.Itoa(i+1)
+			style := ""
+			if c.style != StyleDefault {
+				style = fmt.Sprintf(` s="%d"`, c.style)
+			}
+
+			if c.number {
+				fmt.Fprintf(&buf, `<c r="%s"%s><v>%s</v></c>`, ref, style, c.value)
+			} else {
+				fmt.Fprintf(&buf, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(c.value))
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.Bytes()
+}
+
+// columnName converts a zero based column index (i.e.: 27) into the letters of a cell reference
+// (i.e.: AB). It's the inverse of columnIndex.
+func columnName(idx int) string {
+	var name []byte
+	for idx++; idx > 0; idx = (idx - 1) / 26 {
+		name = append([]byte{byte('A' + (idx-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+func escape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+const rootRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles has the number formats and the cellXfs in the same order as the Style constants.
+const styles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2">` +
+	`<numFmt numFmtId="164" formatCode="#,##0.00\ &quot;€&quot;"/>` +
+	`<numFmt numFmtId="165" formatCode="yyyy\-mm\-dd"/>` +
+	`</numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,106 @@
+package xlsx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	first := w.AddSheet("J quadro 9")
+	first.AppendRow(Text("Símbolo").Bold(), Text("Valor <€> & outros").Bold(), Text("Data").Bold())
+	first.AppendRow(Text("AAPL"), Euro(decimal.RequireFromString("150.755")), Date(time.Date(2023, 6, 20, 23, 59, 0, 0, time.UTC)))
+	first.AppendRow()
+	first.AppendRow(Text("Soma").Bold(), Euro(decimal.RequireFromString("150.76")).Bold(), Integer(-3))
+
+	w.AddSheet("Empty")
+
+	err := w.Close()
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	wb, err := NewWorkbook(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read written workbook: %v", err)
+	}
+
+	if got, want := wb.SheetNames(), []string{"J quadro 9", "Empty"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want sheet names %v but got %v", want, got)
+	}
+
+	rows, err := wb.Rows("J quadro 9")
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+
+	want := [][]string{
+		{"Símbolo", "Valor <€> & outros", "Data"},
+		{"AAPL", "150.76", "45097"},
+		nil,
+		{"Soma", "150.76", "-3"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("want rows %q but got %q", want, rows)
+	}
+
+	date, err := ParseSerialTime(rows[1][2])
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+	if want := time.Date(2023, 6, 20, 0, 0, 0, 0, time.UTC); !date.Equal(want) {
+		t.Errorf("want date %v but got %v", want, date)
+	}
+
+	rows, err = wb.Rows("Empty")
+	if err != nil {
+		t.Fatalf("got unexpected err: %v", err)
+	}
+	if len(rows) != 0 {
+		t.Errorf("want no rows but got %q", rows)
+	}
+}
+
+func TestWriter_NoSheets(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewWriter(&buf).Close()
+	if err == nil {
+		t.Fatal("want error but got nil")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		idx  int
+		want string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := columnName(tt.idx)
+			if got != tt.want {
+				t.Errorf("want %s but got %s", tt.want, got)
+			}
+
+			idx, err := columnIndex(got + "1")
+			if err != nil {
+				t.Fatalf("got unexpected err: %v", err)
+			}
+			if idx != tt.idx {
+				t.Errorf("want index %d but got %d", tt.idx, idx)
+			}
+		})
+	}
+}
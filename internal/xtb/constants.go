@@ -0,0 +1,53 @@
+package xtb
+
+import (
+	"slices"
+
+	"github.com/biter777/countries"
+)
+
+const Country = countries.Poland
+
+// euro is the currency of the markets whose prices need no conversion.
+const euro = "EUR"
+
+// market describes the exchange suffix XTB appends to every symbol (i.e.: the US in AAPL.US).
+type market struct {
+	country countries.CountryCode
+	// exchCode is the OpenFIGI exchange code used to resolve the security type by ticker.
+	exchCode string
+	// currency is the ISO 4217 code of the currency of the prices in the market.
+	currency string
+}
+
+var markets = map[string]market{
+	"BE": {countries.Belgium, "BB", euro},
+	"CH": {countries.Switzerland, "SW", "CHF"},
+	"CZ": {countries.CzechRepublic, "CP", "CZK"},
+	"DE": {countries.Germany, "GY", euro},
+	"DK": {countries.Denmark, "DC", "DKK"},
+	"ES": {countries.Spain, "SM", euro},
+	"FI": {countries.Finland, "FH", euro},
+	"FR": {countries.France, "FP", euro},
+	"IT": {countries.Italy, "IM", euro},
+	"NL": {countries.Netherlands, "NA", euro},
+	"NO": {countries.Norway, "NO", "NOK"},
+	"PL": {countries.Poland, "PW", "PLN"},
+	"PT": {countries.Portugal, "PL", euro},
+	"SE": {countries.Sweden, "SS", "SEK"},
+	"UK": {countries.UnitedKingdom, "LN", "GBP"},
+	"US": {countries.USA, "US", "USD"},
+}
+
+// Currencies returns the currencies of the markets other than EUR, sorted, whose prices must be
+// converted with exchange rates.
+func Currencies() []string {
+	var currencies []string
+	for _, mkt := range markets {
+		if mkt.currency != euro && !slices.Contains(currencies, mkt.currency) {
+			currencies = append(currencies, mkt.currency)
+		}
+	}
+	slices.Sort(currencies)
+	return currencies
+}
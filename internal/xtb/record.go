@@ -0,0 +1,366 @@
+package xtb
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/nmoniz/any2anexoj/internal/xlsx"
+	"github.com/shopspring/decimal"
+)
+
+type Record struct {
+	symbol       string
+	timestamp    time.Time
+	side         internal.Side
+	quantity     decimal.Decimal
+	price        decimal.Decimal
+	fees         decimal.Decimal
+	assetCountry int64
+	// currency is the one of the market and exchangeRate the units of it per EUR used to convert
+	// the price. Both are empty for markets in EUR.
+	currency     string
+	exchangeRate decimal.Decimal
+
+	// natureGetter allows us to defer the operation of figuring out the nature to only when/if needed.
+	natureGetter func() internal.Nature
+	// line is where the record is in the statement, starting at 1.
+	line int
+}
+
+func (r Record) Symbol() string {
+	return r.symbol
+}
+
+func (r Record) Timestamp() time.Time {
+	return r.timestamp
+}
+
+func (r Record) BrokerCountry() int64 {
+	return int64(Country)
+}
+
+// AssetCountry is the country of the market where the security was traded since XTB statements do
+// not include the ISIN.
+func (r Record) AssetCountry() int64 {
+	return r.assetCountry
+}
+
+func (r Record) Side() internal.Side {
+	return r.side
+}
+
+func (r Record) Quantity() decimal.Decimal {
+	return r.quantity
+}
+
+func (r Record) Price() decimal.Decimal {
+	return r.price
+}
+
+func (r Record) Fees() decimal.Decimal {
+	return r.fees
+}
+
+func (r Record) Taxes() decimal.Decimal {
+	return decimal.Decimal{}
+}
+
+func (r Record) Nature() internal.Nature {
+	return r.natureGetter()
+}
+
+// NatureSource is always OpenFIGI since the statement has no type of instrument.
+func (r Record) NatureSource() internal.NatureSource {
+	return internal.NatureSourceOpenFIGI
+}
+
+// Currency is the currency of the prices in the market of the security, or empty if it's EUR.
+func (r Record) Currency() string {
+	return r.currency
+}
+
+func (r Record) ExchangeRate() decimal.Decimal {
+	return r.exchangeRate
+}
+
+func (r Record) Line() int {
+	return r.line
+}
+
+// ClosedPosition is a sell Record that carries the opening leg of the position as matched by XTB.
+type ClosedPosition struct {
+	Record
+
+	openPrice     decimal.Decimal
+	openTimestamp time.Time
+	// openExchangeRate is the rate used to convert the open price.
+	openExchangeRate decimal.Decimal
+}
+
+func (cp ClosedPosition) OpenPrice() decimal.Decimal {
+	return cp.openPrice
+}
+
+func (cp ClosedPosition) OpenTimestamp() time.Time {
+	return cp.openTimestamp
+}
+
+// RecordReader reads the closed positions from the XTB account statement workbook. Prices are in
+// the currency of the market and, unless it's EUR, are converted with the rate of the day of each
+// leg. The commission is in the currency of the account which must be EUR.
+type RecordReader struct {
+	reader  io.Reader
+	figi    *internal.OpenFIGI
+	matched bool
+	// rates by currency.
+	rates map[string]internal.ExchangeRates
+
+	records []internal.Record
+	loaded  bool
+}
+
+// NewRecordReader returns a reader for XTB statements. When matched is true each closed position
+// is returned as a single ClosedPosition, otherwise the position is split into a buy and a sell
+// Record and these are returned in chronological order so the matching can be redone. The prices
+// of markets not in EUR are converted with the rates of their currency, see Currencies.
+func NewRecordReader(r io.Reader, f *internal.OpenFIGI, matched bool, rates map[string]internal.ExchangeRates) *RecordReader {
+	return &RecordReader{
+		reader:  r,
+		figi:    f,
+		matched: matched,
+		rates:   rates,
+	}
+}
+
+const ClosedPositionsSheet = "closed position history"
+
+const (
+	TypeBuy  = "buy"
+	TypeSell = "sell"
+)
+
+// Columns of the closed positions table.
+const (
+	colSymbol     = "symbol"
+	colType       = "type"
+	colVolume     = "volume"
+	colOpenTime   = "open time"
+	colOpenPrice  = "open price"
+	colCloseTime  = "close time"
+	colClosePrice = "close price"
+	colCommission = "commission"
+)
+
+// timeLayout is used when the statement has dates formatted as text instead of date cells.
+const timeLayout = "02.01.2006 15:04:05"
+
+func (rr *RecordReader) ReadRecord(ctx context.Context) (internal.Record, error) {
+	if !rr.loaded {
+		err := rr.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rr.records) == 0 {
+		return nil, fmt.Errorf("read record: %w", io.EOF)
+	}
+
+	rec := rr.records[0]
+	rr.records = rr.records[1:]
+
+	return rec, nil
+}
+
+// load parses the whole sheet at once because positions are sorted by close time and, when not
+// using the broker matching, the opening legs must be interleaved in chronological order.
+func (rr *RecordReader) load(ctx context.Context) error {
+	rr.loaded = true
+
+	wb, err := xlsx.ReadWorkbook(rr.reader)
+	if err != nil {
+		return fmt.Errorf("read statement: %w", err)
+	}
+
+	sheet, ok := findSheet(wb.SheetNames())
+	if !ok {
+		return fmt.Errorf("missing sheet: %s", ClosedPositionsSheet)
+	}
+
+	rows, err := wb.Rows(sheet)
+	if err != nil {
+		return fmt.Errorf("read sheet: %w", err)
+	}
+
+	start, header, err := xlsx.FindHeader(rows, colSymbol, colType, colVolume, colOpenTime, colOpenPrice, colCloseTime, colClosePrice)
+	if err != nil {
+		return fmt.Errorf("read sheet: %w", err)
+	}
+
+	for i, row := range rows[start+1:] {
+		// The table ends with an empty row followed by the totals.
+		if header.Get(row, colSymbol) == "" {
+			break
+		}
+
+		line := start + i + 2
+
+		cp, err := rr.parsePosition(ctx, header, row)
+		if err != nil {
+			return fmt.Errorf("parse row %d: %w", line, err)
+		}
+
+		cp.line = line
+
+		if rr.matched {
+			rr.records = append(rr.records, cp)
+		} else {
+			buy := cp.Record
+			buy.side = internal.SideBuy
+			buy.timestamp = cp.openTimestamp
+			buy.price = cp.openPrice
+			buy.exchangeRate = cp.openExchangeRate
+			// The statement only has the total commission of the position so we attribute it to the
+			// closing leg which is the one where the expenses are declared.
+			buy.fees = decimal.Decimal{}
+
+			rr.records = append(rr.records, buy, cp.Record)
+		}
+	}
+
+	slices.SortStableFunc(rr.records, func(a, b internal.Record) int {
+		return cmp.Compare(a.Timestamp().UnixNano(), b.Timestamp().UnixNano())
+	})
+
+	return nil
+}
+
+func (rr *RecordReader) parsePosition(ctx context.Context, header xlsx.Header, row []string) (ClosedPosition, error) {
+	if !strings.EqualFold(header.Get(row, colType), TypeBuy) {
+		return ClosedPosition{}, fmt.Errorf("unsupported position type: %s", header.Get(row, colType))
+	}
+
+	symbol, ticker, mkt, err := parseSymbol(header.Get(row, colSymbol))
+	if err != nil {
+		return ClosedPosition{}, err
+	}
+
+	volume, err := decimal.NewFromString(header.Get(row, colVolume))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse volume: %w", err)
+	}
+
+	openPrice, err := decimal.NewFromString(header.Get(row, colOpenPrice))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse open price: %w", err)
+	}
+
+	closePrice, err := decimal.NewFromString(header.Get(row, colClosePrice))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse close price: %w", err)
+	}
+
+	openTime, err := parseTime(header.Get(row, colOpenTime))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse open time: %w", err)
+	}
+
+	closeTime, err := parseTime(header.Get(row, colCloseTime))
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("parse close time: %w", err)
+	}
+
+	var commission decimal.Decimal
+	if raw := header.Get(row, colCommission); raw != "" {
+		commission, err = decimal.NewFromString(raw)
+		if err != nil {
+			return ClosedPosition{}, fmt.Errorf("parse commission: %w", err)
+		}
+	}
+
+	cp := ClosedPosition{
+		Record: Record{
+			symbol:       symbol,
+			timestamp:    closeTime,
+			side:         internal.SideSell,
+			quantity:     volume,
+			price:        closePrice,
+			fees:         commission.Abs(),
+			assetCountry: int64(mkt.country),
+			natureGetter: rr.figi.TickerNatureGetter(ctx, ticker, mkt.exchCode),
+		},
+		openPrice:     openPrice,
+		openTimestamp: openTime,
+	}
+
+	if mkt.currency == euro {
+		return cp, nil
+	}
+
+	rates, ok := rr.rates[mkt.currency]
+	if !ok {
+		return ClosedPosition{}, fmt.Errorf("missing %s exchange rates for symbol: %s", mkt.currency, symbol)
+	}
+
+	cp.openExchangeRate, err = rates.Rate(openTime)
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("convert open price: %w", err)
+	}
+
+	cp.exchangeRate, err = rates.Rate(closeTime)
+	if err != nil {
+		return ClosedPosition{}, fmt.Errorf("convert close price: %w", err)
+	}
+
+	cp.currency = mkt.currency
+	cp.price = closePrice.Div(cp.exchangeRate)
+	cp.openPrice = openPrice.Div(cp.openExchangeRate)
+
+	return cp, nil
+}
+
+func findSheet(names []string) (string, bool) {
+	for _, name := range names {
+		if strings.EqualFold(name, ClosedPositionsSheet) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// parseSymbol splits symbols like AAPL.US or AAPL.US_9 into the ticker and the market. It also
+// returns the symbol without the variant suffix (i.e.: AAPL.US) so every variant of a security
+// shares the same lots.
+func parseSymbol(s string) (string, string, market, error) {
+	s, _, _ = strings.Cut(s, "_")
+
+	ticker, suffix, ok := strings.Cut(s, ".")
+	if !ok {
+		return "", "", market{}, fmt.Errorf("missing market in symbol: %s", s)
+	}
+
+	suffix = strings.ToUpper(suffix)
+
+	mkt, ok := markets[suffix]
+	if !ok {
+		return "", "", market{}, fmt.Errorf("unsupported market in symbol: %s", s)
+	}
+
+	return strings.ToUpper(ticker) + "." + suffix, ticker, mkt, nil
+}
+
+// parseTime accepts both date cells and dates formatted as text.
+func parseTime(s string) (time.Time, error) {
+	ts, err := time.Parse(timeLayout, s)
+	if err == nil {
+		return ts, nil
+	}
+
+	return xlsx.ParseSerialTime(s)
+}
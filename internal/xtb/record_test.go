@@ -0,0 +1,276 @@
+package xtb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/biter777/countries"
+	"github.com/nmoniz/any2anexoj/internal"
+	"github.com/shopspring/decimal"
+)
+
+var testRates = map[string]internal.ExchangeRates{
+	"USD": {
+		Currency: "USD",
+		Rates: map[string]decimal.Decimal{
+			"2024-01-01": decimal.RequireFromString("1.2"),
+			"2024-01-09": decimal.RequireFromString("1.25"),
+		},
+	},
+}
+
+var header = []string{"Position", "Symbol", "Type", "Volume", "Open time", "Open price", "Close time", "Close price", "Open origin", "Close origin", "Purchase value", "Sale value", "Commission", "Swap", "Gross P/L"}
+
+func TestRecordReader_ReadRecord(t *testing.T) {
+	rows := [][]string{
+		{"Name and surname", "John Doe"},
+		{"Account", "12345678"},
+		nil,
+		header,
+		{"1001", "VWCE.DE", "BUY", "2", "02.01.2024 09:00:00", "100", "05.03.2024 10:00:00", "110", "", "", "200", "220", "-0.5", "0", "20"},
+		{"1000", "AAPL.US_9", "BUY", "1.5", "45292.5", "180", "45300", "190", "", "", "270", "285", "", "0", "15"},
+		nil,
+		{"", "", "", "", "", "", "", "", "", "", "470", "505", "-0.5", "0", "35"},
+	}
+
+	t.Run("matched", func(t *testing.T) {
+		rr := NewRecordReader(bytes.NewReader(NewTestStatement(t, rows)), NewFigiClientSecurityTypeStub(t, "ETP"), true, testRates)
+
+		got := readAll(t, rr)
+		if len(got) != 2 {
+			t.Fatalf("want 2 records but got %d", len(got))
+		}
+
+		cp, ok := got[0].(internal.ClosedPosition)
+		if !ok {
+			t.Fatalf("want a ClosedPosition but got %T", got[0])
+		}
+
+		assertRecord(t, cp, "AAPL.US", internal.SideSell, "1.5", "152", "0", time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC))
+		if !cp.OpenPrice().Equal(decimal.RequireFromString("150")) {
+			t.Fatalf("want open price 150 but got %v", cp.OpenPrice())
+		}
+		if currency, rate := internal.ExchangeRateOf(cp); currency != "USD" || !rate.Equal(decimal.RequireFromString("1.25")) {
+			t.Fatalf("want exchange rate 1.25 USD but got %v %s", rate, currency)
+		}
+		if !cp.OpenTimestamp().Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+			t.Fatalf("want open timestamp 2024-01-01 12:00:00 but got %v", cp.OpenTimestamp())
+		}
+		if cp.AssetCountry() != int64(countries.USA) {
+			t.Fatalf("want asset country %d but got %d", countries.USA, cp.AssetCountry())
+		}
+		if cp.Nature() != internal.NatureG20 {
+			t.Fatalf("want nature %v but got %v", internal.NatureG20, cp.Nature())
+		}
+
+		assertRecord(t, got[1], "VWCE.DE", internal.SideSell, "2", "110", "0.5", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC))
+		if currency, _ := internal.ExchangeRateOf(got[1]); currency != "" {
+			t.Fatalf("want no exchange rate for a market in EUR but got %s", currency)
+		}
+	})
+
+	t.Run("individual", func(t *testing.T) {
+		rr := NewRecordReader(bytes.NewReader(NewTestStatement(t, rows)), NewFigiClientSecurityTypeStub(t, "ETP"), false, testRates)
+
+		got := readAll(t, rr)
+		if len(got) != 4 {
+			t.Fatalf("want 4 records but got %d", len(got))
+		}
+
+		for _, rec := range got {
+			if _, ok := rec.(internal.ClosedPosition); ok {
+				t.Fatalf("want plain records but got a ClosedPosition")
+			}
+		}
+
+		assertRecord(t, got[0], "AAPL.US", internal.SideBuy, "1.5", "150", "0", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+		if _, rate := internal.ExchangeRateOf(got[0]); !rate.Equal(decimal.RequireFromString("1.2")) {
+			t.Fatalf("want the exchange rate of the open time but got %v", rate)
+		}
+		assertRecord(t, got[1], "VWCE.DE", internal.SideBuy, "2", "100", "0", time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+		assertRecord(t, got[2], "AAPL.US", internal.SideSell, "1.5", "152", "0", time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC))
+		assertRecord(t, got[3], "VWCE.DE", internal.SideSell, "2", "110", "0.5", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC))
+	})
+}
+
+func TestRecordReader_ReadRecordErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]string
+	}{
+		{"missing header", [][]string{{"Account", "12345678"}}},
+		{"short position", [][]string{header, {"1", "AAPL.US", "SELL", "1", "02.01.2024 09:00:00", "100", "05.03.2024 10:00:00", "110"}}},
+		{"unknown market", [][]string{header, {"1", "AAPL.XX", "BUY", "1", "02.01.2024 09:00:00", "100", "05.03.2024 10:00:00", "110"}}},
+		{"malformed volume", [][]string{header, {"1", "AAPL.US", "BUY", "BAD", "02.01.2024 09:00:00", "100", "05.03.2024 10:00:00", "110"}}},
+		{"malformed open price", [][]string{header, {"1", "AAPL.US", "BUY", "1", "02.01.2024 09:00:00", "", "05.03.2024 10:00:00", "110"}}},
+		{"malformed close price", [][]string{header, {"1", "AAPL.US", "BUY", "1", "02.01.2024 09:00:00", "100", "05.03.2024 10:00:00", "BAD"}}},
+		{"malformed open time", [][]string{header, {"1", "AAPL.US", "BUY", "1", "2024-01-02", "100", "05.03.2024 10:00:00", "110"}}},
+		{"malformed close time", [][]string{header, {"1", "AAPL.US", "BUY", "1", "02.01.2024 09:00:00", "100", "", "110"}}},
+		{"missing exchange rates", [][]string{header, {"1", "NESN.CH", "BUY", "1", "01.01.2024 09:00:00", "100", "09.01.2024 10:00:00", "110"}}},
+		{"missing exchange rate of the day", [][]string{header, {"1", "AAPL.US", "BUY", "1", "01.01.2024 09:00:00", "100", "05.03.2024 10:00:00", "110"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := NewRecordReader(bytes.NewReader(NewTestStatement(t, tt.rows)), NewFigiClientSecurityTypeStub(t, "Common Stock"), true, testRates)
+			_, err := rr.ReadRecord(t.Context())
+			if err == nil || errors.Is(err, io.EOF) {
+				t.Fatalf("want parsing error but got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseSymbol(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantSymbol string
+		wantTicker string
+		wantErr    bool
+	}{
+		{"plain", "AAPL.US", "AAPL.US", "AAPL", false},
+		{"variant", "AAPL.US_9", "AAPL.US", "AAPL", false},
+		{"lower case", "vwce.de_4", "VWCE.DE", "vwce", false},
+		{"missing market", "AAPL", "", "", true},
+		{"unknown market", "AAPL.XX_9", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol, ticker, _, err := parseSymbol(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+
+			if symbol != tt.wantSymbol || ticker != tt.wantTicker {
+				t.Errorf("want %s and %s but got %s and %s", tt.wantSymbol, tt.wantTicker, symbol, ticker)
+			}
+		})
+	}
+}
+
+func readAll(t testing.TB, rr *RecordReader) []internal.Record {
+	t.Helper()
+
+	var records []internal.Record
+	for {
+		rec, err := rr.ReadRecord(t.Context())
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+		if err != nil {
+			t.Fatalf("ReadRecord() failed: %v", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func assertRecord(t testing.TB, got internal.Record, symbol string, side internal.Side, quantity, price, fees string, ts time.Time) {
+	t.Helper()
+
+	if got.Symbol() != symbol {
+		t.Fatalf("want symbol %v but got %v", symbol, got.Symbol())
+	}
+
+	if got.Side() != side {
+		t.Fatalf("want side %v but got %v", side, got.Side())
+	}
+
+	if !got.Quantity().Equal(decimal.RequireFromString(quantity)) {
+		t.Fatalf("want quantity %v but got %v", quantity, got.Quantity())
+	}
+
+	if !got.Price().Equal(decimal.RequireFromString(price)) {
+		t.Fatalf("want price %v but got %v", price, got.Price())
+	}
+
+	if !got.Fees().Equal(decimal.RequireFromString(fees)) {
+		t.Fatalf("want fees %v but got %v", fees, got.Fees())
+	}
+
+	if !got.Timestamp().Equal(ts) {
+		t.Fatalf("want timestamp %v but got %v", ts, got.Timestamp())
+	}
+
+	if got.BrokerCountry() != int64(Country) {
+		t.Fatalf("want broker country %v but got %v", int64(Country), got.BrokerCountry())
+	}
+}
+
+// NewTestStatement builds a minimal workbook with a closed positions sheet containing rows.
+func NewTestStatement(t testing.TB, rows [][]string) []byte {
+	t.Helper()
+
+	var sheet strings.Builder
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, val := range row {
+			if val == "" {
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%c%d" t="inlineStr"><is><t>`, 'A'+j, i+1)
+			_ = xml.EscapeText(&sheet, []byte(val))
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="CLOSED POSITION HISTORY" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   sheet.String(),
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		_, err = w.Write([]byte(content))
+		if err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("close zip writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func NewFigiClientSecurityTypeStub(t testing.TB, securityType string) *internal.OpenFIGI {
+	t.Helper()
+
+	c := &http.Client{
+		Timeout: time.Second,
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Status:     http.StatusText(http.StatusOK),
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`[{"data":[{"securityType":%q}]}]`, securityType))),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	return internal.NewOpenFIGI(c)
+}